@@ -20,6 +20,11 @@ func Default() UserFeatures {
 		CognitiveAccount: CognitiveAccountFeatures{
 			PurgeSoftDeleteOnDestroy: true,
 		},
+		HDInsight: HDInsightFeatures{
+			StrictValidation: false,
+			PreventReplacementOnComponentVersionChange:               true,
+			PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+		},
 		KeyVault: KeyVaultFeatures{
 			PurgeSoftDeleteOnDestroy:         true,
 			PurgeSoftDeletedKeysOnDestroy:    true,
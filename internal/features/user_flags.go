@@ -8,6 +8,7 @@ type UserFeatures struct {
 	AppConfiguration       AppConfigurationFeatures
 	ApplicationInsights    ApplicationInsightFeatures
 	CognitiveAccount       CognitiveAccountFeatures
+	HDInsight              HDInsightFeatures
 	VirtualMachine         VirtualMachineFeatures
 	VirtualMachineScaleSet VirtualMachineScaleSetFeatures
 	KeyVault               KeyVaultFeatures
@@ -79,3 +80,9 @@ type AppConfigurationFeatures struct {
 type SubscriptionFeatures struct {
 	PreventCancellationOnDestroy bool
 }
+
+type HDInsightFeatures struct {
+	StrictValidation                                         bool
+	PreventReplacementOnComponentVersionChange               bool
+	PreventDeletionIfDefaultStorageContainerCreatedByCluster bool
+}
@@ -88,6 +88,31 @@ func schemaFeatures(supportLegacyTestSuite bool) *pluginsdk.Schema {
 			},
 		},
 
+		"hdinsight": {
+			Type:     pluginsdk.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &pluginsdk.Resource{
+				Schema: map[string]*pluginsdk.Schema{
+					"strict_validation": {
+						Type:     pluginsdk.TypeBool,
+						Optional: true,
+						Default:  false,
+					},
+					"prevent_replacement_on_component_version_change": {
+						Type:     pluginsdk.TypeBool,
+						Optional: true,
+						Default:  true,
+					},
+					"prevent_deletion_if_default_storage_container_created_by_cluster": {
+						Type:     pluginsdk.TypeBool,
+						Optional: true,
+						Default:  false,
+					},
+				},
+			},
+		},
+
 		"key_vault": {
 			Type:     pluginsdk.TypeList,
 			Optional: true,
@@ -365,6 +390,22 @@ func expandFeatures(input []interface{}) features.UserFeatures {
 		}
 	}
 
+	if raw, ok := val["hdinsight"]; ok {
+		items := raw.([]interface{})
+		if len(items) > 0 && items[0] != nil {
+			hdinsightRaw := items[0].(map[string]interface{})
+			if v, ok := hdinsightRaw["strict_validation"]; ok {
+				featuresMap.HDInsight.StrictValidation = v.(bool)
+			}
+			if v, ok := hdinsightRaw["prevent_replacement_on_component_version_change"]; ok {
+				featuresMap.HDInsight.PreventReplacementOnComponentVersionChange = v.(bool)
+			}
+			if v, ok := hdinsightRaw["prevent_deletion_if_default_storage_container_created_by_cluster"]; ok {
+				featuresMap.HDInsight.PreventDeletionIfDefaultStorageContainerCreatedByCluster = v.(bool)
+			}
+		}
+	}
+
 	if raw, ok := val["key_vault"]; ok {
 		items := raw.([]interface{})
 		if len(items) > 0 && items[0] != nil {
@@ -35,6 +35,11 @@ func TestExpandFeatures(t *testing.T) {
 				CognitiveAccount: features.CognitiveAccountFeatures{
 					PurgeSoftDeleteOnDestroy: true,
 				},
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: false,
+					PreventReplacementOnComponentVersionChange:               true,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+				},
 				KeyVault: features.KeyVaultFeatures{
 					PurgeSoftDeletedCertsOnDestroy:   true,
 					PurgeSoftDeletedKeysOnDestroy:    true,
@@ -99,6 +104,13 @@ func TestExpandFeatures(t *testing.T) {
 							"purge_soft_delete_on_destroy": true,
 						},
 					},
+					"hdinsight": []interface{}{
+						map[string]interface{}{
+							"strict_validation": true,
+							"prevent_replacement_on_component_version_change":                  true,
+							"prevent_deletion_if_default_storage_container_created_by_cluster": true,
+						},
+					},
 					"key_vault": []interface{}{
 						map[string]interface{}{
 							"purge_soft_deleted_certificates_on_destroy":              true,
@@ -168,6 +180,11 @@ func TestExpandFeatures(t *testing.T) {
 				CognitiveAccount: features.CognitiveAccountFeatures{
 					PurgeSoftDeleteOnDestroy: true,
 				},
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: true,
+					PreventReplacementOnComponentVersionChange:               true,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: true,
+				},
 				KeyVault: features.KeyVaultFeatures{
 					PurgeSoftDeletedCertsOnDestroy:   true,
 					PurgeSoftDeletedKeysOnDestroy:    true,
@@ -232,6 +249,13 @@ func TestExpandFeatures(t *testing.T) {
 							"purge_soft_delete_on_destroy": false,
 						},
 					},
+					"hdinsight": []interface{}{
+						map[string]interface{}{
+							"strict_validation": false,
+							"prevent_replacement_on_component_version_change":                  false,
+							"prevent_deletion_if_default_storage_container_created_by_cluster": false,
+						},
+					},
 					"key_vault": []interface{}{
 						map[string]interface{}{
 							"purge_soft_deleted_certificates_on_destroy":              false,
@@ -301,6 +325,11 @@ func TestExpandFeatures(t *testing.T) {
 				CognitiveAccount: features.CognitiveAccountFeatures{
 					PurgeSoftDeleteOnDestroy: false,
 				},
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: false,
+					PreventReplacementOnComponentVersionChange:               false,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+				},
 				KeyVault: features.KeyVaultFeatures{
 					PurgeSoftDeletedCertsOnDestroy:   false,
 					PurgeSoftDeletedKeysOnDestroy:    false,
@@ -1263,3 +1292,120 @@ func TestExpandFeaturesSubscription(t *testing.T) {
 		}
 	}
 }
+
+func TestExpandFeaturesHDInsight(t *testing.T) {
+	testData := []struct {
+		Name     string
+		Input    []interface{}
+		EnvVars  map[string]interface{}
+		Expected features.UserFeatures
+	}{
+		{
+			Name: "Empty Block",
+			Input: []interface{}{
+				map[string]interface{}{
+					"hdinsight": []interface{}{},
+				},
+			},
+			Expected: features.UserFeatures{
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: false,
+					PreventReplacementOnComponentVersionChange:               true,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+				},
+			},
+		},
+		{
+			Name: "Strict Validation Enabled",
+			Input: []interface{}{
+				map[string]interface{}{
+					"hdinsight": []interface{}{
+						map[string]interface{}{
+							"strict_validation": true,
+							"prevent_replacement_on_component_version_change":                  true,
+							"prevent_deletion_if_default_storage_container_created_by_cluster": false,
+						},
+					},
+				},
+			},
+			Expected: features.UserFeatures{
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: true,
+					PreventReplacementOnComponentVersionChange:               true,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+				},
+			},
+		},
+		{
+			Name: "Strict Validation Disabled",
+			Input: []interface{}{
+				map[string]interface{}{
+					"hdinsight": []interface{}{
+						map[string]interface{}{
+							"strict_validation": false,
+							"prevent_replacement_on_component_version_change":                  true,
+							"prevent_deletion_if_default_storage_container_created_by_cluster": false,
+						},
+					},
+				},
+			},
+			Expected: features.UserFeatures{
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: false,
+					PreventReplacementOnComponentVersionChange:               true,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+				},
+			},
+		},
+		{
+			Name: "Prevent Replacement On Component Version Change Disabled",
+			Input: []interface{}{
+				map[string]interface{}{
+					"hdinsight": []interface{}{
+						map[string]interface{}{
+							"strict_validation": false,
+							"prevent_replacement_on_component_version_change":                  false,
+							"prevent_deletion_if_default_storage_container_created_by_cluster": false,
+						},
+					},
+				},
+			},
+			Expected: features.UserFeatures{
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: false,
+					PreventReplacementOnComponentVersionChange:               false,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: false,
+				},
+			},
+		},
+		{
+			Name: "Prevent Deletion If Default Storage Container Created By Cluster Enabled",
+			Input: []interface{}{
+				map[string]interface{}{
+					"hdinsight": []interface{}{
+						map[string]interface{}{
+							"strict_validation": false,
+							"prevent_replacement_on_component_version_change":                  true,
+							"prevent_deletion_if_default_storage_container_created_by_cluster": true,
+						},
+					},
+				},
+			},
+			Expected: features.UserFeatures{
+				HDInsight: features.HDInsightFeatures{
+					StrictValidation: false,
+					PreventReplacementOnComponentVersionChange:               true,
+					PreventDeletionIfDefaultStorageContainerCreatedByCluster: true,
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testData {
+		t.Logf("[DEBUG] Test Case: %q", testCase.Name)
+		result := expandFeatures(testCase.Input)
+		if !reflect.DeepEqual(result.HDInsight, testCase.Expected.HDInsight) {
+			t.Fatalf("Expected %+v but got %+v", result.HDInsight, testCase.Expected.HDInsight)
+		}
+	}
+}
@@ -94,6 +94,12 @@ func TestResourcesSupportCustomTimeouts(t *testing.T) {
 					"azurerm_key_vault_key":         true,
 					"azurerm_key_vault_secret":      true,
 					"azurerm_key_vault_certificate": true,
+					// reading the HDInsight clusters makes several sequential calls to the API, which can exceed 5 minutes on large clusters.
+					"azurerm_hdinsight_hadoop_cluster":            true,
+					"azurerm_hdinsight_hbase_cluster":             true,
+					"azurerm_hdinsight_interactive_query_cluster": true,
+					"azurerm_hdinsight_kafka_cluster":             true,
+					"azurerm_hdinsight_spark_cluster":             true,
 				}
 				if !exceptionResources[resourceName] {
 					t.Fatalf("Read timeouts shouldn't be more than 5 minutes, this indicates a bug which needs to be fixed")
@@ -9,10 +9,14 @@ import (
 )
 
 type Client struct {
-	ApplicationsClient   *hdinsight.ApplicationsClient
-	ClustersClient       *hdinsight.ClustersClient
-	ConfigurationsClient *hdinsight.ConfigurationsClient
-	ExtensionsClient     *hdinsight.ExtensionsClient
+	ApplicationsClient           *hdinsight.ApplicationsClient
+	ClustersClient               *hdinsight.ClustersClient
+	ConfigurationsClient         *hdinsight.ConfigurationsClient
+	ExtensionsClient             *hdinsight.ExtensionsClient
+	LocationsClient              *hdinsight.LocationsClient
+	ScriptActionsClient          *hdinsight.ScriptActionsClient
+	ScriptExecutionHistoryClient *hdinsight.ScriptExecutionHistoryClient
+	VirtualMachinesClient        *hdinsight.VirtualMachinesClient
 }
 
 func NewClient(o *common.ClientOptions) *Client {
@@ -32,11 +36,27 @@ func NewClient(o *common.ClientOptions) *Client {
 	ExtensionsClient := hdinsight.NewExtensionsClientWithBaseURI(opts.ResourceManagerEndpoint, opts.SubscriptionId)
 	opts.ConfigureClient(&ExtensionsClient.Client, opts.ResourceManagerAuthorizer)
 
+	LocationsClient := hdinsight.NewLocationsClientWithBaseURI(opts.ResourceManagerEndpoint, opts.SubscriptionId)
+	opts.ConfigureClient(&LocationsClient.Client, opts.ResourceManagerAuthorizer)
+
+	ScriptActionsClient := hdinsight.NewScriptActionsClientWithBaseURI(opts.ResourceManagerEndpoint, opts.SubscriptionId)
+	opts.ConfigureClient(&ScriptActionsClient.Client, opts.ResourceManagerAuthorizer)
+
+	ScriptExecutionHistoryClient := hdinsight.NewScriptExecutionHistoryClientWithBaseURI(opts.ResourceManagerEndpoint, opts.SubscriptionId)
+	opts.ConfigureClient(&ScriptExecutionHistoryClient.Client, opts.ResourceManagerAuthorizer)
+
+	VirtualMachinesClient := hdinsight.NewVirtualMachinesClientWithBaseURI(opts.ResourceManagerEndpoint, opts.SubscriptionId)
+	opts.ConfigureClient(&VirtualMachinesClient.Client, opts.ResourceManagerAuthorizer)
+
 	c := &Client{
-		ApplicationsClient:   &ApplicationsClient,
-		ClustersClient:       &ClustersClient,
-		ConfigurationsClient: &ConfigurationsClient,
-		ExtensionsClient:     &ExtensionsClient,
+		ApplicationsClient:           &ApplicationsClient,
+		ClustersClient:               &ClustersClient,
+		ConfigurationsClient:         &ConfigurationsClient,
+		ExtensionsClient:             &ExtensionsClient,
+		LocationsClient:              &LocationsClient,
+		ScriptActionsClient:          &ScriptActionsClient,
+		ScriptExecutionHistoryClient: &ScriptExecutionHistoryClient,
+		VirtualMachinesClient:        &VirtualMachinesClient,
 	}
 
 	return c
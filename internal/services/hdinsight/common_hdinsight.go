@@ -5,13 +5,29 @@ package hdinsight
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/identity"
+	"github.com/hashicorp/go-azure-sdk/sdk/environments"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	keyvaultClient "github.com/hashicorp/terraform-provider-azurerm/internal/services/keyvault/client"
+	keyvaultParse "github.com/hashicorp/terraform-provider-azurerm/internal/services/keyvault/parse"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/tags"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
@@ -33,13 +49,30 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 		resourceGroup := id.ResourceGroup
 		name := id.Name
 
-		if d.HasChange("tags") {
-			t := d.Get("tags").(map[string]interface{})
-			params := hdinsight.ClusterPatchParameters{
-				Tags: tags.Expand(t),
+		// each aspect below is updated using a separate API call - should one of these fail the changes to the
+		// remaining aspects mustn't be persisted into the state, otherwise they'd be skipped on the next apply
+		d.Partial(true)
+
+		if d.HasChanges("tags", "node_tags") {
+			if err := updateHDInsightClusterTags(ctx, client, clusterKind, resourceGroup, name, expandHDInsightClusterTags(d)); err != nil {
+				return err
 			}
-			if _, err := client.Update(ctx, resourceGroup, name, params); err != nil {
-				return fmt.Errorf("updating Tags for HDInsight %q Cluster %q (Resource Group %q): %+v", clusterKind, name, resourceGroup, err)
+		}
+
+		// the other fields within `storage_account` are ForceNew, so only `storage_account_key` can change here - which the
+		// API doesn't support updating once the cluster exists
+		if d.HasChange("storage_account") {
+			log.Printf("[WARN] the `storage_account_key` of the HDInsight %q Cluster %q (Resource Group %q) has changed - the cluster continues to use the key it was provisioned with until `fs.azure.account.key.<account>.blob.core.windows.net` is updated in `core-site` (for example using Ambari)", clusterKind, name, resourceGroup)
+		}
+
+		// resizing the cluster and changing its edge nodes are rejected (or fail part-way through) unless it's running
+		healthGatedChanges := []string{"roles.0.worker_node"}
+		if hdinsightClusterKindEqual(clusterKind, "Hadoop") || hdinsightClusterKindEqual(clusterKind, "Spark") {
+			healthGatedChanges = append(healthGatedChanges, "roles.0.edge_node")
+		}
+		if d.HasChanges(healthGatedChanges...) {
+			if err := waitForHDInsightClusterRunning(ctx, client, clusterKind, resourceGroup, name, d.Timeout(pluginsdk.TimeoutUpdate)); err != nil {
+				return err
 			}
 		}
 
@@ -49,6 +82,22 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 			roles := rolesRaw[0].(map[string]interface{})
 			workerNodes := roles["worker_node"].([]interface{})
 			workerNode := workerNodes[0].(map[string]interface{})
+
+			// when autoscale is being disabled this needs to happen before resizing the cluster, since the API rejects
+			// resizing the cluster whilst autoscale is enabled - whereas when it's being enabled the cluster is resized first
+			autoscaleChanged := d.HasChange("roles.0.worker_node.0.autoscale")
+			// Kafka clusters don't support autoscale, so the block isn't present
+			autoscaleRaw, _ := workerNode["autoscale"].([]interface{})
+			autoscale := ExpandHDInsightNodeAutoScaleDefinition(autoscaleRaw)
+			oldAutoscaleRaw, _ := d.GetChange("roles.0.worker_node.0.autoscale")
+			existingAutoscale, _ := oldAutoscaleRaw.([]interface{})
+			previousMode := hdinsightAutoscaleMode(ExpandHDInsightNodeAutoScaleDefinition(existingAutoscale))
+			if autoscaleChanged && autoscale == nil {
+				if err := updateHDInsightClusterAutoscale(ctx, client, clusterKind, resourceGroup, name, previousMode, autoscale); err != nil {
+					return err
+				}
+			}
+
 			if d.HasChange("roles.0.worker_node.0.target_instance_count") {
 				targetInstanceCount := workerNode["target_instance_count"].(int)
 				params := hdinsight.ClusterResizeParameters{
@@ -57,6 +106,11 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 
 				future, err := client.Resize(ctx, resourceGroup, name, params)
 				if err != nil {
+					// the API doesn't expose which operation is in progress, however this is most likely autoscale or a resize made outside of Terraform
+					if utils.ResponseWasConflict(autorest.Response{Response: future.Response()}) {
+						return fmt.Errorf("resizing the HDInsight %q Cluster %q (Resource Group %q): another operation (such as autoscale, or a resize made outside of Terraform) is in progress on the cluster - retry once this has completed: %+v", clusterKind, name, resourceGroup, err)
+					}
+
 					return fmt.Errorf("resizing the HDInsight %q Cluster %q (Resource Group %q): %+v", clusterKind, name, resourceGroup, err)
 				}
 
@@ -65,64 +119,40 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 				}
 			}
 
-			if d.HasChange("roles.0.worker_node.0.autoscale") {
-				autoscale := ExpandHDInsightNodeAutoScaleDefinition(workerNode["autoscale"].([]interface{}))
-				params := hdinsight.AutoscaleConfigurationUpdateParameter{
-					Autoscale: autoscale,
-				}
-
-				future, err := client.UpdateAutoScaleConfiguration(ctx, resourceGroup, name, params)
-				if err != nil {
-					return fmt.Errorf("changing autoscale of the HDInsight %q Cluster %q (Resource Group %q): %+v", clusterKind, name, resourceGroup, err)
-				}
-
-				if err = future.WaitForCompletionRef(ctx, client.Client); err != nil {
-					return fmt.Errorf("waiting for changing autoscale of the HDInsight %q Cluster %q (Resource Group %q) to finish resizing: %+v", clusterKind, name, resourceGroup, err)
+			if autoscaleChanged && autoscale != nil {
+				if err := updateHDInsightClusterAutoscale(ctx, client, clusterKind, resourceGroup, name, previousMode, autoscale); err != nil {
+					return err
 				}
 			}
 		}
 
-		// The API can add an edge node but can't remove them without force newing the pluginsdk. We'll check for adding here
-		// and can come back to removing if that functionality gets added. https://feedback.azure.com/forums/217335-hdinsight/suggestions/5663773-start-stop-cluster-hdinsight?page=3&per_page=20
-		if clusterKind == "Hadoop" {
+		// Edge nodes are deployed as HDInsight Applications, which can be added, resized and removed but not otherwise
+		// updated - so only the edge nodes which changed are resized or recreated, leaving the cluster and the other edge
+		// nodes alone
+		if hdinsightClusterKindEqual(clusterKind, "Hadoop") || hdinsightClusterKindEqual(clusterKind, "Spark") {
 			if d.HasChange("roles.0.edge_node") {
 				log.Printf("[DEBUG] Detected change in edge nodes")
-				edgeNodeRaw := d.Get("roles.0.edge_node").([]interface{})
-				edgeNodeConfig := edgeNodeRaw[0].(map[string]interface{})
 				applicationsClient := meta.(*clients.Client).HDInsight.ApplicationsClient
 
-				oldEdgeNodeCount, newEdgeNodeCount := d.GetChange("roles.0.edge_node.0.target_instance_count")
-				oldEdgeNodeInt := oldEdgeNodeCount.(int)
-				newEdgeNodeInt := newEdgeNodeCount.(int)
+				oldEdgeNodes, newEdgeNodes := d.GetChange("roles.0.edge_node")
+				toDelete, toResize, toCreate := hdinsightEdgeNodeChanges(name, oldEdgeNodes.([]interface{}), newEdgeNodes.([]interface{}))
 
-				// Note: API currently doesn't support updating number of edge nodes
-				// if anything in the edge nodes changes, delete edge nodes then recreate them
-				if oldEdgeNodeInt != 0 {
-					err := deleteHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name)
-					if err != nil {
+				for _, applicationName := range toDelete {
+					if err := deleteHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name, applicationName); err != nil {
 						return err
 					}
 				}
 
-				if newEdgeNodeInt != 0 {
-					err = createHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name, edgeNodeConfig)
-					if err != nil {
+				for _, edgeNodeConfig := range toResize {
+					if err := resizeHDInsightClusterEdgeNode(ctx, meta, clusterKind, resourceGroup, name, edgeNodeConfig, d.Timeout(pluginsdk.TimeoutUpdate)); err != nil {
 						return err
 					}
 				}
 
-				// we can't rely on the use of the Future here due to the node being successfully completed but now the cluster is applying those changes.
-				log.Printf("[DEBUG] Waiting for Hadoop Cluster to %q (Resource Group %q) to finish applying edge node", name, resourceGroup)
-				stateConf := &pluginsdk.StateChangeConf{
-					Pending:    []string{"AzureVMConfiguration", "Accepted", "HdInsightConfiguration"},
-					Target:     []string{"Running"},
-					Refresh:    hdInsightWaitForReadyRefreshFunc(ctx, client, resourceGroup, name),
-					MinTimeout: 15 * time.Second,
-					Timeout:    d.Timeout(pluginsdk.TimeoutUpdate),
-				}
-
-				if _, err := stateConf.WaitForStateContext(ctx); err != nil {
-					return fmt.Errorf("waiting for HDInsight Cluster %q (Resource Group %q) to be running: %s", name, resourceGroup, err)
+				for _, edgeNodeConfig := range toCreate {
+					if err := addHDInsightClusterEdgeNode(ctx, meta, clusterKind, resourceGroup, name, edgeNodeConfig, d.Timeout(pluginsdk.TimeoutUpdate)); err != nil {
+						return err
+					}
 				}
 			}
 		}
@@ -140,6 +170,10 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 		}
 		if d.HasChange("extension") {
 			log.Printf("[DEBUG] Change Azure Monitor for the HDInsight %q Cluster", clusterKind)
+			if d.HasChange("extension.0.primary_key") && !d.HasChange("extension.0.log_analytics_workspace_id") {
+				// the API supports re-enabling Azure Monitor with the rotated key for the same workspace
+				log.Printf("[DEBUG] Rotating the Log Analytics Workspace key for Azure Monitor on the HDInsight %q Cluster", clusterKind)
+			}
 			if v, ok := d.GetOk("extension"); ok {
 				extensionRaw := v.([]interface{})
 				if err := enableHDInsightAzureMonitor(ctx, extensionsClient, resourceGroup, name, extensionRaw); err != nil {
@@ -149,11 +183,17 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 				return err
 			}
 		}
-		if d.HasChange("gateway") {
+		// NOTE: the username can't be changed once the cluster exists (see `hdinsightClusterGatewayUsernameDiff`) so is
+		// only sent along since the API requires it
+		if d.HasChanges("gateway.0.password", "gateway.0.password_key_vault_secret_id", "gateway.0.enabled") {
 			log.Printf("[DEBUG] Updating the HDInsight %q Cluster gateway", clusterKind)
-			vs := d.Get("gateway").([]interface{})[0].(map[string]interface{})
+			gatewayRaw := d.Get("gateway").([]interface{})
+			if err := expandHDInsightKeyVaultPasswords(gatewayRaw, hdinsightKeyVaultSecretLookup(ctx, meta.(*clients.Client).KeyVault)); err != nil {
+				return fmt.Errorf("expanding `gateway`: %+v", err)
+			}
+			vs := gatewayRaw[0].(map[string]interface{})
 
-			enabled := true
+			enabled := vs["enabled"].(bool)
 			username := vs["username"].(string)
 			password := vs["password"].(string)
 
@@ -171,10 +211,208 @@ func hdinsightClusterUpdate(clusterKind string, readFunc pluginsdk.ReadFunc) plu
 			}
 		}
 
+		d.Partial(false)
+
 		return readFunc(d, meta)
 	}
 }
 
+// updateHDInsightClusterTags patches the tags of the cluster. Unlike the other updates this doesn't depend on the state
+// of the cluster - ARM accepts the patch whilst the cluster is Failed or is being updated - so that tags can be applied
+// to clusters which are temporarily unhealthy.
+func updateHDInsightClusterTags(ctx context.Context, client *hdinsight.ClustersClient, clusterKind, resourceGroup, name string, tags map[string]*string) error {
+	params := hdinsight.ClusterPatchParameters{
+		Tags: tags,
+	}
+	if _, err := client.Update(ctx, resourceGroup, name, params); err != nil {
+		return fmt.Errorf("updating Tags for HDInsight %q Cluster %q (Resource Group %q): %+v", clusterKind, name, resourceGroup, err)
+	}
+
+	return nil
+}
+
+// hdInsightClusterTransitionalStates are the values of `clusterState` whilst the cluster is applying a change, after
+// which it returns to `Running`
+var hdInsightClusterTransitionalStates = []string{"AzureVMConfiguration", "Accepted", "HdInsightConfiguration"}
+
+// waitForHDInsightClusterRunning is the health gate run before the cluster is resized or its edge nodes are changed,
+// waiting for any change which is already being applied to finish and failing fast when the cluster is unhealthy -
+// rather than the API rejecting the change, or the change failing part-way through
+func waitForHDInsightClusterRunning(ctx context.Context, client *hdinsight.ClustersClient, clusterKind, resourceGroup, name string, timeout time.Duration) error {
+	resp, err := client.Get(ctx, resourceGroup, name)
+	if err != nil {
+		return fmt.Errorf("retrieving HDInsight %q Cluster %q (Resource Group %q) to check it's running: %+v", clusterKind, name, resourceGroup, err)
+	}
+
+	pending, err := hdinsightClusterHealthGate(resp.Properties)
+	if err != nil {
+		return fmt.Errorf("the HDInsight %q Cluster %q (Resource Group %q) can only be changed once it's running - %+v", clusterKind, name, resourceGroup, err)
+	}
+	if !pending {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Waiting for HDInsight %q Cluster %q (Resource Group %q) to finish applying a previous change", clusterKind, name, resourceGroup)
+	stateConf := &pluginsdk.StateChangeConf{
+		Pending:    hdInsightClusterTransitionalStates,
+		Target:     []string{"Running"},
+		Refresh:    hdInsightWaitForReadyRefreshFunc(ctx, client, resourceGroup, name),
+		MinTimeout: 15 * time.Second,
+		Timeout:    timeout,
+	}
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for HDInsight %q Cluster %q (Resource Group %q) to be running: %s", clusterKind, name, resourceGroup, err)
+	}
+
+	return nil
+}
+
+// hdinsightClusterHealthGate returns whether the cluster is still applying a change, or an error when it's in a state
+// it won't recover from by itself. This uses the `clusterState` reported by HDInsight rather than the ARM
+// `provisioningState`, which only reflects the last operation made through ARM - and so remains `Succeeded` whilst
+// the cluster itself is in the `Error` state.
+func hdinsightClusterHealthGate(props *hdinsight.ClusterGetProperties) (bool, error) {
+	if props == nil || props.ClusterState == nil || *props.ClusterState == "" {
+		// the state isn't known, so it's left to the API to reject the change
+		return false, nil
+	}
+
+	clusterState := *props.ClusterState
+	if strings.EqualFold(clusterState, "Running") {
+		return false, nil
+	}
+
+	for _, v := range hdInsightClusterTransitionalStates {
+		if strings.EqualFold(clusterState, v) {
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("the cluster state is %q (provisioning state %q)", clusterState, string(props.ProvisioningState))
+}
+
+// updateHDInsightClusterAutoscale updates the autoscale configuration of the Worker Nodes in-place, disabling autoscale
+// when `autoscale` is nil. The API replaces the whole configuration, which allows switching between capacity and
+// recurrence autoscale - `previousMode` is used to confirm the previous configuration has been replaced when doing so.
+func updateHDInsightClusterAutoscale(ctx context.Context, client *hdinsight.ClustersClient, clusterKind, resourceGroup, name, previousMode string, autoscale *hdinsight.Autoscale) error {
+	params := hdinsight.AutoscaleConfigurationUpdateParameter{
+		Autoscale: autoscale,
+	}
+	if autoscale == nil {
+		// a nil autoscale is omitted from the request body, which the API treats as no change - so an empty autoscale
+		// configuration is sent explicitly to disable it
+		params.Autoscale = &hdinsight.Autoscale{}
+	}
+
+	mode := hdinsightAutoscaleMode(autoscale)
+	switching := previousMode != "" && mode != "" && previousMode != mode
+	if switching {
+		log.Printf("[DEBUG] Switching the HDInsight %q Cluster %q (Resource Group %q) from %s autoscale to %s autoscale", clusterKind, name, resourceGroup, previousMode, mode)
+	}
+
+	future, err := client.UpdateAutoScaleConfiguration(ctx, resourceGroup, name, params)
+	if err != nil {
+		return fmt.Errorf("changing autoscale of the HDInsight %q Cluster %q (Resource Group %q): %+v", clusterKind, name, resourceGroup, err)
+	}
+
+	if err = future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting for changing autoscale of the HDInsight %q Cluster %q (Resource Group %q) to finish: %+v", clusterKind, name, resourceGroup, err)
+	}
+
+	if autoscale != nil && !switching {
+		return nil
+	}
+
+	resp, err := client.Get(ctx, resourceGroup, name)
+	if err != nil {
+		return fmt.Errorf("retrieving HDInsight %q Cluster %q (Resource Group %q) to confirm autoscale was changed: %+v", clusterKind, name, resourceGroup, err)
+	}
+	if autoscale == nil {
+		if hdinsightClusterWorkerNodeAutoscaleEnabled(resp.Properties) {
+			return fmt.Errorf("disabling autoscale of the HDInsight %q Cluster %q (Resource Group %q): the autoscale configuration of the Worker Nodes is still present", clusterKind, name, resourceGroup)
+		}
+		return nil
+	}
+	if actual := hdinsightAutoscaleMode(hdinsightClusterWorkerNodeAutoscale(resp.Properties)); actual != mode {
+		return fmt.Errorf("switching the HDInsight %q Cluster %q (Resource Group %q) from %s autoscale to %s autoscale: the Worker Nodes are using %q autoscale", clusterKind, name, resourceGroup, previousMode, mode, actual)
+	}
+
+	return nil
+}
+
+// hdinsightClusterWorkerNodeAutoscaleEnabled returns whether the Worker Nodes of the cluster have either a capacity or
+// a recurrence autoscale configuration - the API can return an empty configuration once autoscale has been disabled
+func hdinsightClusterWorkerNodeAutoscaleEnabled(props *hdinsight.ClusterGetProperties) bool {
+	return hdinsightAutoscaleMode(hdinsightClusterWorkerNodeAutoscale(props)) != ""
+}
+
+func hdinsightClusterWorkerNodeAutoscale(props *hdinsight.ClusterGetProperties) *hdinsight.Autoscale {
+	if props == nil || props.ComputeProfile == nil {
+		return nil
+	}
+
+	workerNode := FindHDInsightRole(props.ComputeProfile.Roles, "workernode")
+	if workerNode == nil {
+		return nil
+	}
+
+	return workerNode.AutoscaleConfiguration
+}
+
+// hdinsightClusterMaxWorkerCount returns the highest number of Worker Nodes the cluster can be scaled to - the maximum
+// of the capacity autoscale, the highest target of the recurrence autoscale schedules, or otherwise the target instance
+// count (since the cluster isn't scaled automatically)
+func hdinsightClusterMaxWorkerCount(props *hdinsight.ClusterGetProperties) int {
+	if props == nil || props.ComputeProfile == nil {
+		return 0
+	}
+
+	workerNode := FindHDInsightRole(props.ComputeProfile.Roles, "workernode")
+	if workerNode == nil {
+		return 0
+	}
+
+	maxCount := 0
+	if autoscale := workerNode.AutoscaleConfiguration; autoscale != nil {
+		if autoscale.Capacity != nil && autoscale.Capacity.MaxInstanceCount != nil {
+			maxCount = int(*autoscale.Capacity.MaxInstanceCount)
+		}
+
+		if autoscale.Recurrence != nil && autoscale.Recurrence.Schedule != nil {
+			for _, schedule := range *autoscale.Recurrence.Schedule {
+				if schedule.TimeAndCapacity != nil && schedule.TimeAndCapacity.MaxInstanceCount != nil && int(*schedule.TimeAndCapacity.MaxInstanceCount) > maxCount {
+					maxCount = int(*schedule.TimeAndCapacity.MaxInstanceCount)
+				}
+			}
+		}
+	}
+
+	if maxCount == 0 && workerNode.TargetInstanceCount != nil {
+		maxCount = int(*workerNode.TargetInstanceCount)
+	}
+
+	return maxCount
+}
+
+// hdinsightAutoscaleMode returns which kind of autoscale the configuration uses, or an empty string when autoscale is
+// disabled
+func hdinsightAutoscaleMode(input *hdinsight.Autoscale) string {
+	if input == nil {
+		return ""
+	}
+
+	switch {
+	case input.Capacity != nil && input.Recurrence != nil:
+		return "capacity and recurrence"
+	case input.Capacity != nil:
+		return "capacity"
+	case input.Recurrence != nil:
+		return "recurrence"
+	}
+
+	return ""
+}
+
 func hdinsightClusterDelete(clusterKind string) pluginsdk.DeleteFunc {
 	return func(d *pluginsdk.ResourceData, meta interface{}) error {
 		client := meta.(*clients.Client).HDInsight.ClustersClient
@@ -189,6 +427,20 @@ func hdinsightClusterDelete(clusterKind string) pluginsdk.DeleteFunc {
 		resourceGroup := id.ResourceGroup
 		name := id.Name
 
+		createdByCluster := d.Get("default_storage_container_created_by_cluster").(bool)
+		preventDeletion := meta.(*clients.Client).Features.HDInsight.PreventDeletionIfDefaultStorageContainerCreatedByCluster
+		if err := checkHDInsightClusterDefaultStorageBeforeDelete(clusterKind, *id, createdByCluster, preventDeletion); err != nil {
+			return err
+		}
+
+		if exportPath := d.Get("configuration_export_on_destroy_path").(string); exportPath != "" {
+			fileName, err := exportHDInsightClusterConfiguration(ctx, meta, *id, exportPath)
+			if err != nil {
+				return fmt.Errorf("exporting the configuration of HDInsight %q Cluster %q (Resource Group %q) to %q - the cluster hasn't been deleted: %+v", clusterKind, name, resourceGroup, exportPath, err)
+			}
+			log.Printf("[INFO] exported the configuration of HDInsight %q Cluster %q (Resource Group %q) to %q", clusterKind, name, resourceGroup, fileName)
+		}
+
 		future, err := client.Delete(ctx, resourceGroup, name)
 		if err != nil {
 			return fmt.Errorf("deleting HDInsight %q Cluster %q (Resource Group %q): %+v", clusterKind, name, resourceGroup, err)
@@ -202,6 +454,74 @@ func hdinsightClusterDelete(clusterKind string) pluginsdk.DeleteFunc {
 	}
 }
 
+// checkHDInsightClusterDefaultStorageBeforeDelete guards the deletion of a cluster whose default storage container was
+// created by the cluster. The container itself isn't deleted with the cluster, but as it isn't managed by Terraform the
+// job output it holds is easily overlooked once the cluster is gone.
+func checkHDInsightClusterDefaultStorageBeforeDelete(clusterKind string, id parse.ClusterId, createdByCluster, preventDeletion bool) error {
+	if !createdByCluster {
+		return nil
+	}
+
+	if preventDeletion {
+		return fmt.Errorf("deleting HDInsight %q Cluster %q (Resource Group %q): the default storage container was created by the cluster and isn't managed by Terraform - back up any job output it holds and set `prevent_deletion_if_default_storage_container_created_by_cluster` to `false` within the `hdinsight` block of the provider `features` to delete the cluster", clusterKind, id.Name, id.ResourceGroup)
+	}
+
+	log.Printf("[WARN] the default storage container of HDInsight %q Cluster %q (Resource Group %q) was created by the cluster and isn't managed by Terraform - it's retained, but any data disks and the contents of the managed resource group are deleted with the cluster", clusterKind, id.Name, id.ResourceGroup)
+	return nil
+}
+
+// hdinsightClusterDefaultStorageContainerCreatedByCluster determines whether the default storage container within the
+// `storage_account` blocks doesn't exist yet, in which case it's created implicitly when the cluster is provisioned. Since
+// this is informational, false is returned when the container can't be checked (e.g. the account is in another subscription).
+func hdinsightClusterDefaultStorageContainerCreatedByCluster(ctx context.Context, meta interface{}, storageAccounts []interface{}) bool {
+	accountName, containerName := hdinsightClusterDefaultStorageContainer(storageAccounts)
+	if accountName == "" || containerName == "" {
+		return false
+	}
+
+	storageClient := meta.(*clients.Client).Storage
+	account, err := storageClient.FindAccount(ctx, accountName)
+	if err != nil || account == nil {
+		log.Printf("[DEBUG] Unable to locate the Storage Account %q to check for the default storage container %q: %+v", accountName, containerName, err)
+		return false
+	}
+
+	containersClient, err := storageClient.ContainersClient(ctx, *account)
+	if err != nil {
+		log.Printf("[DEBUG] Unable to build the Containers Client for the Storage Account %q: %+v", accountName, err)
+		return false
+	}
+
+	exists, err := containersClient.Exists(ctx, account.ResourceGroup, accountName, containerName)
+	if err != nil || exists == nil {
+		log.Printf("[DEBUG] Unable to check for the default storage container %q within the Storage Account %q: %+v", containerName, accountName, err)
+		return false
+	}
+
+	return !*exists
+}
+
+// hdinsightClusterDefaultStorageContainer returns the name of the Storage Account and the Container from the
+// `storage_container_id` of the `storage_account` block marked as `is_default`
+func hdinsightClusterDefaultStorageContainer(storageAccounts []interface{}) (string, string) {
+	for _, raw := range storageAccounts {
+		v, ok := raw.(map[string]interface{})
+		if !ok || !v["is_default"].(bool) {
+			continue
+		}
+
+		// https://storageaccountname.blob.core.windows.net/containername
+		uri, err := url.Parse(v["storage_container_id"].(string))
+		if err != nil {
+			return "", ""
+		}
+		accountName, _, _ := strings.Cut(uri.Host, ".")
+		return accountName, strings.Trim(uri.Path, "/")
+	}
+
+	return "", ""
+}
+
 type hdInsightRoleDefinition struct {
 	HeadNodeDef            HDInsightNodeDefinition
 	WorkerNodeDef          HDInsightNodeDefinition
@@ -317,7 +637,135 @@ func flattenHDInsightRoles(d *pluginsdk.ResourceData, input *hdinsight.ComputePr
 	}
 }
 
-func createHDInsightEdgeNodes(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string, input map[string]interface{}) error {
+// addHDInsightClusterEdgeNode creates the Application for an edge node of the cluster and waits for the cluster to
+// finish applying it
+func addHDInsightClusterEdgeNode(ctx context.Context, meta interface{}, clusterKind, resourceGroup, name string, input map[string]interface{}, timeout time.Duration) error {
+	client := meta.(*clients.Client).HDInsight.ClustersClient
+	applicationsClient := meta.(*clients.Client).HDInsight.ApplicationsClient
+
+	applicationName := hdinsightEdgeNodeApplicationName(name, input)
+	if err := createHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name, applicationName, input); err != nil {
+		return err
+	}
+
+	// we can't rely on the use of the Future here due to the node being successfully completed but now the cluster is applying those changes.
+	log.Printf("[DEBUG] Waiting for HDInsight %q Cluster %q (Resource Group %q) to finish applying edge node %q", clusterKind, name, resourceGroup, applicationName)
+	stateConf := &pluginsdk.StateChangeConf{
+		Pending:    hdInsightClusterTransitionalStates,
+		Target:     []string{"Running"},
+		Refresh:    hdInsightWaitForReadyRefreshFunc(ctx, client, resourceGroup, name),
+		MinTimeout: 15 * time.Second,
+		Timeout:    timeout,
+	}
+
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for HDInsight %q Cluster %q (Resource Group %q) to be running: %s", clusterKind, name, resourceGroup, err)
+	}
+
+	return nil
+}
+
+// resizeHDInsightClusterEdgeNode changes the number of instances of an existing edge node of the cluster, waiting for
+// both the Application and the cluster to finish applying it
+func resizeHDInsightClusterEdgeNode(ctx context.Context, meta interface{}, clusterKind, resourceGroup, name string, input map[string]interface{}, timeout time.Duration) error {
+	client := meta.(*clients.Client).HDInsight.ClustersClient
+	applicationsClient := meta.(*clients.Client).HDInsight.ApplicationsClient
+
+	applicationName := hdinsightEdgeNodeApplicationName(name, input)
+	targetInstanceCount := input["target_instance_count"].(int)
+	if err := resizeHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name, applicationName, targetInstanceCount); err != nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] Waiting for edge node %q of HDInsight %q Cluster %q (Resource Group %q) to finish resizing", applicationName, clusterKind, name, resourceGroup)
+	applicationStateConf := &pluginsdk.StateChangeConf{
+		Pending:    []string{"Accepted", "InProgress", "Updating"},
+		Target:     []string{"Succeeded"},
+		Refresh:    hdInsightEdgeNodeProvisioningStateRefreshFunc(ctx, applicationsClient, resourceGroup, name, applicationName),
+		MinTimeout: 15 * time.Second,
+		Timeout:    timeout,
+	}
+
+	if _, err := applicationStateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for edge node %q of HDInsight %q Cluster %q (Resource Group %q) to finish resizing: %s", applicationName, clusterKind, name, resourceGroup, err)
+	}
+
+	stateConf := &pluginsdk.StateChangeConf{
+		Pending:    hdInsightClusterTransitionalStates,
+		Target:     []string{"Running"},
+		Refresh:    hdInsightWaitForReadyRefreshFunc(ctx, client, resourceGroup, name),
+		MinTimeout: 15 * time.Second,
+		Timeout:    timeout,
+	}
+
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for HDInsight %q Cluster %q (Resource Group %q) to be running: %s", clusterKind, name, resourceGroup, err)
+	}
+
+	return nil
+}
+
+// resizeHDInsightEdgeNodes updates the compute profile of the Application for an edge node - there's no PATCH for
+// Applications, so the existing Application is sent back with the new number of instances, and any error returned
+// by the API (for example when the VM size doesn't support the number of instances) is surfaced as-is
+func resizeHDInsightEdgeNodes(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string, applicationName string, targetInstanceCount int) error {
+	existing, err := client.Get(ctx, resourceGroup, name, applicationName)
+	if err != nil {
+		return fmt.Errorf("retrieving edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
+	}
+	if existing.Properties == nil || existing.Properties.ComputeProfile == nil || existing.Properties.ComputeProfile.Roles == nil {
+		return fmt.Errorf("retrieving edge node %q for HDInsight Cluster %q (Resource Group %q): `properties.computeProfile.roles` was nil", applicationName, name, resourceGroup)
+	}
+
+	roles := *existing.Properties.ComputeProfile.Roles
+	for i := range roles {
+		roles[i].TargetInstanceCount = utils.Int32(int32(targetInstanceCount))
+	}
+
+	future, err := client.Create(ctx, resourceGroup, name, applicationName, existing)
+	if err != nil {
+		return fmt.Errorf("resizing edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting for edge node %q for HDInsight Cluster %q (Resource Group %q) to be resized: %+v", applicationName, name, resourceGroup, err)
+	}
+
+	return nil
+}
+
+func hdInsightEdgeNodeProvisioningStateRefreshFunc(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string, applicationName string) pluginsdk.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		res, err := client.Get(ctx, resourceGroup, name, applicationName)
+		if err != nil {
+			return nil, "Error", fmt.Errorf("retrieving edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
+		}
+		if props := res.Properties; props != nil && props.ProvisioningState != nil {
+			return res, *props.ProvisioningState, nil
+		}
+
+		return res, "InProgress", nil
+	}
+}
+
+func createHDInsightEdgeNodes(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string, applicationName string, input map[string]interface{}) error {
+	application := expandHDInsightEdgeNodeApplication(input, "CustomApplication")
+
+	future, err := client.Create(ctx, resourceGroup, name, applicationName, application)
+	if err != nil {
+		return fmt.Errorf("creating edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
+	}
+
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting for creation of edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
+	}
+
+	return nil
+}
+
+// expandHDInsightEdgeNodeApplication builds the Application deployed for an edge node, from either an `edge_node`
+// block of a cluster or an `azurerm_hdinsight_application`
+func expandHDInsightEdgeNodeApplication(input map[string]interface{}, applicationType string) hdinsight.Application {
 	installScriptActions := expandHDInsightApplicationEdgeNodeInstallScriptActions(input["install_script_action"].([]interface{}))
 
 	application := hdinsight.Application{
@@ -332,7 +780,7 @@ func createHDInsightEdgeNodes(ctx context.Context, client *hdinsight.Application
 				}},
 			},
 			InstallScriptActions: installScriptActions,
-			ApplicationType:      utils.String("CustomApplication"),
+			ApplicationType:      utils.String(applicationType),
 		},
 	}
 
@@ -346,84 +794,216 @@ func createHDInsightEdgeNodes(ctx context.Context, client *hdinsight.Application
 		application.Properties.UninstallScriptActions = uninstallScriptActions
 	}
 
-	future, err := client.Create(ctx, resourceGroup, name, name, application)
+	return application
+}
+
+func deleteHDInsightEdgeNodes(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string, applicationName string) error {
+	future, err := client.Delete(ctx, resourceGroup, name, applicationName)
 	if err != nil {
-		return fmt.Errorf("creating edge nodes for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return fmt.Errorf("deleting edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
 	}
 
 	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
-		return fmt.Errorf("waiting for creation of edge node for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return fmt.Errorf("waiting for deletion of edge node %q for HDInsight Cluster %q (Resource Group %q): %+v", applicationName, name, resourceGroup, err)
 	}
 
 	return nil
 }
 
-func deleteHDInsightEdgeNodes(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string) error {
-	future, err := client.Delete(ctx, resourceGroup, name, name)
+// listHDInsightEdgeNodes returns the Applications deployed for the edge nodes of the cluster - Applications installed
+// from the Marketplace aren't managed by the `edge_node` blocks, so are skipped
+func listHDInsightEdgeNodes(ctx context.Context, client *hdinsight.ApplicationsClient, resourceGroup string, name string) ([]hdinsight.Application, error) {
+	iterator, err := client.ListByClusterComplete(ctx, resourceGroup, name)
 	if err != nil {
-		return fmt.Errorf("deleting edge nodes for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return nil, err
 	}
 
-	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
-		return fmt.Errorf("waiting for deletion of edge nodes for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+	applications := make([]hdinsight.Application, 0)
+	for iterator.NotDone() {
+		application := iterator.Value()
+		if props := application.Properties; props != nil && (props.ApplicationType == nil || strings.EqualFold(*props.ApplicationType, "CustomApplication")) {
+			applications = append(applications, application)
+		}
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return applications, nil
 }
 
-func expandHDInsightsMetastore(input []interface{}) map[string]interface{} {
-	if len(input) == 0 || input[0] == nil {
-		return map[string]interface{}{}
+// hdinsightEdgeNodeApplicationName returns the name of the Application an `edge_node` block is deployed as, which is
+// the name of the cluster when the block doesn't specify one
+func hdinsightEdgeNodeApplicationName(clusterName string, input map[string]interface{}) string {
+	if v, ok := input["name"].(string); ok && v != "" {
+		return v
 	}
 
-	v := input[0].(map[string]interface{})
-	config := map[string]interface{}{}
+	return clusterName
+}
 
-	if hiveRaw, ok := v["hive"]; ok {
-		for k, val := range ExpandHDInsightsHiveMetastore(hiveRaw.([]interface{})) {
-			config[k] = val
+// hdinsightEdgeNodeChanges compares the old and new `edge_node` blocks by the name of their Application, returning the
+// names of the Applications to delete, the blocks to resize and the blocks to create - an edge node where only the
+// `target_instance_count` changed is resized in-place, whereas any other change deletes and recreates it
+func hdinsightEdgeNodeChanges(clusterName string, oldRaw, newRaw []interface{}) ([]string, []map[string]interface{}, []map[string]interface{}) {
+	oldEdgeNodes := hdinsightEdgeNodesByName(clusterName, oldRaw)
+	newEdgeNodes := hdinsightEdgeNodesByName(clusterName, newRaw)
+
+	toDelete := make([]string, 0)
+	for _, raw := range oldRaw {
+		if v, ok := raw.(map[string]interface{}); ok {
+			applicationName := hdinsightEdgeNodeApplicationName(clusterName, v)
+			if updated := newEdgeNodes[applicationName]; !hdinsightEdgeNodeEqual(v, updated) && !hdinsightEdgeNodeResized(v, updated) {
+				toDelete = append(toDelete, applicationName)
+			}
 		}
 	}
 
-	if oozieRaw, ok := v["oozie"]; ok {
-		for k, val := range ExpandHDInsightsOozieMetastore(oozieRaw.([]interface{})) {
-			config[k] = val
+	toResize := make([]map[string]interface{}, 0)
+	toCreate := make([]map[string]interface{}, 0)
+	for _, raw := range newRaw {
+		if v, ok := raw.(map[string]interface{}); ok {
+			existing := oldEdgeNodes[hdinsightEdgeNodeApplicationName(clusterName, v)]
+			if hdinsightEdgeNodeEqual(existing, v) {
+				continue
+			}
+
+			if hdinsightEdgeNodeResized(existing, v) {
+				toResize = append(toResize, v)
+			} else {
+				toCreate = append(toCreate, v)
+			}
 		}
 	}
 
-	if ambariRaw, ok := v["ambari"]; ok {
-		for k, val := range ExpandHDInsightsAmbariMetastore(ambariRaw.([]interface{})) {
-			config[k] = val
+	return toDelete, toResize, toCreate
+}
+
+func hdinsightEdgeNodesByName(clusterName string, input []interface{}) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	for _, raw := range input {
+		if v, ok := raw.(map[string]interface{}); ok {
+			result[hdinsightEdgeNodeApplicationName(clusterName, v)] = v
 		}
 	}
 
-	return config
+	return result
 }
 
-func flattenHDInsightsMetastores(d *pluginsdk.ResourceData, configurations map[string]map[string]*string) {
-	result := map[string]interface{}{}
-
-	hiveEnv, envExists := configurations["hive-env"]
-	hiveSite, siteExists := configurations["hive-site"]
-	if envExists && siteExists {
-		result["hive"] = FlattenHDInsightsHiveMetastore(hiveEnv, hiveSite)
+// hdinsightEdgeNodeEqual compares two `edge_node` blocks deployed as the same Application, ignoring whether the name was
+// specified explicitly
+func hdinsightEdgeNodeEqual(first, second map[string]interface{}) bool {
+	if first == nil || second == nil || len(first) != len(second) {
+		return false
 	}
 
-	oozieEnv, envExists := configurations["oozie-env"]
-	oozieSite, siteExists := configurations["oozie-site"]
-	if envExists && siteExists {
-		result["oozie"] = FlattenHDInsightsOozieMetastore(oozieEnv, oozieSite)
+	for k, v := range first {
+		if k == "name" {
+			continue
+		}
+		if !reflect.DeepEqual(v, second[k]) {
+			return false
+		}
 	}
 
-	ambari, ambariExists := configurations["ambari-conf"]
-	if ambariExists {
-		result["ambari"] = FlattenHDInsightsAmbariMetastore(ambari)
+	return true
+}
+
+// hdinsightEdgeNodeResized returns whether the only difference between two `edge_node` blocks deployed as the same
+// Application is the `target_instance_count`
+func hdinsightEdgeNodeResized(first, second map[string]interface{}) bool {
+	if first == nil || second == nil || reflect.DeepEqual(first["target_instance_count"], second["target_instance_count"]) {
+		return false
 	}
 
-	if len(result) > 0 {
-		d.Set("metastores", []interface{}{
-			result,
-		})
+	resized := make(map[string]interface{}, len(second))
+	for k, v := range second {
+		resized[k] = v
+	}
+	resized["target_instance_count"] = first["target_instance_count"]
+
+	return hdinsightEdgeNodeEqual(first, resized)
+}
+
+// expandHDInsightComponentVersion expands the `component_version` block of a cluster, which only contains the version
+// of the component for that kind of cluster - any other key (or an empty version) would otherwise be dropped silently,
+// provisioning the cluster using the default version of the component
+func expandHDInsightComponentVersion(input []interface{}, clusterKind, key, component string) (map[string]*string, error) {
+	if len(input) == 0 || input[0] == nil {
+		return nil, fmt.Errorf("`component_version` must specify `%s` for an HDInsight %s Cluster", key, clusterKind)
+	}
+
+	vs := input[0].(map[string]interface{})
+	for k := range vs {
+		if k != key {
+			return nil, fmt.Errorf("`component_version` contains the unsupported key `%s` - an HDInsight %s Cluster expects `%s`", k, clusterKind, key)
+		}
+	}
+
+	version, _ := vs[key].(string)
+	if version == "" {
+		return nil, fmt.Errorf("`component_version.0.%s` must be specified for an HDInsight %s Cluster", key, clusterKind)
+	}
+
+	return map[string]*string{
+		component: utils.String(version),
+	}, nil
+}
+
+func expandHDInsightsMetastore(input []interface{}) map[string]interface{} {
+	if len(input) == 0 || input[0] == nil {
+		return map[string]interface{}{}
+	}
+
+	v := input[0].(map[string]interface{})
+	config := map[string]interface{}{}
+
+	if hiveRaw, ok := v["hive"]; ok {
+		for k, val := range ExpandHDInsightsHiveMetastore(hiveRaw.([]interface{})) {
+			config[k] = val
+		}
+	}
+
+	if oozieRaw, ok := v["oozie"]; ok {
+		for k, val := range ExpandHDInsightsOozieMetastore(oozieRaw.([]interface{})) {
+			config[k] = val
+		}
+	}
+
+	if ambariRaw, ok := v["ambari"]; ok {
+		for k, val := range ExpandHDInsightsAmbariMetastore(ambariRaw.([]interface{})) {
+			config[k] = val
+		}
+	}
+
+	return config
+}
+
+func flattenHDInsightsMetastores(d *pluginsdk.ResourceData, configurations map[string]map[string]*string) {
+	result := map[string]interface{}{}
+
+	hiveEnv, envExists := configurations["hive-env"]
+	hiveSite, siteExists := configurations["hive-site"]
+	if envExists && siteExists {
+		result["hive"] = FlattenHDInsightsHiveMetastore(hiveEnv, hiveSite)
+	}
+
+	oozieEnv, envExists := configurations["oozie-env"]
+	oozieSite, siteExists := configurations["oozie-site"]
+	if envExists && siteExists {
+		result["oozie"] = FlattenHDInsightsOozieMetastore(oozieEnv, oozieSite)
+	}
+
+	ambari, ambariExists := configurations["ambari-conf"]
+	if ambariExists {
+		result["ambari"] = FlattenHDInsightsAmbariMetastore(ambari)
+	}
+
+	if len(result) > 0 {
+		d.Set("metastores", []interface{}{
+			result,
+		})
 	}
 }
 
@@ -440,12 +1020,18 @@ func flattenHDInsightMonitoring(monitor hdinsight.ClusterMonitoringResponse) []i
 	return nil
 }
 
-func flattenHDInsightAzureMonitor(extension hdinsight.AzureMonitorResponse) []interface{} {
+func flattenHDInsightAzureMonitor(extension hdinsight.AzureMonitorResponse, existing []interface{}) []interface{} {
 	if *extension.ClusterMonitoringEnabled {
+		// the API doesn't return the key, so we use the existing value to be able to detect when it's rotated
+		primaryKey := ""
+		if len(existing) > 0 && existing[0] != nil {
+			primaryKey = existing[0].(map[string]interface{})["primary_key"].(string)
+		}
+
 		return []interface{}{
 			map[string]string{
 				"log_analytics_workspace_id": *extension.WorkspaceID,
-				"primary_key":                "*****",
+				"primary_key":                primaryKey,
 			},
 		}
 	}
@@ -514,3 +1100,950 @@ func disableHDInsightAzureMonitor(ctx context.Context, client *hdinsight.Extensi
 
 	return nil
 }
+
+// expandHDInsightClusterTags merges the `node_tags` into the `tags` for the cluster - HDInsight doesn't support tagging
+// individual roles, however the cluster tags are propagated to the underlying compute
+func expandHDInsightClusterTags(d *pluginsdk.ResourceData) map[string]*string {
+	output := tags.Expand(d.Get("tags").(map[string]interface{}))
+	for k, v := range tags.Expand(d.Get("node_tags").(map[string]interface{})) {
+		output[k] = v
+	}
+
+	return output
+}
+
+// flattenHDInsightClusterTags splits the tags returned from the API back into `tags` and `node_tags`, using the
+// keys currently defined in `node_tags` to determine which tags belong to which attribute
+func flattenHDInsightClusterTags(d *pluginsdk.ResourceData, input map[string]*string) error {
+	existingNodeTags := d.Get("node_tags").(map[string]interface{})
+
+	clusterTags := make(map[string]*string)
+	nodeTags := make(map[string]*string)
+	for k, v := range input {
+		if _, ok := existingNodeTags[k]; ok {
+			nodeTags[k] = v
+			continue
+		}
+		clusterTags[k] = v
+	}
+
+	if err := d.Set("node_tags", tags.Flatten(nodeTags)); err != nil {
+		return fmt.Errorf("setting `node_tags`: %+v", err)
+	}
+
+	return tags.FlattenAndSet(d, clusterTags)
+}
+
+// hdinsightClusterReadError wraps an error returned from one of the API calls made when reading an HDInsight Cluster,
+// calling out when the call timed out so that this isn't mistaken for a bug in the provider
+func hdinsightClusterReadError(ctx context.Context, description string, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out reading %s - consider increasing the `read` timeout: %+v", description, err)
+	}
+
+	return fmt.Errorf("retrieving %s: %+v", description, err)
+}
+
+// hdinsightClusterConfigurationsNotReady returns whether listing the configurations of an HDInsight Cluster failed since
+// the cluster isn't in a ready state (e.g. whilst it's still being provisioned or scaled), in which case the API returns
+// either a 409 or a 412 rather than the configurations
+func hdinsightClusterConfigurationsNotReady(resp autorest.Response) bool {
+	return utils.ResponseWasConflict(resp) || utils.ResponseWasStatusCode(resp, http.StatusPreconditionFailed)
+}
+
+// hdinsightClusterConfigurationsUnreachable returns whether listing the configurations of an HDInsight Cluster failed
+// since the cluster is only reachable over Private Link and the request was made from a network without private
+// connectivity - in which case either no response is received, or the request is rejected with a 403. Timeouts aren't
+// included, since these are reported as such.
+func hdinsightClusterConfigurationsUnreachable(ctx context.Context, props *hdinsight.ClusterGetProperties, resp autorest.Response) bool {
+	if props == nil || props.NetworkProperties == nil || props.NetworkProperties.PrivateLink != hdinsight.PrivateLinkEnabled {
+		return false
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return resp.Response == nil || resp.StatusCode == http.StatusForbidden
+}
+
+// expandHDInsightClusterIdentity merges the User Assigned Identities specified in the `identity` block into those which
+// are required by the rest of the configuration (e.g. for Data Lake Gen2 storage or the Enterprise Security Package),
+// so that identities used purely by the workloads running on the cluster are also attached to the cluster nodes
+func expandHDInsightClusterIdentity(input []interface{}, existing *hdinsight.ClusterIdentity) (*hdinsight.ClusterIdentity, error) {
+	expanded, err := identity.ExpandUserAssignedMap(input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := existing
+	for id := range expanded.IdentityIds {
+		output = addHDInsightClusterUserAssignedIdentity(output, id)
+	}
+
+	return output, nil
+}
+
+// addHDInsightClusterUserAssignedIdentity adds the specified User Assigned Identity to the cluster identity, rather than
+// replacing any identities which have already been added
+func addHDInsightClusterUserAssignedIdentity(input *hdinsight.ClusterIdentity, id string) *hdinsight.ClusterIdentity {
+	if input == nil {
+		input = &hdinsight.ClusterIdentity{
+			Type:                   hdinsight.ResourceIdentityTypeUserAssigned,
+			UserAssignedIdentities: make(map[string]*hdinsight.ClusterIdentityUserAssignedIdentitiesValue),
+		}
+	}
+
+	for existing := range input.UserAssignedIdentities {
+		if strings.EqualFold(existing, id) {
+			return input
+		}
+	}
+
+	// ... API doesn't seem to require client_id or principal_id, so pass in an empty ClusterIdentityUserAssignedIdentitiesValue
+	input.UserAssignedIdentities[id] = &hdinsight.ClusterIdentityUserAssignedIdentitiesValue{}
+
+	return input
+}
+
+// flattenHDInsightClusterIdentity returns the User Assigned Identities attached to the cluster, excluding those which
+// are referenced by the `storage_account_gen2`, `security_profile` or `disk_encryption` blocks - since these are
+// attached to the cluster implicitly - unless they're also specified in the `identity` block
+func flattenHDInsightClusterIdentity(d *pluginsdk.ResourceData, input *hdinsight.ClusterIdentity) (*[]interface{}, error) {
+	referenced := make([]string, 0)
+	for _, v := range d.Get("storage_account_gen2").([]interface{}) {
+		if raw, ok := v.(map[string]interface{}); ok {
+			referenced = append(referenced, raw["managed_identity_resource_id"].(string))
+		}
+	}
+	for _, v := range d.Get("security_profile").([]interface{}) {
+		if raw, ok := v.(map[string]interface{}); ok {
+			referenced = append(referenced, raw["msi_resource_id"].(string))
+		}
+	}
+	for _, v := range d.Get("disk_encryption").([]interface{}) {
+		if raw, ok := v.(map[string]interface{}); ok {
+			referenced = append(referenced, raw["key_vault_managed_identity_id"].(string))
+		}
+	}
+
+	return flattenHDInsightClusterUserAssignedIdentities(input, referenced, d.Get("identity").([]interface{}))
+}
+
+func flattenHDInsightClusterUserAssignedIdentities(input *hdinsight.ClusterIdentity, referenced []string, configured []interface{}) (*[]interface{}, error) {
+	if input == nil || len(input.UserAssignedIdentities) == 0 {
+		return &[]interface{}{}, nil
+	}
+
+	// the identities which are specified in the `identity` block are kept, even when they're also referenced elsewhere,
+	// since `identity` can't be changed without replacing the cluster
+	specified, err := identity.ExpandUserAssignedMap(configured)
+	if err != nil {
+		return nil, err
+	}
+
+	userAssigned := identity.UserAssignedMap{
+		Type:        identity.TypeUserAssigned,
+		IdentityIds: make(map[string]identity.UserAssignedIdentityDetails),
+	}
+	for id := range input.UserAssignedIdentities {
+		isSpecified := false
+		for v := range specified.IdentityIds {
+			if strings.EqualFold(v, id) {
+				isSpecified = true
+				// the casing used in the config is kept
+				id = v
+				break
+			}
+		}
+
+		isReferenced := false
+		for _, v := range referenced {
+			if strings.EqualFold(v, id) {
+				isReferenced = true
+				break
+			}
+		}
+
+		if isSpecified || !isReferenced {
+			userAssigned.IdentityIds[id] = identity.UserAssignedIdentityDetails{}
+		}
+	}
+
+	if len(userAssigned.IdentityIds) == 0 {
+		return &[]interface{}{}, nil
+	}
+
+	return identity.FlattenUserAssignedMap(&userAssigned)
+}
+
+// hdinsightClusterCurrentWorkerCount returns the number of Worker Nodes currently running within the cluster - when
+// autoscale is enabled the compute profile only contains the initial/minimum instance count, so the hosts are listed
+// (falling back to the target instance count when these can't be listed, since the cluster isn't ready)
+func hdinsightClusterCurrentWorkerCount(ctx context.Context, client *hdinsight.VirtualMachinesClient, resourceGroup, name string, input *hdinsight.ComputeProfile) (int, error) {
+	if input == nil {
+		return 0, nil
+	}
+
+	workerNode := FindHDInsightRole(input.Roles, "workernode")
+	if workerNode == nil {
+		return 0, nil
+	}
+
+	if workerNode.AutoscaleConfiguration == nil {
+		if workerNode.TargetInstanceCount == nil {
+			return 0, nil
+		}
+
+		return int(*workerNode.TargetInstanceCount), nil
+	}
+
+	hosts, err := client.ListHosts(ctx, resourceGroup, name)
+	if err != nil {
+		if hdinsightClusterConfigurationsNotReady(hosts.Response) && workerNode.TargetInstanceCount != nil {
+			log.Printf("[DEBUG] the hosts of HDInsight Cluster %q (Resource Group %q) aren't available since the cluster isn't ready - using the target instance count: %+v", name, resourceGroup, err)
+			return int(*workerNode.TargetInstanceCount), nil
+		}
+
+		return 0, err
+	}
+
+	count := 0
+	if hosts.Value != nil {
+		for _, host := range *hosts.Value {
+			// Worker Node hosts are named `wn{index}-{prefix}`
+			if host.Name != nil && strings.HasPrefix(strings.ToLower(*host.Name), "wn") {
+				count++
+			}
+		}
+	}
+
+	return count, nil
+}
+
+// hdinsightClusterNonPersistedScriptActionNames returns the names of the script actions across all of the roles which
+// should only be run when the cluster is provisioned, rather than also on the nodes added when scaling out
+func hdinsightClusterNonPersistedScriptActionNames(input []interface{}) []string {
+	names := make([]string, 0)
+	if len(input) == 0 || input[0] == nil {
+		return names
+	}
+
+	roles := input[0].(map[string]interface{})
+	for _, role := range []string{"head_node", "worker_node", "zookeeper_node", "kafka_management_node"} {
+		nodes, ok := roles[role].([]interface{})
+		if !ok || len(nodes) == 0 || nodes[0] == nil {
+			continue
+		}
+
+		scriptActions, ok := nodes[0].(map[string]interface{})["script_actions"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, v := range scriptActions {
+			scriptAction := v.(map[string]interface{})
+			if persisted, ok := scriptAction["persisted"].(bool); ok && !persisted {
+				names = append(names, scriptAction["name"].(string))
+			}
+		}
+	}
+
+	return names
+}
+
+// removeHDInsightNonPersistedScriptActions removes the script actions which should only be run when the cluster is
+// provisioned from the persisted script actions - since the API automatically persists the script actions used when
+// creating the cluster, which would otherwise also be run on the nodes added when scaling out
+func removeHDInsightNonPersistedScriptActions(ctx context.Context, client *hdinsight.ScriptActionsClient, resourceGroup, name string, rolesRaw []interface{}) error {
+	for _, scriptName := range hdinsightClusterNonPersistedScriptActionNames(rolesRaw) {
+		log.Printf("[DEBUG] Removing the persisted Script Action %q from HDInsight Cluster %q (Resource Group %q)..", scriptName, name, resourceGroup)
+		resp, err := client.Delete(ctx, resourceGroup, name, scriptName)
+		if err != nil && !utils.ResponseWasNotFound(resp) {
+			return fmt.Errorf("removing the persisted Script Action %q from HDInsight Cluster %q (Resource Group %q): %+v", scriptName, name, resourceGroup, err)
+		}
+	}
+
+	return nil
+}
+
+// hdInsightScriptActionRoles maps the roles within the `roles` block to the names of the roles used by the API for the
+// persisted script actions
+var hdInsightScriptActionRoles = map[string]string{
+	"head_node":             "headnode",
+	"worker_node":           "workernode",
+	"zookeeper_node":        "zookeepernode",
+	"kafka_management_node": "kafkamanagementnode",
+}
+
+// listHDInsightPersistedScriptActions returns the persisted script actions of the cluster, returning false when these
+// can't be retrieved since the cluster isn't ready
+func listHDInsightPersistedScriptActions(ctx context.Context, client *hdinsight.ScriptActionsClient, resourceGroup, name string) ([]hdinsight.RuntimeScriptActionDetail, bool, error) {
+	scriptActions := make([]hdinsight.RuntimeScriptActionDetail, 0)
+
+	iterator, err := client.ListByClusterComplete(ctx, resourceGroup, name)
+	if err != nil {
+		if hdinsightClusterConfigurationsNotReady(iterator.Response().Response) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	for iterator.NotDone() {
+		scriptActions = append(scriptActions, iterator.Value())
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return scriptActions, true, nil
+}
+
+// applyHDInsightPersistedScriptActions updates the `script_actions` of each role in the flattened `roles` block with the
+// script actions persisted on the cluster for that role (see `mergeHDInsightRoleScriptActions`), returning the persisted
+// script actions which aren't specified for any of the roles. Since the `script_actions` of the roles can only be set
+// when creating the cluster, these are returned separately rather than being added to the roles - which would replace
+// the cluster whenever a script action is persisted outside of the cluster resource.
+func applyHDInsightPersistedScriptActions(roles []interface{}, persisted []hdinsight.RuntimeScriptActionDetail) []interface{} {
+	managed := make(map[string]bool)
+	if len(roles) > 0 && roles[0] != nil {
+		for role, apiRole := range hdInsightScriptActionRoles {
+			nodes, ok := roles[0].(map[string]interface{})[role].([]interface{})
+			if !ok || len(nodes) == 0 || nodes[0] == nil {
+				continue
+			}
+
+			node := nodes[0].(map[string]interface{})
+			existing, _ := node["script_actions"].([]interface{})
+			for _, raw := range existing {
+				if v, ok := raw.(map[string]interface{}); ok {
+					managed[v["name"].(string)] = true
+				}
+			}
+			node["script_actions"] = mergeHDInsightRoleScriptActions(existing, persisted, apiRole)
+		}
+	}
+
+	unmanaged := make([]hdinsight.RuntimeScriptActionDetail, 0)
+	for _, v := range persisted {
+		// the script actions used to install applications (such as on the edge nodes) are managed by the application
+		if v.Name == nil || managed[*v.Name] || (v.ApplicationName != nil && *v.ApplicationName != "") {
+			continue
+		}
+		unmanaged = append(unmanaged, v)
+	}
+	// the API doesn't return these in a stable order
+	sort.Slice(unmanaged, func(i, j int) bool {
+		return *unmanaged[i].Name < *unmanaged[j].Name
+	})
+
+	output := make([]interface{}, 0)
+	for _, v := range unmanaged {
+		scriptAction := flattenHDInsightRoleScriptAction(v)
+		delete(scriptAction, "persisted")
+		roles := flattenHDInsightScriptActionRoles(v.Roles)
+		sort.Slice(roles, func(i, j int) bool {
+			return roles[i].(string) < roles[j].(string)
+		})
+		scriptAction["roles"] = roles
+		output = append(output, scriptAction)
+	}
+
+	return output
+}
+
+// mergeHDInsightRoleScriptActions maps the script actions persisted on the cluster for a role back onto the
+// `script_actions` in the state, so that changes to these are detected. The API doesn't return these in a stable order,
+// so these are matched by name - keeping the order of the state. Script actions which aren't persisted are only run
+// when the cluster is provisioned, so are kept as-is. Persisted script actions which aren't in the state are returned
+// by `applyHDInsightPersistedScriptActions` instead.
+func mergeHDInsightRoleScriptActions(existing []interface{}, persisted []hdinsight.RuntimeScriptActionDetail, apiRole string) []interface{} {
+	forRole := make(map[string]hdinsight.RuntimeScriptActionDetail)
+	for _, v := range persisted {
+		// the script actions used to install applications (such as on the edge nodes) are managed by the application
+		if v.Name == nil || (v.ApplicationName != nil && *v.ApplicationName != "") || v.Roles == nil {
+			continue
+		}
+		for _, role := range *v.Roles {
+			if strings.EqualFold(role, apiRole) {
+				forRole[*v.Name] = v
+				break
+			}
+		}
+	}
+
+	output := make([]interface{}, 0)
+	for _, raw := range existing {
+		v, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if persistedValue, ok := v["persisted"].(bool); ok && !persistedValue {
+			output = append(output, v)
+			continue
+		}
+
+		scriptAction, ok := forRole[v["name"].(string)]
+		if !ok {
+			// removed outside of Terraform
+			continue
+		}
+
+		output = append(output, flattenHDInsightRoleScriptAction(scriptAction))
+	}
+
+	return output
+}
+
+func flattenHDInsightRoleScriptAction(input hdinsight.RuntimeScriptActionDetail) map[string]interface{} {
+	name := ""
+	if input.Name != nil {
+		name = *input.Name
+	}
+
+	uri := ""
+	if input.URI != nil {
+		uri = *input.URI
+	}
+
+	parameters := ""
+	if input.Parameters != nil {
+		parameters = *input.Parameters
+	}
+
+	return map[string]interface{}{
+		"name":       name,
+		"uri":        uri,
+		"parameters": parameters,
+		"persisted":  true,
+	}
+}
+
+// hdinsightClusterExists checks for an existing cluster prior to creating it. Since the principal used for creating the
+// cluster may not have permission to read it (e.g. pipelines with restricted RBAC), the creation proceeds when that's the case.
+func hdinsightClusterExists(ctx context.Context, client *hdinsight.ClustersClient, id parse.ClusterId) (bool, error) {
+	existing, err := client.Get(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(existing.Response) {
+			return false, nil
+		}
+
+		if utils.ResponseWasForbidden(existing.Response) {
+			log.Printf("[WARN] insufficient permissions to check for an existing %s - proceeding to create it: %+v", id, err)
+			return false, nil
+		}
+
+		return false, hdinsightResourceProviderRegistrationError(id.SubscriptionId, err)
+	}
+
+	return true, nil
+}
+
+// hdinsightResourceProviderRegistrationError replaces the error returned when the Microsoft.HDInsight Resource Provider
+// isn't registered for the subscription (e.g. a new subscription where `skip_provider_registration` is set) with one
+// explaining how to register it, returning any other error as-is
+func hdinsightResourceProviderRegistrationError(subscriptionId string, err error) error {
+	// autorest attempts to register the Resource Provider itself when it encounters this error, wrapping the original
+	// error as text when that fails - so the error code can't be checked using the Service Error
+	if err == nil || !strings.Contains(err.Error(), "MissingSubscriptionRegistration") {
+		return err
+	}
+
+	return fmt.Errorf("the Resource Provider %q isn't registered for Subscription %q. Either register it (for example using `az provider register --namespace Microsoft.HDInsight`), or remove `skip_provider_registration` from the Provider block so that Terraform registers it automatically - registration can take several minutes to complete: %+v", "Microsoft.HDInsight", subscriptionId, err)
+}
+
+// hdInsightClusterApplicationPaths maps each cluster kind (as returned by the API, lower-cased) to the paths of the web
+// applications available through the HTTPS endpoint of the cluster, in addition to Ambari which is available for all kinds
+var hdInsightClusterApplicationPaths = map[string]map[string]string{
+	"hadoop": {
+		"hive": "/hive2",
+		"yarn": "/yarnui/hn/cluster",
+	},
+	"hbase": {
+		"hbase_rest": "/hbaserest",
+	},
+	"interactivehive": {
+		"hive": "/hive2",
+	},
+	"kafka": {},
+	"spark": {
+		"jupyter":       "/jupyter",
+		"livy":          "/livy/v1",
+		"spark_history": "/sparkhistory",
+		"yarn":          "/yarnui/hn/cluster",
+		"zeppelin":      "/zeppelin",
+	},
+}
+
+func hdinsightClusterHttpsUrl(httpsEndpoint string) string {
+	if httpsEndpoint == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("https://%s", httpsEndpoint)
+}
+
+// hdinsightClusterEndpointDNSLabel returns the DNS label of the `*.azurehdinsight.net` endpoints of the cluster - this is
+// taken from the HTTPS endpoint where available, which isn't the case when the public endpoints are removed by private link
+func hdinsightClusterEndpointDNSLabel(name, httpsEndpoint string) string {
+	if label, _, ok := strings.Cut(httpsEndpoint, "."); ok && label != "" {
+		return strings.ToLower(label)
+	}
+
+	return validate.HDInsightEndpointDNSLabel(name)
+}
+
+// hdinsightEnvironmentEndpointDNSSuffixes are the DNS suffixes of the endpoints of HDInsight Clusters within each Azure
+// environment, since these aren't part of the environment metadata
+var hdinsightEnvironmentEndpointDNSSuffixes = map[string]string{
+	environments.AzurePublicCloud:       "azurehdinsight.net",
+	environments.AzureChinaCloud:        "azurehdinsight.cn",
+	environments.AzureUSGovernmentCloud: "azurehdinsight.us",
+}
+
+// hdinsightEnvironmentEndpointDNSSuffix returns the DNS suffix of the endpoints of HDInsight Clusters within the Azure
+// environment the provider is configured for. This is only needed to describe endpoints which don't exist yet, since the
+// endpoints of an existing cluster are taken from the connectivity endpoints returned by the API
+func hdinsightEnvironmentEndpointDNSSuffix(environmentName string) (string, bool) {
+	for name, suffix := range hdinsightEnvironmentEndpointDNSSuffixes {
+		if strings.EqualFold(name, environmentName) {
+			return suffix, true
+		}
+	}
+
+	return "", false
+}
+
+// hdinsightClusterDefaultStorageEndpoint returns the root of the default filesystem of the cluster, built from whichever
+// `storage_account` or `storage_account_gen2` block is marked as `is_default`. The API doesn't return the storage accounts,
+// so when these aren't available (e.g. when importing) `fs.defaultFS` from the `core-site` configuration is used instead
+func hdinsightClusterDefaultStorageEndpoint(storageAccounts []interface{}, gen2StorageAccounts []interface{}, coreSite map[string]*string) string {
+	for _, raw := range storageAccounts {
+		v, ok := raw.(map[string]interface{})
+		if !ok || !v["is_default"].(bool) {
+			continue
+		}
+
+		// https://storageaccountname.blob.core.windows.net/containername -> wasbs://containername@storageaccountname.blob.core.windows.net/
+		uri, err := url.Parse(v["storage_container_id"].(string))
+		if err != nil || uri.Host == "" {
+			return ""
+		}
+		return fmt.Sprintf("wasbs://%s@%s/", strings.Trim(uri.Path, "/"), uri.Host)
+	}
+
+	for _, raw := range gen2StorageAccounts {
+		v, ok := raw.(map[string]interface{})
+		if !ok || !v["is_default"].(bool) {
+			continue
+		}
+
+		// https://storageaccountname.dfs.core.windows.net/filesystemname -> abfss://filesystemname@storageaccountname.dfs.core.windows.net/
+		uri, err := url.Parse(v["filesystem_id"].(string))
+		if err != nil || uri.Host == "" {
+			return ""
+		}
+		return fmt.Sprintf("abfss://%s@%s/", strings.Trim(uri.Path, "/"), uri.Host)
+	}
+
+	if len(storageAccounts) > 0 || len(gen2StorageAccounts) > 0 {
+		return ""
+	}
+
+	defaultFS := coreSite["fs.defaultFS"]
+	if defaultFS == nil {
+		return ""
+	}
+	uri, err := url.Parse(*defaultFS)
+	if err != nil || uri.User == nil || uri.Host == "" {
+		return ""
+	}
+	// the cluster may be configured with the unencrypted scheme, however the endpoint is always exposed using TLS
+	scheme := strings.ToLower(uri.Scheme)
+	switch scheme {
+	case "wasb":
+		scheme = "wasbs"
+	case "abfs":
+		scheme = "abfss"
+	}
+	return fmt.Sprintf("%s://%s@%s/", scheme, uri.User.Username(), uri.Host)
+}
+
+// normalizeHDInsightClusterKind returns the kind of cluster in lower-case, since clusters created using older versions of
+// the API can report their kind using a different casing (for example `SPARK` rather than `Spark`)
+func normalizeHDInsightClusterKind(kind string) string {
+	return strings.ToLower(strings.TrimSpace(kind))
+}
+
+func hdinsightClusterKindEqual(first, second string) bool {
+	return normalizeHDInsightClusterKind(first) == normalizeHDInsightClusterKind(second)
+}
+
+func flattenHDInsightClusterApplicationEndpoints(kind, httpsEndpoint string) map[string]interface{} {
+	output := make(map[string]interface{})
+	if httpsEndpoint == "" {
+		return output
+	}
+
+	baseUrl := hdinsightClusterHttpsUrl(httpsEndpoint)
+	output["ambari"] = baseUrl + "/"
+	for name, path := range hdInsightClusterApplicationPaths[normalizeHDInsightClusterKind(kind)] {
+		output[name] = baseUrl + path
+	}
+
+	return output
+}
+
+const (
+	hdInsightGatewayAuthenticationModeAAD   = "aad"
+	hdInsightGatewayAuthenticationModeBasic = "basic"
+	hdInsightGatewayAuthenticationModeNone  = "none"
+)
+
+// hdinsightClusterGatewayAuthenticationMode returns how users authenticate against the gateway - clusters using the
+// Enterprise Security Package authenticate users against Azure Active Directory, whereas other clusters use the
+// basic (HTTP) credential configured in the `gateway` block when it's enabled
+func hdinsightClusterGatewayAuthenticationMode(securityProfile *hdinsight.SecurityProfile, gatewayEnabled bool) string {
+	if securityProfile != nil {
+		return hdInsightGatewayAuthenticationModeAAD
+	}
+
+	if gatewayEnabled {
+		return hdInsightGatewayAuthenticationModeBasic
+	}
+
+	return hdInsightGatewayAuthenticationModeNone
+}
+
+// hdinsightClusterCreationInterrupted determines whether waiting for the creation of the cluster stopped because Terraform
+// was interrupted (e.g. Ctrl/Cmd+C cancels the StopContext) - rather than the creation failing or the timeout elapsing
+func hdinsightClusterCreationInterrupted(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled)
+}
+
+// hdinsightClusterScriptActionCreateError appends the details of the failed script actions to an error returned when
+// creating the cluster - since the API only reports that the creation failed, whereas which script failed and why
+// (the debug information contains the exit code and where the logs can be found) is only available in the execution history
+func hdinsightClusterScriptActionCreateError(ctx context.Context, meta interface{}, resourceGroup, name string, err error) error {
+	if !hdinsightClusterErrorIsScriptActionFailure(err) {
+		return err
+	}
+
+	historyClient := meta.(*clients.Client).HDInsight.ScriptExecutionHistoryClient
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
+
+	history, historyErr := historyClient.ListByClusterComplete(ctx, resourceGroup, name)
+	if historyErr != nil {
+		log.Printf("[DEBUG] Unable to retrieve the Script Action execution history for HDInsight Cluster %q (Resource Group %q): %+v", name, resourceGroup, historyErr)
+		return err
+	}
+
+	failures := make([]string, 0)
+	for history.NotDone() {
+		execution := history.Value()
+		if execution.Status != nil && strings.Contains(strings.ToLower(*execution.Status), "fail") {
+			// the debug information is only returned when retrieving the individual execution
+			if execution.ScriptExecutionID != nil {
+				detail, detailErr := scriptActionsClient.GetExecutionDetail(ctx, resourceGroup, name, strconv.FormatInt(*execution.ScriptExecutionID, 10))
+				if detailErr != nil {
+					log.Printf("[DEBUG] Unable to retrieve the Script Action execution %d for HDInsight Cluster %q (Resource Group %q): %+v", *execution.ScriptExecutionID, name, resourceGroup, detailErr)
+				} else {
+					execution = detail
+				}
+			}
+
+			failures = append(failures, formatHDInsightScriptActionFailure(execution))
+		}
+
+		if historyErr := history.NextWithContext(ctx); historyErr != nil {
+			log.Printf("[DEBUG] Unable to retrieve the Script Action execution history for HDInsight Cluster %q (Resource Group %q): %+v", name, resourceGroup, historyErr)
+			break
+		}
+	}
+
+	if len(failures) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("%+v\n\nThe following Script Actions failed:\n\n%s", err, strings.Join(failures, "\n"))
+}
+
+// hdinsightClusterErrorIsScriptActionFailure determines whether the cluster failed to provision due to a script action
+func hdinsightClusterErrorIsScriptActionFailure(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "script")
+}
+
+func formatHDInsightScriptActionFailure(input hdinsight.RuntimeScriptActionDetail) string {
+	name := ""
+	if input.Name != nil {
+		name = *input.Name
+	}
+
+	status := ""
+	if input.Status != nil {
+		status = *input.Status
+	}
+
+	output := fmt.Sprintf("* %q (Status %q)", name, status)
+	if input.DebugInformation != nil && *input.DebugInformation != "" {
+		output += fmt.Sprintf(": %s", *input.DebugInformation)
+	}
+
+	return output
+}
+
+// hdinsightSecretLookupFunc returns the value of the Key Vault Secret with the specified ID
+type hdinsightSecretLookupFunc func(secretId string) (string, error)
+
+// hdinsightKeyVaultSecretLookup retrieves the passwords specified using `password_key_vault_secret_id` at apply time, so
+// that only the ID of the secret (rather than the password itself) is stored in the plan and the state
+func hdinsightKeyVaultSecretLookup(ctx context.Context, client *keyvaultClient.Client) hdinsightSecretLookupFunc {
+	return func(secretId string) (string, error) {
+		id, err := keyvaultParse.ParseOptionallyVersionedNestedItemID(secretId)
+		if err != nil {
+			return "", err
+		}
+
+		secret, err := client.ManagementClient.GetSecret(ctx, id.KeyVaultBaseUrl, id.Name, id.Version)
+		if err != nil {
+			return "", fmt.Errorf("retrieving Key Vault Secret %q: %+v", secretId, err)
+		}
+
+		if secret.Value == nil || *secret.Value == "" {
+			return "", fmt.Errorf("Key Vault Secret %q has no value", secretId)
+		}
+
+		return *secret.Value, nil
+	}
+}
+
+// expandHDInsightKeyVaultPasswords sets the `password` of each block within the input which specifies a
+// `password_key_vault_secret_id` to the value of that secret, so that these can be expanded as usual
+func expandHDInsightKeyVaultPasswords(input []interface{}, lookup hdinsightSecretLookupFunc) error {
+	for _, raw := range input {
+		v, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		secretId, _ := v["password_key_vault_secret_id"].(string)
+		if secretId == "" {
+			continue
+		}
+
+		id, err := keyvaultParse.ParseOptionallyVersionedNestedItemID(secretId)
+		if err != nil {
+			return fmt.Errorf("parsing `password_key_vault_secret_id`: %+v", err)
+		}
+		if id.NestedItemType != keyvaultParse.NestedItemTypeSecret {
+			return fmt.Errorf("`password_key_vault_secret_id` must be the ID of a Key Vault Secret but got %q", secretId)
+		}
+
+		password, err := lookup(secretId)
+		if err != nil {
+			return err
+		}
+		v["password"] = password
+	}
+
+	return nil
+}
+
+// expandHDInsightRolesKeyVaultPasswords resolves the `password_key_vault_secret_id` of each of the nodes within the `roles` block
+func expandHDInsightRolesKeyVaultPasswords(input []interface{}, lookup hdinsightSecretLookupFunc) error {
+	if len(input) == 0 || input[0] == nil {
+		return nil
+	}
+
+	roles := input[0].(map[string]interface{})
+	for _, role := range hdInsightRolesWithUsernames {
+		nodes, ok := roles[role].([]interface{})
+		if !ok {
+			continue
+		}
+
+		if err := expandHDInsightKeyVaultPasswords(nodes, lookup); err != nil {
+			return fmt.Errorf("`%s`: %+v", role, err)
+		}
+	}
+
+	return nil
+}
+
+// hdInsightClusterImportIdFormats are the formats of the ID which can be used to import an HDInsight Cluster
+var hdInsightClusterImportIdFormats = []string{
+	"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.HDInsight/clusters/{clusterName}",
+	"resourceGroups/{resourceGroupName}/providers/Microsoft.HDInsight/clusters/{clusterName}",
+	"{resourceGroupName}/{clusterName}",
+}
+
+// parseHDInsightClusterImportId parses the ID used to import an HDInsight Cluster - which as well as the Resource ID can
+// omit the Subscription (or be just the Resource Group and Cluster name), in which case the specified Subscription is used
+func parseHDInsightClusterImportId(input, subscriptionId string) (*parse.ClusterId, error) {
+	if strings.HasPrefix(strings.ToLower(input), "/subscriptions/") {
+		id, err := parse.ClusterID(input)
+		if err != nil {
+			return nil, hdinsightClusterImportIdError(input, err)
+		}
+		return id, nil
+	}
+
+	segments := strings.Split(strings.Trim(input, "/"), "/")
+	resourceGroup, name := "", ""
+	switch len(segments) {
+	case 2:
+		resourceGroup, name = segments[0], segments[1]
+	case 6:
+		if strings.EqualFold(segments[0], "resourceGroups") && strings.EqualFold(segments[2], "providers") && strings.EqualFold(segments[3], "Microsoft.HDInsight") && strings.EqualFold(segments[4], "clusters") {
+			resourceGroup, name = segments[1], segments[5]
+		}
+	}
+
+	if resourceGroup == "" || name == "" {
+		return nil, hdinsightClusterImportIdError(input, nil)
+	}
+
+	id := parse.NewClusterID(subscriptionId, resourceGroup, name)
+	return &id, nil
+}
+
+func hdinsightClusterImportIdError(input string, err error) error {
+	formats := make([]string, 0)
+	for _, v := range hdInsightClusterImportIdFormats {
+		formats = append(formats, fmt.Sprintf("* `%s`", v))
+	}
+
+	message := fmt.Sprintf("parsing %q: the ID of an HDInsight Cluster must be in one of the following formats:\n\n%s", input, strings.Join(formats, "\n"))
+	if err != nil {
+		message = fmt.Sprintf("%s\n\n%+v", message, err)
+	}
+
+	return errors.New(message)
+}
+
+// importHDInsightCluster sets the Resource ID of the HDInsight Cluster being imported, constructing it using the
+// Subscription of the Provider when imported using one of the shorter formats
+func importHDInsightCluster(_ context.Context, d *pluginsdk.ResourceData, meta interface{}) ([]*pluginsdk.ResourceData, error) {
+	id, err := parseHDInsightClusterImportId(d.Id(), meta.(*clients.Client).Account.SubscriptionId)
+	if err != nil {
+		return []*pluginsdk.ResourceData{}, err
+	}
+
+	d.SetId(id.ID())
+
+	return []*pluginsdk.ResourceData{d}, nil
+}
+
+// hdinsightClusterConfigurationExport is the archive written to `configuration_export_on_destroy_path` before the
+// cluster is deleted
+type hdinsightClusterConfigurationExport struct {
+	ClusterId              string                                `json:"cluster_id"`
+	ExportedAt             string                                `json:"exported_at"`
+	Configurations         map[string]map[string]*string         `json:"configurations"`
+	PersistedScriptActions []hdinsight.RuntimeScriptActionDetail `json:"persisted_script_actions"`
+}
+
+// exportHDInsightClusterConfiguration writes the Ambari configurations and the persisted Script Actions of the cluster
+// to a new file within the specified directory, returning the name of the file
+func exportHDInsightClusterConfiguration(ctx context.Context, meta interface{}, id parse.ClusterId, directory string) (string, error) {
+	configurationsClient := meta.(*clients.Client).HDInsight.ConfigurationsClient
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
+
+	configurations, err := configurationsClient.List(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		return "", fmt.Errorf("retrieving the configurations: %+v", err)
+	}
+
+	scriptActions := make([]hdinsight.RuntimeScriptActionDetail, 0)
+	iterator, err := scriptActionsClient.ListByClusterComplete(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		return "", fmt.Errorf("listing the persisted Script Actions: %+v", err)
+	}
+	for iterator.NotDone() {
+		scriptActions = append(scriptActions, iterator.Value())
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return "", fmt.Errorf("listing the persisted Script Actions: %+v", err)
+		}
+	}
+
+	exportedAt := time.Now().UTC()
+	export := hdinsightClusterConfigurationExport{
+		ClusterId:              id.ID(),
+		ExportedAt:             exportedAt.Format(time.RFC3339),
+		Configurations:         redactHDInsightClusterConfigurations(configurations.Configurations),
+		PersistedScriptActions: scriptActions,
+	}
+
+	contents, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("serializing the configuration: %+v", err)
+	}
+
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return "", fmt.Errorf("creating the directory: %+v", err)
+	}
+
+	fileName := filepath.Join(directory, fmt.Sprintf("%s-%s.json", id.Name, exportedAt.Format("20060102T150405Z")))
+	if err := os.WriteFile(fileName, contents, 0o600); err != nil {
+		return "", fmt.Errorf("writing %q: %+v", fileName, err)
+	}
+
+	return fileName, nil
+}
+
+// hdInsightClusterSecretConfigurationKeyPrefixes are the prefixes of the configuration keys which are named after the
+// resource they grant access to, such as `fs.azure.account.key.<account>.blob.core.windows.net` within `core-site`
+var hdInsightClusterSecretConfigurationKeyPrefixes = []string{
+	"fs.azure.account.key.",
+	"fs.azure.sas.",
+}
+
+// hdInsightClusterSecretConfigurationKeyTerms are the terms which, when contained in the last segment of a
+// configuration key, identify its value as a secret
+var hdInsightClusterSecretConfigurationKeyTerms = []string{
+	"password",
+	"secret",
+	"key",
+	"sas",
+	"credential",
+}
+
+// redactHDInsightClusterConfigurations returns a copy of the configurations where the value of any secret (such as the
+// gateway password, the metastore connection passwords and the storage account keys) is masked
+func redactHDInsightClusterConfigurations(input map[string]map[string]*string) map[string]map[string]*string {
+	output := make(map[string]map[string]*string, len(input))
+	for configuration, values := range input {
+		redacted := make(map[string]*string, len(values))
+		for k, v := range values {
+			if v != nil && hdinsightClusterConfigurationIsSecret(k) {
+				v = utils.String("*****")
+			}
+			redacted[k] = v
+		}
+		output[configuration] = redacted
+	}
+
+	return output
+}
+
+func hdinsightClusterConfigurationIsSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, prefix := range hdInsightClusterSecretConfigurationKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	// only the last segment is compared, so that e.g. `restAuthCredential.username` isn't masked
+	segment := key[strings.LastIndex(key, ".")+1:]
+	for _, term := range hdInsightClusterSecretConfigurationKeyTerms {
+		if strings.Contains(segment, term) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,1946 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+func TestHDInsightClusterReadError(t *testing.T) {
+	err := hdinsightClusterReadError(context.Background(), "Configuration for example", errors.New("boom"))
+	if !strings.HasPrefix(err.Error(), "retrieving Configuration for example") {
+		t.Fatalf("Expected a retrieving error but got %q", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err = hdinsightClusterReadError(ctx, "Configuration for example", ctx.Err())
+	if !strings.HasPrefix(err.Error(), "timed out reading Configuration for example - consider increasing the `read` timeout") {
+		t.Fatalf("Expected a timed out error but got %q", err.Error())
+	}
+}
+
+func TestExpandHDInsightClusterIdentity(t *testing.T) {
+	storageIdentityId := "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/storage"
+	workloadIdentityId := "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/workload"
+
+	existing := addHDInsightClusterUserAssignedIdentity(nil, storageIdentityId)
+	input := []interface{}{
+		map[string]interface{}{
+			"type":         "UserAssigned",
+			"identity_ids": pluginsdk.NewSet(pluginsdk.HashString, []interface{}{workloadIdentityId, strings.ToUpper(storageIdentityId)}),
+		},
+	}
+
+	actual, err := expandHDInsightClusterIdentity(input, existing)
+	if err != nil {
+		t.Fatalf("expanding identity: %+v", err)
+	}
+
+	if actual.Type != hdinsight.ResourceIdentityTypeUserAssigned {
+		t.Fatalf("Expected the identity type to be %q but got %q", hdinsight.ResourceIdentityTypeUserAssigned, actual.Type)
+	}
+	if len(actual.UserAssignedIdentities) != 2 {
+		t.Fatalf("Expected 2 User Assigned Identities but got %d: %+v", len(actual.UserAssignedIdentities), actual.UserAssignedIdentities)
+	}
+	for _, id := range []string{storageIdentityId, workloadIdentityId} {
+		if _, ok := actual.UserAssignedIdentities[id]; !ok {
+			t.Fatalf("Expected the User Assigned Identity %q to be attached but got %+v", id, actual.UserAssignedIdentities)
+		}
+	}
+
+	none, err := expandHDInsightClusterIdentity([]interface{}{}, nil)
+	if err != nil {
+		t.Fatalf("expanding empty identity: %+v", err)
+	}
+	if none != nil {
+		t.Fatalf("Expected no identity but got %+v", none)
+	}
+}
+
+func TestFlattenHDInsightClusterUserAssignedIdentities(t *testing.T) {
+	storageIdentityId := "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/storage"
+	encryptionIdentityId := "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/encryption"
+	workloadIdentityId := "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/workload"
+
+	input := &hdinsight.ClusterIdentity{
+		Type: hdinsight.ResourceIdentityTypeUserAssigned,
+		UserAssignedIdentities: map[string]*hdinsight.ClusterIdentityUserAssignedIdentitiesValue{
+			storageIdentityId:    {},
+			encryptionIdentityId: {},
+			workloadIdentityId:   {},
+		},
+	}
+	referenced := []string{storageIdentityId, strings.ToUpper(encryptionIdentityId)}
+
+	testData := []struct {
+		name       string
+		configured []interface{}
+		expected   []string
+	}{
+		{
+			name:       "referenced identities are excluded",
+			configured: []interface{}{},
+			expected:   []string{workloadIdentityId},
+		},
+		{
+			name: "referenced identities which are also configured are kept",
+			configured: []interface{}{
+				map[string]interface{}{
+					"type":         "UserAssigned",
+					"identity_ids": pluginsdk.NewSet(pluginsdk.HashString, []interface{}{workloadIdentityId, encryptionIdentityId}),
+				},
+			},
+			expected: []string{encryptionIdentityId, workloadIdentityId},
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q", v.name)
+
+		actual, err := flattenHDInsightClusterUserAssignedIdentities(input, referenced, v.configured)
+		if err != nil {
+			t.Fatalf("flattening identity: %+v", err)
+		}
+		if len(*actual) != 1 {
+			t.Fatalf("Expected a single identity block but got %+v", *actual)
+		}
+
+		identityIds := (*actual)[0].(map[string]interface{})["identity_ids"].([]string)
+		sort.Strings(identityIds)
+		if !reflect.DeepEqual(identityIds, v.expected) {
+			t.Fatalf("Expected the identity_ids %+v but got %+v", v.expected, identityIds)
+		}
+	}
+
+	none, err := flattenHDInsightClusterUserAssignedIdentities(input, []string{storageIdentityId, encryptionIdentityId, workloadIdentityId}, []interface{}{})
+	if err != nil {
+		t.Fatalf("flattening identity: %+v", err)
+	}
+	if len(*none) != 0 {
+		t.Fatalf("Expected no identity block when every identity is referenced but got %+v", *none)
+	}
+}
+
+func TestHDInsightClusterCurrentWorkerCount(t *testing.T) {
+	input := &hdinsight.ComputeProfile{
+		Roles: &[]hdinsight.Role{
+			{
+				Name:                utils.String("headnode"),
+				TargetInstanceCount: utils.Int32(2),
+			},
+			{
+				Name:                utils.String("workernode"),
+				TargetInstanceCount: utils.Int32(4),
+			},
+		},
+	}
+
+	// without autoscale the count comes from the compute profile, so the hosts API isn't called
+	actual, err := hdinsightClusterCurrentWorkerCount(context.Background(), nil, "group1", "cluster1", input)
+	if err != nil {
+		t.Fatalf("retrieving current worker count: %+v", err)
+	}
+	if actual != 4 {
+		t.Fatalf("Expected the current worker count to be 4 but got %d", actual)
+	}
+
+	actual, err = hdinsightClusterCurrentWorkerCount(context.Background(), nil, "group1", "cluster1", nil)
+	if err != nil {
+		t.Fatalf("retrieving current worker count: %+v", err)
+	}
+	if actual != 0 {
+		t.Fatalf("Expected the current worker count to be 0 but got %d", actual)
+	}
+}
+
+func TestHDInsightClusterCurrentWorkerCountNotReady(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client := hdinsight.NewVirtualMachinesClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000")
+	input := &hdinsight.ComputeProfile{
+		Roles: &[]hdinsight.Role{
+			{
+				Name:                   utils.String("workernode"),
+				TargetInstanceCount:    utils.Int32(3),
+				AutoscaleConfiguration: &hdinsight.Autoscale{Capacity: &hdinsight.AutoscaleCapacity{MinInstanceCount: utils.Int32(3), MaxInstanceCount: utils.Int32(6)}},
+			},
+		},
+	}
+
+	// the hosts can't be listed whilst the cluster is being updated (or has failed), so the target instance count is used
+	actual, err := hdinsightClusterCurrentWorkerCount(context.Background(), &client, "group1", "cluster1", input)
+	if err != nil {
+		t.Fatalf("retrieving current worker count: %+v", err)
+	}
+	if actual != 3 {
+		t.Fatalf("Expected the current worker count to be 3 but got %d", actual)
+	}
+}
+
+func TestUpdateHDInsightClusterTags(t *testing.T) {
+	var method, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+
+		// the cluster is returned as-is, including when it's in a Failed state
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"properties":{"clusterState":"Error","provisioningState":"Failed"}}`))
+	}))
+	defer server.Close()
+
+	client := hdinsight.NewClustersClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000")
+	if err := updateHDInsightClusterTags(context.Background(), &client, "Spark", "group1", "cluster1", map[string]*string{"owner": utils.String("data-platform")}); err != nil {
+		t.Fatalf("updating tags: %+v", err)
+	}
+
+	if method != http.MethodPatch {
+		t.Fatalf("Expected the tags to be updated using a PATCH but got a %s", method)
+	}
+	if expected := `{"tags":{"owner":"data-platform"}}`; body != expected {
+		t.Fatalf("Expected the request body %s but got %s", expected, body)
+	}
+}
+
+func TestHDInsightClusterNonPersistedScriptActionNames(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{
+			"head_node": []interface{}{
+				map[string]interface{}{
+					"script_actions": []interface{}{
+						map[string]interface{}{
+							"name":      "kernel-tuning",
+							"uri":       "https://example.com/kernel-tuning.sh",
+							"persisted": false,
+						},
+					},
+				},
+			},
+			"worker_node": []interface{}{
+				map[string]interface{}{
+					"script_actions": []interface{}{
+						map[string]interface{}{
+							"name":      "install-libraries",
+							"uri":       "https://example.com/install-libraries.sh",
+							"persisted": true,
+						},
+					},
+				},
+			},
+			"zookeeper_node": []interface{}{},
+		},
+	}
+
+	actual := hdinsightClusterNonPersistedScriptActionNames(input)
+	if len(actual) != 1 || actual[0] != "kernel-tuning" {
+		t.Fatalf("Expected only `kernel-tuning` to be non-persisted but got %+v", actual)
+	}
+}
+
+func TestFlattenHDInsightAzureMonitor(t *testing.T) {
+	extension := hdinsight.AzureMonitorResponse{
+		ClusterMonitoringEnabled: utils.Bool(true),
+		WorkspaceID:              utils.String("00000000-0000-0000-0000-000000000000"),
+	}
+	existing := []interface{}{
+		map[string]interface{}{
+			"log_analytics_workspace_id": "00000000-0000-0000-0000-000000000000",
+			"primary_key":                "rotated-key",
+			"enabled":                    true,
+		},
+	}
+
+	actual := flattenHDInsightAzureMonitor(extension, existing)
+	if len(actual) != 1 || actual[0].(map[string]string)["primary_key"] != "rotated-key" {
+		t.Fatalf("Expected the existing `primary_key` to be kept but got %+v", actual)
+	}
+
+	actual = flattenHDInsightAzureMonitor(extension, []interface{}{})
+	if len(actual) != 1 || actual[0].(map[string]string)["primary_key"] != "" {
+		t.Fatalf("Expected an empty `primary_key` when importing but got %+v", actual)
+	}
+
+	if actual := flattenHDInsightAzureMonitor(hdinsight.AzureMonitorResponse{ClusterMonitoringEnabled: utils.Bool(false)}, existing); actual != nil {
+		t.Fatalf("Expected no extension when Azure Monitor is disabled but got %+v", actual)
+	}
+}
+
+func TestHDInsightClusterErrorIsScriptActionFailure(t *testing.T) {
+	if !hdinsightClusterErrorIsScriptActionFailure(errors.New("Code=\"InvalidDocumentErrorCode\" Message=\"ScriptActionFailed: Execution of the script action 'install-libraries' failed\"")) {
+		t.Fatalf("Expected a script action error to be detected")
+	}
+
+	if hdinsightClusterErrorIsScriptActionFailure(errors.New("Code=\"QuotaExceeded\" Message=\"Operation could not be completed as it results in exceeding approved cores quota\"")) {
+		t.Fatalf("Expected a quota error not to be detected as a script action error")
+	}
+
+	if hdinsightClusterErrorIsScriptActionFailure(nil) {
+		t.Fatalf("Expected no error not to be detected as a script action error")
+	}
+}
+
+func TestFormatHDInsightScriptActionFailure(t *testing.T) {
+	actual := formatHDInsightScriptActionFailure(hdinsight.RuntimeScriptActionDetail{
+		Name:             utils.String("install-libraries"),
+		Status:           utils.String("Failed"),
+		DebugInformation: utils.String("exit code 1, logs: https://example.blob.core.windows.net/logs"),
+	})
+	expected := "* \"install-libraries\" (Status \"Failed\"): exit code 1, logs: https://example.blob.core.windows.net/logs"
+	if actual != expected {
+		t.Fatalf("Expected %q but got %q", expected, actual)
+	}
+
+	actual = formatHDInsightScriptActionFailure(hdinsight.RuntimeScriptActionDetail{
+		Name:   utils.String("install-libraries"),
+		Status: utils.String("Failed"),
+	})
+	expected = "* \"install-libraries\" (Status \"Failed\")"
+	if actual != expected {
+		t.Fatalf("Expected %q but got %q", expected, actual)
+	}
+}
+
+func TestHDInsightClusterCreationInterrupted(t *testing.T) {
+	if hdinsightClusterCreationInterrupted(context.Background()) {
+		t.Fatalf("Expected an active context not to be interrupted")
+	}
+
+	interruptedCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if !hdinsightClusterCreationInterrupted(interruptedCtx) {
+		t.Fatalf("Expected a cancelled context to be interrupted")
+	}
+
+	timedOutCtx, timedOutCancel := context.WithTimeout(context.Background(), 0)
+	defer timedOutCancel()
+	<-timedOutCtx.Done()
+	if hdinsightClusterCreationInterrupted(timedOutCtx) {
+		t.Fatalf("Expected a context which timed out not to be interrupted")
+	}
+}
+
+func TestHDInsightClusterConfigurationsNotReady(t *testing.T) {
+	testData := []struct {
+		statusCode int
+		expected   bool
+	}{
+		{statusCode: http.StatusOK, expected: false},
+		{statusCode: http.StatusNotFound, expected: false},
+		{statusCode: http.StatusConflict, expected: true},
+		{statusCode: http.StatusPreconditionFailed, expected: true},
+		{statusCode: http.StatusInternalServerError, expected: false},
+	}
+
+	for _, v := range testData {
+		resp := autorest.Response{Response: &http.Response{StatusCode: v.statusCode}}
+		if actual := hdinsightClusterConfigurationsNotReady(resp); actual != v.expected {
+			t.Fatalf("Expected %t for a %d but got %t", v.expected, v.statusCode, actual)
+		}
+	}
+
+	if hdinsightClusterConfigurationsNotReady(autorest.Response{}) {
+		t.Fatalf("Expected a missing response not to be considered not ready")
+	}
+}
+
+func TestHDInsightClusterConfigurationsUnreachable(t *testing.T) {
+	privateLink := func(v hdinsight.PrivateLink) *hdinsight.ClusterGetProperties {
+		return &hdinsight.ClusterGetProperties{
+			NetworkProperties: &hdinsight.NetworkProperties{
+				ResourceProviderConnection: hdinsight.ResourceProviderConnectionOutbound,
+				PrivateLink:                v,
+			},
+		}
+	}
+	response := func(statusCode int) autorest.Response {
+		return autorest.Response{Response: &http.Response{StatusCode: statusCode}}
+	}
+
+	testData := []struct {
+		name     string
+		props    *hdinsight.ClusterGetProperties
+		resp     autorest.Response
+		expected bool
+	}{
+		{name: "private link without a response", props: privateLink(hdinsight.PrivateLinkEnabled), resp: autorest.Response{}, expected: true},
+		{name: "private link forbidden", props: privateLink(hdinsight.PrivateLinkEnabled), resp: response(http.StatusForbidden), expected: true},
+		{name: "private link server error", props: privateLink(hdinsight.PrivateLinkEnabled), resp: response(http.StatusInternalServerError)},
+		{name: "public forbidden", props: privateLink(hdinsight.PrivateLinkDisabled), resp: response(http.StatusForbidden)},
+		{name: "public without a response", props: privateLink(hdinsight.PrivateLinkDisabled), resp: autorest.Response{}},
+		{name: "no network properties", props: &hdinsight.ClusterGetProperties{}, resp: autorest.Response{}},
+		{name: "no properties", resp: autorest.Response{}},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q", v.name)
+
+		if actual := hdinsightClusterConfigurationsUnreachable(context.Background(), v.props, v.resp); actual != v.expected {
+			t.Fatalf("Expected %t but got %t", v.expected, actual)
+		}
+	}
+
+	// timing out is reported as such, rather than assumed to be a connectivity failure
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if hdinsightClusterConfigurationsUnreachable(ctx, privateLink(hdinsight.PrivateLinkEnabled), autorest.Response{}) {
+		t.Fatalf("Expected a cancelled request not to be considered unreachable")
+	}
+}
+
+func TestFlattenHDInsightClusterApplicationEndpoints(t *testing.T) {
+	expected := map[string]interface{}{
+		"ambari":        "https://example.azurehdinsight.net/",
+		"jupyter":       "https://example.azurehdinsight.net/jupyter",
+		"livy":          "https://example.azurehdinsight.net/livy/v1",
+		"spark_history": "https://example.azurehdinsight.net/sparkhistory",
+		"yarn":          "https://example.azurehdinsight.net/yarnui/hn/cluster",
+		"zeppelin":      "https://example.azurehdinsight.net/zeppelin",
+	}
+	if actual := flattenHDInsightClusterApplicationEndpoints("SPARK", "example.azurehdinsight.net"); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	expected = map[string]interface{}{
+		"ambari": "https://example.azurehdinsight.net/",
+	}
+	if actual := flattenHDInsightClusterApplicationEndpoints("kafka", "example.azurehdinsight.net"); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	if actual := flattenHDInsightClusterApplicationEndpoints("spark", ""); len(actual) != 0 {
+		t.Fatalf("Expected no application endpoints without a HTTPS endpoint but got %+v", actual)
+	}
+}
+
+func TestHDInsightClusterKindCasing(t *testing.T) {
+	for _, kind := range []string{"Spark", "SPARK", "spark", " Spark "} {
+		if actual := normalizeHDInsightClusterKind(kind); actual != "spark" {
+			t.Fatalf("Expected %q to be normalized to \"spark\" but got %q", kind, actual)
+		}
+		if !hdinsightClusterKindEqual(kind, "Spark") {
+			t.Fatalf("Expected %q to be the same kind as \"Spark\"", kind)
+		}
+
+		expected := flattenHDInsightClusterApplicationEndpoints("Spark", "example.azurehdinsight.net")
+		if actual := flattenHDInsightClusterApplicationEndpoints(kind, "example.azurehdinsight.net"); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("Expected the application endpoints for %q to be %+v but got %+v", kind, expected, actual)
+		}
+
+		if actual := hdinsightEnterpriseSecurityPackageSupportedVersions(kind); !reflect.DeepEqual(actual, hdInsightEnterpriseSecurityPackageSupportedVersions["Spark"]) {
+			t.Fatalf("Expected the ESP versions for %q to be %+v but got %+v", kind, hdInsightEnterpriseSecurityPackageSupportedVersions["Spark"], actual)
+		}
+	}
+
+	for _, kind := range []string{"INTERACTIVEHIVE", "InteractiveHive", "interactivehive"} {
+		if actual := flattenHDInsightClusterApplicationEndpoints(kind, "example.azurehdinsight.net"); actual["hive"] != "https://example.azurehdinsight.net/hive2" {
+			t.Fatalf("Expected the hive endpoint for %q but got %+v", kind, actual)
+		}
+	}
+
+	if hdinsightClusterKindEqual("Spark", "Hadoop") {
+		t.Fatalf("Expected different kinds not to be equal")
+	}
+}
+
+func TestHDInsightClusterExists(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		exists     bool
+		valid      bool
+	}{
+		{
+			statusCode: http.StatusOK,
+			exists:     true,
+			valid:      true,
+		},
+		{
+			statusCode: http.StatusNotFound,
+			exists:     false,
+			valid:      true,
+		},
+		{
+			statusCode: http.StatusForbidden,
+			exists:     false,
+			valid:      true,
+		},
+		{
+			statusCode: http.StatusBadRequest,
+			exists:     false,
+			valid:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			client := hdinsight.NewClustersClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000")
+			id := parse.NewClusterID("00000000-0000-0000-0000-000000000000", "group1", "cluster1")
+
+			exists, err := hdinsightClusterExists(context.Background(), &client, id)
+			if valid := err == nil; valid != tt.valid {
+				t.Fatalf("Expected valid %t but got %t (%v)", tt.valid, valid, err)
+			}
+			if exists != tt.exists {
+				t.Fatalf("Expected exists %t but got %t", tt.exists, exists)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterExistsResourceProviderNotRegistered(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":{"code":"MissingSubscriptionRegistration","message":"The subscription is not registered to use namespace 'Microsoft.HDInsight'."}}`))
+	}))
+	defer server.Close()
+
+	client := hdinsight.NewClustersClientWithBaseURI(server.URL, "00000000-0000-0000-0000-000000000000")
+	id := parse.NewClusterID("00000000-0000-0000-0000-000000000000", "group1", "cluster1")
+
+	_, err := hdinsightClusterExists(context.Background(), &client, id)
+	if err == nil || !strings.Contains(err.Error(), "az provider register --namespace Microsoft.HDInsight") {
+		t.Fatalf("Expected an error explaining how to register the Resource Provider but got %v", err)
+	}
+
+	other := errors.New("some other error")
+	if actual := hdinsightResourceProviderRegistrationError(id.SubscriptionId, other); actual != other {
+		t.Fatalf("Expected other errors to be returned as-is but got %v", actual)
+	}
+}
+
+func TestHDInsightClusterHealthGate(t *testing.T) {
+	testData := []struct {
+		Name       string
+		Input      *hdinsight.ClusterGetProperties
+		Pending    bool
+		ErrorMatch string
+	}{
+		{
+			Name:  "no properties",
+			Input: nil,
+		},
+		{
+			Name:  "no cluster state",
+			Input: &hdinsight.ClusterGetProperties{ProvisioningState: hdinsight.ClusterProvisioningStateSucceeded},
+		},
+		{
+			Name:  "running",
+			Input: &hdinsight.ClusterGetProperties{ClusterState: utils.String("Running"), ProvisioningState: hdinsight.ClusterProvisioningStateSucceeded},
+		},
+		{
+			Name:  "running after a failed operation",
+			Input: &hdinsight.ClusterGetProperties{ClusterState: utils.String("Running"), ProvisioningState: hdinsight.ClusterProvisioningStateFailed},
+		},
+		{
+			Name:    "applying a change",
+			Input:   &hdinsight.ClusterGetProperties{ClusterState: utils.String("HdInsightConfiguration"), ProvisioningState: hdinsight.ClusterProvisioningStateInProgress},
+			Pending: true,
+		},
+		{
+			Name:    "accepted with different casing",
+			Input:   &hdinsight.ClusterGetProperties{ClusterState: utils.String("accepted")},
+			Pending: true,
+		},
+		{
+			// the ARM provisioning state alone would suggest the cluster is healthy
+			Name:       "error whilst provisioned",
+			Input:      &hdinsight.ClusterGetProperties{ClusterState: utils.String("Error"), ProvisioningState: hdinsight.ClusterProvisioningStateSucceeded},
+			ErrorMatch: `the cluster state is "Error" (provisioning state "Succeeded")`,
+		},
+		{
+			Name:       "deleting",
+			Input:      &hdinsight.ClusterGetProperties{ClusterState: utils.String("Deleting"), ProvisioningState: hdinsight.ClusterProvisioningStateDeleting},
+			ErrorMatch: `"Deleting"`,
+		},
+	}
+
+	for _, v := range testData {
+		t.Run(v.Name, func(t *testing.T) {
+			pending, err := hdinsightClusterHealthGate(v.Input)
+			if v.ErrorMatch != "" {
+				if err == nil || !strings.Contains(err.Error(), v.ErrorMatch) {
+					t.Fatalf("Expected an error containing %q but got %v", v.ErrorMatch, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %+v", err)
+			}
+			if pending != v.Pending {
+				t.Fatalf("Expected pending to be %t but got %t", v.Pending, pending)
+			}
+		})
+	}
+}
+
+func TestFlattenHDInsightPersistedScriptActions(t *testing.T) {
+	actual := flattenHDInsightPersistedScriptActions([]hdinsight.RuntimeScriptActionDetail{
+		{
+			Name:       utils.String("install-agent"),
+			URI:        utils.String("https://example.com/install.sh"),
+			Parameters: utils.String("--verbose"),
+			Roles:      &[]string{"headnode", "workernode"},
+		},
+		{
+			Name: utils.String("no-parameters"),
+			URI:  utils.String("https://example.com/other.sh"),
+		},
+	})
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"name":       "install-agent",
+			"uri":        "https://example.com/install.sh",
+			"parameters": "--verbose",
+			"roles":      []interface{}{"headnode", "workernode"},
+		},
+		map[string]interface{}{
+			"name":       "no-parameters",
+			"uri":        "https://example.com/other.sh",
+			"parameters": "",
+			"roles":      []interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+}
+
+func TestFlattenHDInsightClusterApplications(t *testing.T) {
+	clusterId := parse.NewClusterID("00000000-0000-0000-0000-000000000000", "group1", "cluster1")
+	applications := []hdinsight.Application{
+		{
+			Name: utils.String("cluster1/agent-monitoring"),
+			Properties: &hdinsight.ApplicationProperties{
+				ApplicationType:   utils.String("CustomApplication"),
+				ProvisioningState: utils.String("Failed"),
+				ApplicationState:  utils.String("Failed"),
+				ComputeProfile: &hdinsight.ComputeProfile{
+					Roles: &[]hdinsight.Role{{
+						Name:                utils.String("edgenode"),
+						HardwareProfile:     &hdinsight.HardwareProfile{VMSize: utils.String("Standard_D3_V2")},
+						TargetInstanceCount: utils.Int32(2),
+					}},
+				},
+				HTTPSEndpoints: &[]hdinsight.ApplicationGetHTTPSEndpoint{{
+					AccessModes:     &[]string{"WebPage"},
+					Location:        utils.String("agent.cluster1.azurehdinsight.net"),
+					DestinationPort: utils.Int32(8080),
+					PublicPort:      utils.Int32(443),
+				}},
+				SSHEndpoints: &[]hdinsight.ApplicationGetEndpoint{{
+					Location:        utils.String("agent-ssh.cluster1.azurehdinsight.net"),
+					DestinationPort: utils.Int32(22),
+					PublicPort:      utils.Int32(22),
+				}},
+				Errors: &[]hdinsight.Errors{{
+					Code:    utils.String("ScriptActionFailed"),
+					Message: utils.String("install.sh exited with code 1"),
+				}},
+			},
+		},
+		{
+			Name: utils.String("Agent-Logging"),
+		},
+		{
+			Name: utils.String("notebooks"),
+			Properties: &hdinsight.ApplicationProperties{
+				ApplicationType: utils.String("CustomApplication"),
+			},
+		},
+	}
+
+	actual := flattenHDInsightClusterApplications(clusterId, applications, "agent-")
+	expected := []interface{}{
+		map[string]interface{}{
+			"id":                    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.HDInsight/clusters/cluster1/applications/agent-monitoring",
+			"name":                  "agent-monitoring",
+			"application_type":      "CustomApplication",
+			"provisioning_state":    "Failed",
+			"application_state":     "Failed",
+			"vm_size":               "Standard_D3_V2",
+			"target_instance_count": 2,
+			"https_endpoints": []interface{}{
+				map[string]interface{}{
+					"location":             "agent.cluster1.azurehdinsight.net",
+					"public_port":          443,
+					"destination_port":     8080,
+					"private_ip_address":   "",
+					"sub_domain_suffix":    "",
+					"access_modes":         []interface{}{"WebPage"},
+					"disable_gateway_auth": false,
+				},
+			},
+			"ssh_endpoints": []interface{}{
+				map[string]interface{}{
+					"location":           "agent-ssh.cluster1.azurehdinsight.net",
+					"public_port":        22,
+					"destination_port":   22,
+					"private_ip_address": "",
+				},
+			},
+			"errors": []interface{}{
+				map[string]interface{}{
+					"code":    "ScriptActionFailed",
+					"message": "install.sh exited with code 1",
+				},
+			},
+		},
+		map[string]interface{}{
+			"id":                    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.HDInsight/clusters/cluster1/applications/Agent-Logging",
+			"name":                  "Agent-Logging",
+			"application_type":      "",
+			"provisioning_state":    "",
+			"application_state":     "",
+			"vm_size":               "",
+			"target_instance_count": 0,
+			"https_endpoints":       []interface{}{},
+			"ssh_endpoints":         []interface{}{},
+			"errors":                []interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	if actual := flattenHDInsightClusterApplications(clusterId, applications, ""); len(actual) != 3 {
+		t.Fatalf("Expected all 3 Applications to be returned without a prefix but got %d", len(actual))
+	}
+}
+
+func TestExpandHDInsightKeyVaultPasswords(t *testing.T) {
+	secretId := "https://example.vault.azure.net/secrets/gateway/fdf067c93bbb4b22bff4d8b7a9a56217"
+	lookup := func(id string) (string, error) {
+		if id != secretId {
+			return "", fmt.Errorf("unexpected secret %q", id)
+		}
+		return "from-key-vault", nil
+	}
+
+	gateway := []interface{}{
+		map[string]interface{}{
+			"username":                     "acctestusrgw",
+			"password":                     "",
+			"password_key_vault_secret_id": secretId,
+		},
+	}
+	if err := expandHDInsightKeyVaultPasswords(gateway, lookup); err != nil {
+		t.Fatalf("expanding gateway: %+v", err)
+	}
+	if actual := gateway[0].(map[string]interface{})["password"]; actual != "from-key-vault" {
+		t.Fatalf("Expected the password to be retrieved from Key Vault but got %q", actual)
+	}
+
+	roles := []interface{}{
+		map[string]interface{}{
+			"head_node": []interface{}{
+				map[string]interface{}{
+					"password":                     "",
+					"password_key_vault_secret_id": secretId,
+				},
+			},
+			"worker_node": []interface{}{
+				map[string]interface{}{
+					"password":                     "from-config",
+					"password_key_vault_secret_id": "",
+				},
+			},
+		},
+	}
+	if err := expandHDInsightRolesKeyVaultPasswords(roles, lookup); err != nil {
+		t.Fatalf("expanding roles: %+v", err)
+	}
+	role := roles[0].(map[string]interface{})
+	if actual := role["head_node"].([]interface{})[0].(map[string]interface{})["password"]; actual != "from-key-vault" {
+		t.Fatalf("Expected the `head_node` password to be retrieved from Key Vault but got %q", actual)
+	}
+	if actual := role["worker_node"].([]interface{})[0].(map[string]interface{})["password"]; actual != "from-config" {
+		t.Fatalf("Expected the `worker_node` password to be kept but got %q", actual)
+	}
+
+	keys := []interface{}{
+		map[string]interface{}{
+			"password_key_vault_secret_id": "https://example.vault.azure.net/keys/gateway/fdf067c93bbb4b22bff4d8b7a9a56217",
+		},
+	}
+	if err := expandHDInsightKeyVaultPasswords(keys, lookup); err == nil {
+		t.Fatalf("Expected an error for a Key Vault Key but got none")
+	}
+}
+
+func TestParseHDInsightClusterImportId(t *testing.T) {
+	subscriptionId := "12345678-1234-9876-4563-123456789012"
+	expected := "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.HDInsight/clusters/cluster1"
+
+	testData := []struct {
+		input string
+		valid bool
+	}{
+		{
+			input: expected,
+			valid: true,
+		},
+		{
+			input: "resourceGroups/group1/providers/Microsoft.HDInsight/clusters/cluster1",
+			valid: true,
+		},
+		{
+			input: "/resourceGroups/group1/providers/Microsoft.HDInsight/clusters/cluster1",
+			valid: true,
+		},
+		{
+			input: "group1/cluster1",
+			valid: true,
+		},
+		{
+			input: "cluster1",
+			valid: false,
+		},
+		{
+			input: "group1/",
+			valid: false,
+		},
+		{
+			input: "resourceGroups/group1/providers/Microsoft.Storage/storageAccounts/account1",
+			valid: false,
+		},
+		{
+			input: "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1",
+			valid: false,
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.input)
+
+		id, err := parseHDInsightClusterImportId(v.input, subscriptionId)
+		if !v.valid {
+			if err == nil {
+				t.Fatalf("Expected an error but got %q", id.ID())
+			}
+			if !strings.Contains(err.Error(), "{resourceGroupName}/{clusterName}") {
+				t.Fatalf("Expected the error to list the accepted formats but got %q", err.Error())
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Expected no error but got %+v", err)
+		}
+		if id.ID() != expected {
+			t.Fatalf("Expected %q but got %q", expected, id.ID())
+		}
+	}
+}
+
+func TestRedactHDInsightClusterConfigurations(t *testing.T) {
+	input := map[string]map[string]*string{
+		"gateway": {
+			"restAuthCredential.isEnabled": utils.String("true"),
+			"restAuthCredential.username":  utils.String("admin"),
+			"restAuthCredential.password":  utils.String("P@ssw0rd"),
+		},
+		"hive-site": {
+			"javax.jdo.option.ConnectionPassword": utils.String("P@ssw0rd"),
+			"javax.jdo.option.ConnectionUserName": utils.String("sqladmin"),
+		},
+		"core-site": {
+			"fs.defaultFS": utils.String("wasb://data@example.blob.core.windows.net"),
+			"fs.azure.account.key.example.blob.core.windows.net":         utils.String("c3RvcmFnZWFjY291bnRrZXk="),
+			"fs.azure.account.keyprovider.example.blob.core.windows.net": utils.String("org.apache.hadoop.fs.azure.ShellDecryptionKeyProvider"),
+			"fs.azure.sas.data.example.blob.core.windows.net":            utils.String("sv=2021-06-08&sig=secret"),
+			"fs.azure.account.oauth2.client.secret":                      utils.String("s3cr3t"),
+			"fs.azure.account.oauth2.client.id":                          utils.String("00000000-0000-0000-0000-000000000000"),
+		},
+	}
+
+	expected := map[string]map[string]*string{
+		"gateway": {
+			"restAuthCredential.isEnabled": utils.String("true"),
+			"restAuthCredential.username":  utils.String("admin"),
+			"restAuthCredential.password":  utils.String("*****"),
+		},
+		"hive-site": {
+			"javax.jdo.option.ConnectionPassword": utils.String("*****"),
+			"javax.jdo.option.ConnectionUserName": utils.String("sqladmin"),
+		},
+		"core-site": {
+			"fs.defaultFS": utils.String("wasb://data@example.blob.core.windows.net"),
+			"fs.azure.account.key.example.blob.core.windows.net":         utils.String("*****"),
+			"fs.azure.account.keyprovider.example.blob.core.windows.net": utils.String("org.apache.hadoop.fs.azure.ShellDecryptionKeyProvider"),
+			"fs.azure.sas.data.example.blob.core.windows.net":            utils.String("*****"),
+			"fs.azure.account.oauth2.client.secret":                      utils.String("*****"),
+			"fs.azure.account.oauth2.client.id":                          utils.String("00000000-0000-0000-0000-000000000000"),
+		},
+	}
+
+	if actual := redactHDInsightClusterConfigurations(input); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	if v := *input["gateway"]["restAuthCredential.password"]; v != "P@ssw0rd" {
+		t.Fatalf("Expected the input not to be modified but got %q", v)
+	}
+}
+
+func TestHDInsightClusterGatewayAuthenticationMode(t *testing.T) {
+	testData := []struct {
+		securityProfile *hdinsight.SecurityProfile
+		gatewayEnabled  bool
+		expected        string
+	}{
+		{
+			securityProfile: nil,
+			gatewayEnabled:  true,
+			expected:        "basic",
+		},
+		{
+			securityProfile: nil,
+			gatewayEnabled:  false,
+			expected:        "none",
+		},
+		{
+			securityProfile: &hdinsight.SecurityProfile{
+				DirectoryType: hdinsight.DirectoryTypeActiveDirectory,
+				Domain:        utils.String("example.com"),
+			},
+			gatewayEnabled: true,
+			expected:       "aad",
+		},
+	}
+
+	for _, v := range testData {
+		if actual := hdinsightClusterGatewayAuthenticationMode(v.securityProfile, v.gatewayEnabled); actual != v.expected {
+			t.Fatalf("Expected %q but got %q", v.expected, actual)
+		}
+	}
+}
+
+func TestExpandHDInsightComponentVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []interface{}
+		expected string
+		err      string
+	}{
+		{
+			name: "expected key",
+			input: []interface{}{
+				map[string]interface{}{
+					"spark": "3.3",
+				},
+			},
+			expected: "3.3",
+		},
+		{
+			name:  "missing block",
+			input: []interface{}{},
+			err:   "must specify `spark`",
+		},
+		{
+			name: "misspelled key",
+			input: []interface{}{
+				map[string]interface{}{
+					"sparks": "3.3",
+				},
+			},
+			err: "unsupported key `sparks` - an HDInsight Spark Cluster expects `spark`",
+		},
+		{
+			name: "key from another kind",
+			input: []interface{}{
+				map[string]interface{}{
+					"spark": "3.3",
+					"kafka": "2.4",
+				},
+			},
+			err: "unsupported key `kafka`",
+		},
+		{
+			name: "empty version",
+			input: []interface{}{
+				map[string]interface{}{
+					"spark": "",
+				},
+			},
+			err: "`component_version.0.spark` must be specified",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := expandHDInsightSparkComponentVersion(tt.input)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected an error containing %q but got %+v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %+v", err)
+			}
+			if v := actual["Spark"]; v == nil || *v != tt.expected {
+				t.Fatalf("Expected the Spark version to be %q but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterEndpointDNSLabel(t *testing.T) {
+	tests := []struct {
+		name          string
+		clusterName   string
+		httpsEndpoint string
+		expected      string
+	}{
+		{
+			name:          "from the https endpoint",
+			clusterName:   "Example",
+			httpsEndpoint: "example.azurehdinsight.net",
+			expected:      "example",
+		},
+		{
+			name:          "no public endpoints when using private link",
+			clusterName:   "Example",
+			httpsEndpoint: "",
+			expected:      "example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := hdinsightClusterEndpointDNSLabel(tt.clusterName, tt.httpsEndpoint); actual != tt.expected {
+				t.Fatalf("Expected %q but got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHDInsightEnvironmentEndpointDNSSuffix(t *testing.T) {
+	tests := map[string]string{
+		"Public":       "azurehdinsight.net",
+		"china":        "azurehdinsight.cn",
+		"USGovernment": "azurehdinsight.us",
+		"AzureStack":   "",
+	}
+
+	for environmentName, expected := range tests {
+		actual, ok := hdinsightEnvironmentEndpointDNSSuffix(environmentName)
+		if actual != expected || ok != (expected != "") {
+			t.Fatalf("Expected %q for the environment %q but got %q", expected, environmentName, actual)
+		}
+	}
+}
+
+func TestHDInsightClusterDefaultStorageEndpoint(t *testing.T) {
+	storageAccount := func(containerID string, isDefault bool) interface{} {
+		return map[string]interface{}{
+			"storage_container_id": containerID,
+			"is_default":           isDefault,
+		}
+	}
+	gen2StorageAccount := func(fileSystemID string, isDefault bool) interface{} {
+		return map[string]interface{}{
+			"filesystem_id": fileSystemID,
+			"is_default":    isDefault,
+		}
+	}
+
+	tests := []struct {
+		name                string
+		storageAccounts     []interface{}
+		gen2StorageAccounts []interface{}
+		coreSite            map[string]*string
+		expected            string
+	}{
+		{
+			name:     "no storage accounts",
+			expected: "",
+		},
+		{
+			name: "default storage account",
+			storageAccounts: []interface{}{
+				storageAccount("https://additional.blob.core.windows.net/data", false),
+				storageAccount("https://example.blob.core.windows.net/content", true),
+			},
+			expected: "wasbs://content@example.blob.core.windows.net/",
+		},
+		{
+			name: "default gen2 storage account",
+			storageAccounts: []interface{}{
+				storageAccount("https://additional.blob.core.windows.net/data", false),
+			},
+			gen2StorageAccounts: []interface{}{
+				gen2StorageAccount("https://example.dfs.core.windows.net/content", true),
+			},
+			expected: "abfss://content@example.dfs.core.windows.net/",
+		},
+		{
+			name: "sovereign cloud",
+			gen2StorageAccounts: []interface{}{
+				gen2StorageAccount("https://example.dfs.core.chinacloudapi.cn/content", true),
+			},
+			expected: "abfss://content@example.dfs.core.chinacloudapi.cn/",
+		},
+		{
+			name: "no default storage account",
+			storageAccounts: []interface{}{
+				storageAccount("https://additional.blob.core.windows.net/data", false),
+			},
+			coreSite: map[string]*string{
+				"fs.defaultFS": utils.String("wasb://content@example.blob.core.windows.net"),
+			},
+			expected: "",
+		},
+		{
+			name: "imported using wasb",
+			coreSite: map[string]*string{
+				"fs.defaultFS": utils.String("wasb://content@example.blob.core.windows.net"),
+			},
+			expected: "wasbs://content@example.blob.core.windows.net/",
+		},
+		{
+			name: "imported using abfs",
+			coreSite: map[string]*string{
+				"fs.defaultFS": utils.String("abfs://content@example.dfs.core.windows.net/"),
+			},
+			expected: "abfss://content@example.dfs.core.windows.net/",
+		},
+		{
+			name:     "imported whilst the configuration isn't available",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := hdinsightClusterDefaultStorageEndpoint(tt.storageAccounts, tt.gen2StorageAccounts, tt.coreSite); actual != tt.expected {
+				t.Fatalf("Expected %q but got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHDInsightAutoscaleMode(t *testing.T) {
+	capacity := &hdinsight.AutoscaleCapacity{
+		MinInstanceCount: utils.Int32(1),
+		MaxInstanceCount: utils.Int32(3),
+	}
+	recurrence := &hdinsight.AutoscaleRecurrence{
+		TimeZone: utils.String("UTC"),
+	}
+
+	tests := []struct {
+		name     string
+		input    *hdinsight.Autoscale
+		expected string
+	}{
+		{
+			name:     "disabled",
+			input:    nil,
+			expected: "",
+		},
+		{
+			name:     "empty",
+			input:    &hdinsight.Autoscale{},
+			expected: "",
+		},
+		{
+			name:     "capacity",
+			input:    &hdinsight.Autoscale{Capacity: capacity},
+			expected: "capacity",
+		},
+		{
+			name:     "recurrence",
+			input:    &hdinsight.Autoscale{Recurrence: recurrence},
+			expected: "recurrence",
+		},
+		{
+			name:     "both",
+			input:    &hdinsight.Autoscale{Capacity: capacity, Recurrence: recurrence},
+			expected: "capacity and recurrence",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := hdinsightAutoscaleMode(tt.input); actual != tt.expected {
+				t.Fatalf("Expected %q but got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterWorkerNodeAutoscaleEnabled(t *testing.T) {
+	properties := func(autoscale *hdinsight.Autoscale) *hdinsight.ClusterGetProperties {
+		return &hdinsight.ClusterGetProperties{
+			ComputeProfile: &hdinsight.ComputeProfile{
+				Roles: &[]hdinsight.Role{
+					{
+						Name: utils.String("headnode"),
+					},
+					{
+						Name:                   utils.String("workernode"),
+						AutoscaleConfiguration: autoscale,
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		input    *hdinsight.ClusterGetProperties
+		expected bool
+	}{
+		{
+			name:     "no properties",
+			input:    nil,
+			expected: false,
+		},
+		{
+			name:     "autoscale disabled",
+			input:    properties(nil),
+			expected: false,
+		},
+		{
+			name:     "empty autoscale configuration",
+			input:    properties(&hdinsight.Autoscale{}),
+			expected: false,
+		},
+		{
+			name: "capacity autoscale",
+			input: properties(&hdinsight.Autoscale{
+				Capacity: &hdinsight.AutoscaleCapacity{
+					MinInstanceCount: utils.Int32(1),
+					MaxInstanceCount: utils.Int32(3),
+				},
+			}),
+			expected: true,
+		},
+		{
+			name: "recurrence autoscale",
+			input: properties(&hdinsight.Autoscale{
+				Recurrence: &hdinsight.AutoscaleRecurrence{
+					TimeZone: utils.String("UTC"),
+				},
+			}),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := hdinsightClusterWorkerNodeAutoscaleEnabled(tt.input); actual != tt.expected {
+				t.Fatalf("Expected %t but got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterCostEstimate(t *testing.T) {
+	billingSpecs := hdinsight.BillingResponseListResult{
+		VMSizeProperties: &[]hdinsight.VMSizeProperty{
+			{Name: utils.String("Standard_D3_V2"), Cores: utils.Int32(4)},
+		},
+		BillingResources: &[]hdinsight.BillingResources{
+			{
+				Region: utils.String("West Europe"),
+				BillingMeters: &[]hdinsight.BillingMeters{
+					{MeterParameter: utils.String("standard_d3_v2"), Meter: utils.String("meter-d3"), Unit: utils.String("CoreHours")},
+					{MeterParameter: utils.String("Standard_A4_V2"), Meter: utils.String("meter-a4"), Unit: utils.String("VMHours")},
+				},
+				DiskBillingMeters: &[]hdinsight.DiskBillingMeters{
+					{DiskRpMeter: utils.String("meter-s30"), Sku: utils.String("S30"), Tier: hdinsight.TierStandard},
+				},
+			},
+		},
+	}
+
+	roles, err := expandHDInsightCostEstimateRoles("spark", []interface{}{
+		map[string]interface{}{"name": "head_node", "vm_size": "Standard_D3_V2", "instance_count": 0, "disks_per_node": 0},
+		map[string]interface{}{"name": "worker_node", "vm_size": "Standard_D3_V2", "instance_count": 3, "disks_per_node": 0},
+		map[string]interface{}{"name": "zookeeper_node", "vm_size": "Standard_A4_V2", "instance_count": 0, "disks_per_node": 0},
+	})
+	if err != nil {
+		t.Fatalf("expanding roles: %+v", err)
+	}
+
+	meters, err := hdinsightClusterCostEstimate(billingSpecs, "westeurope", hdinsight.TierStandard, roles)
+	if err != nil {
+		t.Fatalf("estimating: %+v", err)
+	}
+
+	expected := []struct {
+		instanceCount int
+		cores         int
+		meterId       string
+		quantity      int
+	}{
+		{instanceCount: 2, cores: 4, meterId: "meter-d3", quantity: 8},
+		{instanceCount: 3, cores: 4, meterId: "meter-d3", quantity: 12},
+		{instanceCount: 3, cores: 4, meterId: "meter-a4", quantity: 3},
+	}
+	if len(meters) != len(expected) {
+		t.Fatalf("expected %d meters but got %d", len(expected), len(meters))
+	}
+	for i, v := range expected {
+		m := meters[i]
+		if m.InstanceCount != v.instanceCount || m.Cores != v.cores || m.MeterId != v.meterId || m.HourlyMeterQuantity != v.quantity {
+			t.Fatalf("unexpected meter for %q: %+v", m.Name, m)
+		}
+	}
+
+	kafka, err := hdinsightClusterCostEstimate(billingSpecs, "westeurope", hdinsight.TierStandard, []hdinsightCostEstimateRole{
+		{Name: "worker_node", VMSize: "Standard_D3_V2", InstanceCount: 3, DisksPerNode: 2},
+	})
+	if err != nil {
+		t.Fatalf("estimating kafka: %+v", err)
+	}
+	if kafka[0].DiskMeterId != "meter-s30" || kafka[0].DiskSku != "S30" {
+		t.Fatalf("unexpected disk meter: %+v", kafka[0])
+	}
+
+	if _, err := hdinsightClusterCostEstimate(billingSpecs, "westeurope", hdinsight.TierPremium, []hdinsightCostEstimateRole{
+		{Name: "worker_node", VMSize: "Standard_D3_V2", InstanceCount: 3, DisksPerNode: 2},
+	}); err == nil {
+		t.Fatalf("expected an error when there's no managed disk meter for the tier")
+	}
+
+	roles[1].VMSize = "Standard_E64_V3"
+	if _, err := hdinsightClusterCostEstimate(billingSpecs, "westeurope", hdinsight.TierStandard, roles); err == nil || !strings.Contains(err.Error(), "Standard_A4_V2, standard_d3_v2") {
+		t.Fatalf("expected an error listing the available VM Sizes but got %v", err)
+	}
+
+	if _, err := hdinsightClusterCostEstimate(billingSpecs, "eastus", hdinsight.TierStandard, roles); err == nil {
+		t.Fatalf("expected an error for a location without billing meters")
+	}
+}
+
+func TestExpandHDInsightCostEstimateRolesInvalid(t *testing.T) {
+	cases := map[string][]interface{}{
+		"unsupported role": {
+			map[string]interface{}{"name": "edge_node", "vm_size": "Standard_D3_V2", "instance_count": 1, "disks_per_node": 0},
+		},
+		"fixed count": {
+			map[string]interface{}{"name": "head_node", "vm_size": "Standard_D3_V2", "instance_count": 3, "disks_per_node": 0},
+		},
+		"missing worker count": {
+			map[string]interface{}{"name": "worker_node", "vm_size": "Standard_D3_V2", "instance_count": 0, "disks_per_node": 0},
+		},
+		"disks on a spark worker": {
+			map[string]interface{}{"name": "worker_node", "vm_size": "Standard_D3_V2", "instance_count": 3, "disks_per_node": 2},
+		},
+		"missing roles": {
+			map[string]interface{}{"name": "head_node", "vm_size": "Standard_D3_V2", "instance_count": 0, "disks_per_node": 0},
+		},
+	}
+
+	for name, input := range cases {
+		if _, err := expandHDInsightCostEstimateRoles("spark", input); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMergeHDInsightRoleScriptActions(t *testing.T) {
+	existing := []interface{}{
+		map[string]interface{}{"name": "install-libs", "uri": "https://example.com/libs.sh", "parameters": "", "persisted": true},
+		map[string]interface{}{"name": "bootstrap", "uri": "https://example.com/bootstrap.sh", "parameters": "", "persisted": false},
+		map[string]interface{}{"name": "removed", "uri": "https://example.com/removed.sh", "parameters": "", "persisted": true},
+		map[string]interface{}{"name": "tune", "uri": "https://example.com/tune.sh", "parameters": "--fast", "persisted": true},
+	}
+	// returned in a different order to the state, with script actions added outside of Terraform
+	persisted := []hdinsight.RuntimeScriptActionDetail{
+		{Name: utils.String("tune"), URI: utils.String("https://example.com/tune.sh"), Parameters: utils.String("--fast"), Roles: &[]string{"headnode", "workernode"}},
+		{Name: utils.String("portal-b"), URI: utils.String("https://example.com/b.sh"), Roles: &[]string{"WorkerNode"}},
+		{Name: utils.String("portal-a"), URI: utils.String("https://example.com/a.sh"), Roles: &[]string{"workernode"}},
+		{Name: utils.String("install-libs"), URI: utils.String("https://example.com/libs.sh"), Roles: &[]string{"workernode"}},
+		{Name: utils.String("zookeeper-only"), URI: utils.String("https://example.com/zk.sh"), Roles: &[]string{"zookeepernode"}},
+		{Name: utils.String("edge-app"), URI: utils.String("https://example.com/app.sh"), Roles: &[]string{"workernode"}, ApplicationName: utils.String("app")},
+	}
+
+	actual := mergeHDInsightRoleScriptActions(existing, persisted, "workernode")
+	names := make([]string, 0)
+	for _, v := range actual {
+		names = append(names, v.(map[string]interface{})["name"].(string))
+	}
+	// those added outside of Terraform aren't added, since the `script_actions` can only be set when creating the cluster
+	expected := []string{"install-libs", "bootstrap", "tune"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected the script actions %+v but got %+v", expected, names)
+	}
+
+	if v := actual[2].(map[string]interface{}); v["uri"] != "https://example.com/tune.sh" || v["parameters"] != "--fast" || v["persisted"] != true {
+		t.Fatalf("Unexpected script action: %+v", v)
+	}
+
+	reversed := make([]hdinsight.RuntimeScriptActionDetail, 0)
+	for i := len(persisted) - 1; i >= 0; i-- {
+		reversed = append(reversed, persisted[i])
+	}
+	if again := mergeHDInsightRoleScriptActions(existing, reversed, "workernode"); !reflect.DeepEqual(again, actual) {
+		t.Fatalf("Expected the order returned by the API not to matter but got %+v and %+v", actual, again)
+	}
+}
+
+func TestApplyHDInsightPersistedScriptActions(t *testing.T) {
+	roles := []interface{}{
+		map[string]interface{}{
+			"head_node": []interface{}{
+				map[string]interface{}{"script_actions": []interface{}{}},
+			},
+			"worker_node": []interface{}{
+				map[string]interface{}{"script_actions": []interface{}{}},
+			},
+			"zookeeper_node": []interface{}{
+				map[string]interface{}{"script_actions": []interface{}{}},
+			},
+		},
+	}
+	persisted := []hdinsight.RuntimeScriptActionDetail{
+		{Name: utils.String("tune"), URI: utils.String("https://example.com/tune.sh"), Roles: &[]string{"headnode", "workernode"}},
+		{Name: utils.String("portal"), URI: utils.String("https://example.com/portal.sh"), Parameters: utils.String("--all"), Roles: &[]string{"workernode", "HeadNode"}},
+		{Name: utils.String("cli"), URI: utils.String("https://example.com/cli.sh"), Roles: &[]string{"edgenode"}},
+		{Name: utils.String("edge-app"), URI: utils.String("https://example.com/app.sh"), Roles: &[]string{"edgenode"}, ApplicationName: utils.String("app")},
+	}
+
+	// nothing's specified in the config, so the persisted script actions aren't added to the roles - since this would
+	// replace the cluster
+	unmanaged := applyHDInsightPersistedScriptActions(roles, persisted)
+	for _, role := range []string{"head_node", "worker_node", "zookeeper_node"} {
+		node := roles[0].(map[string]interface{})[role].([]interface{})[0].(map[string]interface{})
+		if actual := len(node["script_actions"].([]interface{})); actual != 0 {
+			t.Fatalf("Expected no script actions for the %s but got %d", role, actual)
+		}
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"name": "cli", "uri": "https://example.com/cli.sh", "parameters": "", "roles": []interface{}{"edge_node"}},
+		map[string]interface{}{"name": "portal", "uri": "https://example.com/portal.sh", "parameters": "--all", "roles": []interface{}{"head_node", "worker_node"}},
+		map[string]interface{}{"name": "tune", "uri": "https://example.com/tune.sh", "parameters": "", "roles": []interface{}{"head_node", "worker_node"}},
+	}
+	if !reflect.DeepEqual(unmanaged, expected) {
+		t.Fatalf("Expected the unmanaged script actions %+v but got %+v", expected, unmanaged)
+	}
+
+	// once specified in the config, the script action is read back for each of its roles rather than being unmanaged
+	for _, role := range []string{"head_node", "worker_node"} {
+		node := roles[0].(map[string]interface{})[role].([]interface{})[0].(map[string]interface{})
+		node["script_actions"] = []interface{}{
+			map[string]interface{}{"name": "tune", "uri": "https://example.com/old.sh", "parameters": "", "persisted": true},
+		}
+	}
+
+	unmanaged = applyHDInsightPersistedScriptActions(roles, persisted)
+	for role, expected := range map[string]int{"head_node": 1, "worker_node": 1, "zookeeper_node": 0} {
+		node := roles[0].(map[string]interface{})[role].([]interface{})[0].(map[string]interface{})
+		scriptActions := node["script_actions"].([]interface{})
+		if actual := len(scriptActions); actual != expected {
+			t.Fatalf("Expected %d script actions for the %s but got %d", expected, role, actual)
+		}
+		if expected == 1 && scriptActions[0].(map[string]interface{})["uri"] != "https://example.com/tune.sh" {
+			t.Fatalf("Expected the change to the %s script action to be detected but got %+v", role, scriptActions[0])
+		}
+	}
+	if len(unmanaged) != 2 {
+		t.Fatalf("Expected 2 unmanaged script actions but got %+v", unmanaged)
+	}
+}
+
+func TestFindLatestHDInsightScriptActionExecution(t *testing.T) {
+	history := []hdinsight.RuntimeScriptActionDetail{
+		{Name: utils.String("tune"), ScriptExecutionID: utils.Int64(7), Status: utils.String("Failed")},
+		{Name: utils.String("install"), ScriptExecutionID: utils.Int64(12), Status: utils.String("Succeeded")},
+		{Name: utils.String("Tune"), ScriptExecutionID: utils.Int64(9), Status: utils.String("Succeeded")},
+		{Name: utils.String("tune"), Status: utils.String("Running")},
+	}
+
+	latest := findLatestHDInsightScriptActionExecution(history, "tune")
+	if latest == nil || *latest.ScriptExecutionID != 9 {
+		t.Fatalf("Expected execution 9 to be the latest execution of `tune` but got %+v", latest)
+	}
+	if hdinsightScriptActionExecutionFailed(*latest) {
+		t.Fatalf("Expected execution 9 not to have failed")
+	}
+
+	if latest := findLatestHDInsightScriptActionExecution(history, "missing"); latest != nil {
+		t.Fatalf("Expected no execution of `missing` but got %+v", latest)
+	}
+
+	if !hdinsightScriptActionExecutionFailed(history[0]) {
+		t.Fatalf("Expected execution 7 to have failed")
+	}
+}
+
+func TestHDInsightScriptActionRoles(t *testing.T) {
+	expanded := expandHDInsightScriptActionRoles([]interface{}{"worker_node", "edge_node", "head_node"})
+	if expected := []string{"edgenode", "headnode", "workernode"}; !reflect.DeepEqual(*expanded, expected) {
+		t.Fatalf("Expected the roles %v but got %v", expected, *expanded)
+	}
+
+	flattened := flattenHDInsightScriptActionRoles(&[]string{"HeadNode", "kafkamanagementnode", "unknown"})
+	if expected := []interface{}{"head_node", "kafka_management_node"}; !reflect.DeepEqual(flattened, expected) {
+		t.Fatalf("Expected the roles %v but got %v", expected, flattened)
+	}
+}
+
+func TestHDInsightClusterDefaultStorageContainer(t *testing.T) {
+	storageAccounts := []interface{}{
+		map[string]interface{}{
+			"storage_container_id": "https://additional.blob.core.windows.net/data",
+			"is_default":           false,
+		},
+		map[string]interface{}{
+			"storage_container_id": "https://example.blob.core.windows.net/cluster-output",
+			"is_default":           true,
+		},
+	}
+
+	accountName, containerName := hdinsightClusterDefaultStorageContainer(storageAccounts)
+	if accountName != "example" || containerName != "cluster-output" {
+		t.Fatalf("Expected the default storage container `example/cluster-output` but got `%s/%s`", accountName, containerName)
+	}
+
+	if accountName, containerName := hdinsightClusterDefaultStorageContainer(storageAccounts[:1]); accountName != "" || containerName != "" {
+		t.Fatalf("Expected no default storage container but got `%s/%s`", accountName, containerName)
+	}
+}
+
+func TestCheckHDInsightClusterDefaultStorageBeforeDelete(t *testing.T) {
+	id := parse.NewClusterID("00000000-0000-0000-0000-000000000000", "resGroup1", "cluster1")
+
+	testData := []struct {
+		createdByCluster bool
+		preventDeletion  bool
+		expectError      bool
+	}{
+		{createdByCluster: false, preventDeletion: false, expectError: false},
+		{createdByCluster: false, preventDeletion: true, expectError: false},
+		{createdByCluster: true, preventDeletion: false, expectError: false},
+		{createdByCluster: true, preventDeletion: true, expectError: true},
+	}
+
+	for _, v := range testData {
+		err := checkHDInsightClusterDefaultStorageBeforeDelete("Spark", id, v.createdByCluster, v.preventDeletion)
+		if v.expectError != (err != nil) {
+			t.Fatalf("Expected an error %t for created by cluster %t and prevent deletion %t but got %+v", v.expectError, v.createdByCluster, v.preventDeletion, err)
+		}
+	}
+}
+
+func TestHDInsightClusterMaxWorkerCount(t *testing.T) {
+	workerNode := func(targetInstanceCount int32, autoscale *hdinsight.Autoscale) *hdinsight.ClusterGetProperties {
+		return &hdinsight.ClusterGetProperties{
+			ComputeProfile: &hdinsight.ComputeProfile{
+				Roles: &[]hdinsight.Role{
+					{Name: utils.String("headnode"), TargetInstanceCount: utils.Int32(2)},
+					{Name: utils.String("workernode"), TargetInstanceCount: utils.Int32(targetInstanceCount), AutoscaleConfiguration: autoscale},
+				},
+			},
+		}
+	}
+
+	testData := []struct {
+		name     string
+		input    *hdinsight.ClusterGetProperties
+		expected int
+	}{
+		{
+			name:     "no compute profile",
+			input:    &hdinsight.ClusterGetProperties{},
+			expected: 0,
+		},
+		{
+			name:     "no autoscale",
+			input:    workerNode(3, nil),
+			expected: 3,
+		},
+		{
+			name: "capacity",
+			input: workerNode(3, &hdinsight.Autoscale{
+				Capacity: &hdinsight.AutoscaleCapacity{MinInstanceCount: utils.Int32(2), MaxInstanceCount: utils.Int32(10)},
+			}),
+			expected: 10,
+		},
+		{
+			name: "recurrence",
+			input: workerNode(3, &hdinsight.Autoscale{
+				Recurrence: &hdinsight.AutoscaleRecurrence{
+					Schedule: &[]hdinsight.AutoscaleSchedule{
+						{TimeAndCapacity: &hdinsight.AutoscaleTimeAndCapacity{MinInstanceCount: utils.Int32(5), MaxInstanceCount: utils.Int32(5)}},
+						{TimeAndCapacity: &hdinsight.AutoscaleTimeAndCapacity{MinInstanceCount: utils.Int32(8), MaxInstanceCount: utils.Int32(8)}},
+						{TimeAndCapacity: &hdinsight.AutoscaleTimeAndCapacity{MinInstanceCount: utils.Int32(2), MaxInstanceCount: utils.Int32(2)}},
+					},
+				},
+			}),
+			expected: 8,
+		},
+		{
+			name:     "autoscale disabled",
+			input:    workerNode(4, &hdinsight.Autoscale{}),
+			expected: 4,
+		},
+	}
+
+	for _, v := range testData {
+		if actual := hdinsightClusterMaxWorkerCount(v.input); actual != v.expected {
+			t.Fatalf("Expected a maximum of %d Worker Nodes for %q but got %d", v.expected, v.name, actual)
+		}
+	}
+}
+
+func TestValidateHDInsightScriptActionPromotion(t *testing.T) {
+	testData := []struct {
+		execution hdinsight.RuntimeScriptActionDetail
+		valid     bool
+	}{
+		{
+			execution: hdinsight.RuntimeScriptActionDetail{Name: utils.String("Tune"), Status: utils.String("Succeeded")},
+			valid:     true,
+		},
+		{
+			execution: hdinsight.RuntimeScriptActionDetail{Name: utils.String("install"), Status: utils.String("Succeeded")},
+			valid:     false,
+		},
+		{
+			execution: hdinsight.RuntimeScriptActionDetail{Name: utils.String("tune"), Status: utils.String("Failed"), DebugInformation: utils.String("exit code 1")},
+			valid:     false,
+		},
+		{
+			execution: hdinsight.RuntimeScriptActionDetail{Status: utils.String("Succeeded")},
+			valid:     false,
+		},
+	}
+
+	for _, v := range testData {
+		err := validateHDInsightScriptActionPromotion(v.execution, "tune")
+		if v.valid != (err == nil) {
+			t.Fatalf("Expected valid %t for %+v but got %+v", v.valid, v.execution, err)
+		}
+	}
+}
+
+func TestHDInsightEdgeNodeChanges(t *testing.T) {
+	edgeNode := func(name, vmSize string, count int) map[string]interface{} {
+		return map[string]interface{}{
+			"name":                  name,
+			"vm_size":               vmSize,
+			"target_instance_count": count,
+		}
+	}
+
+	analysts := edgeNode("analysts", "Standard_D3_V2", 1)
+	jobs := edgeNode("jobs", "Standard_D4_V2", 2)
+	unnamed := edgeNode("", "Standard_D3_V2", 1)
+
+	tests := []struct {
+		name     string
+		old      []interface{}
+		new      []interface{}
+		toDelete []string
+		toResize []map[string]interface{}
+		toCreate []map[string]interface{}
+	}{
+		{
+			name:     "add an edge node",
+			old:      []interface{}{analysts},
+			new:      []interface{}{analysts, jobs},
+			toDelete: []string{},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{jobs},
+		},
+		{
+			name:     "remove an edge node",
+			old:      []interface{}{analysts, jobs},
+			new:      []interface{}{jobs},
+			toDelete: []string{"analysts"},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{},
+		},
+		{
+			name:     "reorder the edge nodes",
+			old:      []interface{}{analysts, jobs},
+			new:      []interface{}{jobs, analysts},
+			toDelete: []string{},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{},
+		},
+		{
+			name:     "resize an edge node",
+			old:      []interface{}{analysts, jobs},
+			new:      []interface{}{analysts, edgeNode("jobs", "Standard_D4_V2", 3)},
+			toDelete: []string{},
+			toResize: []map[string]interface{}{edgeNode("jobs", "Standard_D4_V2", 3)},
+			toCreate: []map[string]interface{}{},
+		},
+		{
+			name:     "change the vm size of an edge node",
+			old:      []interface{}{analysts, jobs},
+			new:      []interface{}{analysts, edgeNode("jobs", "Standard_D3_V2", 2)},
+			toDelete: []string{"jobs"},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{edgeNode("jobs", "Standard_D3_V2", 2)},
+		},
+		{
+			name:     "resize and change the vm size of an edge node",
+			old:      []interface{}{analysts, jobs},
+			new:      []interface{}{analysts, edgeNode("jobs", "Standard_D3_V2", 3)},
+			toDelete: []string{"jobs"},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{edgeNode("jobs", "Standard_D3_V2", 3)},
+		},
+		{
+			name:     "name the unnamed edge node after the cluster",
+			old:      []interface{}{unnamed},
+			new:      []interface{}{edgeNode("acctesthdi", "Standard_D3_V2", 1)},
+			toDelete: []string{},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{},
+		},
+		{
+			name:     "name the unnamed edge node",
+			old:      []interface{}{unnamed},
+			new:      []interface{}{edgeNode("analysts", "Standard_D3_V2", 1)},
+			toDelete: []string{"acctesthdi"},
+			toResize: []map[string]interface{}{},
+			toCreate: []map[string]interface{}{edgeNode("analysts", "Standard_D3_V2", 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toDelete, toResize, toCreate := hdinsightEdgeNodeChanges("acctesthdi", tt.old, tt.new)
+			if !reflect.DeepEqual(toDelete, tt.toDelete) {
+				t.Fatalf("Expected %+v to be deleted but got %+v", tt.toDelete, toDelete)
+			}
+			if !reflect.DeepEqual(toResize, tt.toResize) {
+				t.Fatalf("Expected %+v to be resized but got %+v", tt.toResize, toResize)
+			}
+			if !reflect.DeepEqual(toCreate, tt.toCreate) {
+				t.Fatalf("Expected %+v to be created but got %+v", tt.toCreate, toCreate)
+			}
+		})
+	}
+}
+
+func TestFlattenHDInsightEdgeNodes(t *testing.T) {
+	application := func(name, vmSize string) hdinsight.Application {
+		return hdinsight.Application{
+			Name: utils.String(name),
+			Properties: &hdinsight.ApplicationProperties{
+				ComputeProfile: &hdinsight.ComputeProfile{
+					Roles: &[]hdinsight.Role{{
+						Name:                utils.String("edgenode"),
+						HardwareProfile:     &hdinsight.HardwareProfile{VMSize: utils.String(vmSize)},
+						TargetInstanceCount: utils.Int32(1),
+					}},
+				},
+			},
+		}
+	}
+	applications := []hdinsight.Application{
+		application("acctesthdi", "standard_d3_v2"),
+		application("acctesthdi/jobs", "Standard_D4_V2"),
+		application("analysts", "Standard_D3_V2"),
+	}
+
+	names := func(roles []interface{}) []string {
+		result := make([]string, 0)
+		for _, raw := range roles[0].(map[string]interface{})["edge_node"].([]interface{}) {
+			result = append(result, raw.(map[string]interface{})["name"].(string))
+		}
+		return result
+	}
+
+	t.Run("import", func(t *testing.T) {
+		roles := flattenHDInsightEdgeNodes([]interface{}{map[string]interface{}{}}, "acctesthdi", nil, applications)
+		if expected := []string{"", "analysts", "jobs"}; !reflect.DeepEqual(names(roles), expected) {
+			t.Fatalf("Expected the edge nodes %+v but got %+v", expected, names(roles))
+		}
+
+		edgeNode := roles[0].(map[string]interface{})["edge_node"].([]interface{})[0].(map[string]interface{})
+		if edgeNode["vm_size"] != "Standard_D3_V2" || edgeNode["target_instance_count"] != 1 {
+			t.Fatalf("Expected the edge node to be flattened but got %+v", edgeNode)
+		}
+	})
+
+	t.Run("existing order", func(t *testing.T) {
+		existing := []interface{}{
+			map[string]interface{}{"name": "jobs"},
+			map[string]interface{}{"name": "acctesthdi"},
+		}
+		roles := flattenHDInsightEdgeNodes([]interface{}{map[string]interface{}{}}, "acctesthdi", existing, applications)
+		if expected := []string{"jobs", "acctesthdi", "analysts"}; !reflect.DeepEqual(names(roles), expected) {
+			t.Fatalf("Expected the edge nodes %+v but got %+v", expected, names(roles))
+		}
+	})
+}
+
+func TestFlattenHDInsightEdgeNodeScriptActions(t *testing.T) {
+	action := func(name, parameters string) hdinsight.RuntimeScriptAction {
+		result := hdinsight.RuntimeScriptAction{
+			Name:  utils.String(name),
+			URI:   utils.String("https://example.com/" + name + ".sh"),
+			Roles: &[]string{"edgenode"},
+		}
+		if parameters != "" {
+			result.Parameters = utils.String(parameters)
+		}
+		return result
+	}
+
+	props := &hdinsight.ApplicationProperties{
+		InstallScriptActions:   &[]hdinsight.RuntimeScriptAction{action("install", "")},
+		UninstallScriptActions: &[]hdinsight.RuntimeScriptAction{action("deregister-agent", "--force"), action("remove-dns-record", "")},
+	}
+
+	edgeNode := flattenHDInsightEdgeNode(props)
+
+	expectedInstall := []interface{}{
+		map[string]interface{}{"name": "install", "uri": "https://example.com/install.sh", "parameters": ""},
+	}
+	if !reflect.DeepEqual(edgeNode["install_script_action"], expectedInstall) {
+		t.Fatalf("Expected the install script actions to be %+v but got %+v", expectedInstall, edgeNode["install_script_action"])
+	}
+
+	expectedUninstall := []interface{}{
+		map[string]interface{}{"name": "deregister-agent", "uri": "https://example.com/deregister-agent.sh", "parameters": "--force"},
+		map[string]interface{}{"name": "remove-dns-record", "uri": "https://example.com/remove-dns-record.sh", "parameters": ""},
+	}
+	if !reflect.DeepEqual(edgeNode["uninstall_script_actions"], expectedUninstall) {
+		t.Fatalf("Expected the uninstall script actions to be %+v but got %+v", expectedUninstall, edgeNode["uninstall_script_actions"])
+	}
+
+	props.UninstallScriptActions = nil
+	if v, ok := flattenHDInsightEdgeNode(props)["uninstall_script_actions"]; ok {
+		t.Fatalf("Expected no uninstall script actions but got %+v", v)
+	}
+}
+
+func TestHDInsightApplicationEdgeNodeHttpsEndpoints(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{
+			"access_modes":         []interface{}{"WebPage"},
+			"destination_port":     8888,
+			"disable_gateway_auth": true,
+			"private_ip_address":   "",
+			"sub_domain_suffix":    "jup",
+		},
+	}
+
+	expanded := *expandHDInsightApplicationEdgeNodeHttpsEndpoints(input)
+	if len(expanded) != 1 {
+		t.Fatalf("Expected 1 endpoint but got %d", len(expanded))
+	}
+	if expanded[0].PrivateIPAddress != nil {
+		t.Fatalf("Expected the private IP address to be left for HDInsight to assign but got %q", *expanded[0].PrivateIPAddress)
+	}
+
+	// HDInsight returns the generated public endpoint and assigns a private IP address
+	expanded[0].Location = utils.String("acctesthdi-jup.azurehdinsight.net")
+	expanded[0].PublicPort = utils.Int32(443)
+	expanded[0].PrivateIPAddress = utils.String("10.0.0.12")
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"access_modes":         []interface{}{"WebPage"},
+			"destination_port":     8888,
+			"disable_gateway_auth": true,
+			"private_ip_address":   "10.0.0.12",
+			"sub_domain_suffix":    "jup",
+		},
+	}
+	if actual := flattenHDInsightApplicationEdgeNodeHttpsEndpoints(&expanded); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+}
+
+func TestFlattenKafkaRestProxyProperty(t *testing.T) {
+	existing := []interface{}{
+		map[string]interface{}{
+			"security_group_id":   "00000000-0000-0000-0000-000000000000",
+			"security_group_name": "kafka-clients",
+		},
+	}
+
+	if actual := flattenKafkaRestProxyProperty(nil, existing); len(actual) != 0 {
+		t.Fatalf("Expected no `rest_proxy` when the REST proxy isn't enabled but got %+v", actual)
+	}
+
+	// the group changed outside of Terraform is returned, so the drift is detected
+	actual := flattenKafkaRestProxyProperty(&hdinsight.KafkaRestProperties{
+		ClientGroupInfo: &hdinsight.ClientGroupInfo{
+			GroupID:   utils.String("11111111-1111-1111-1111-111111111111"),
+			GroupName: utils.String("other-clients"),
+		},
+	}, existing)
+	expected := []interface{}{
+		map[string]interface{}{
+			"security_group_id":   "11111111-1111-1111-1111-111111111111",
+			"security_group_name": "other-clients",
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	// the name is taken from the existing state when the API omits it
+	actual = flattenKafkaRestProxyProperty(&hdinsight.KafkaRestProperties{
+		ClientGroupInfo: &hdinsight.ClientGroupInfo{
+			GroupID: utils.String("00000000-0000-0000-0000-000000000000"),
+		},
+	}, existing)
+	if !reflect.DeepEqual(actual, existing) {
+		t.Fatalf("Expected %+v but got %+v", existing, actual)
+	}
+
+	// when importing there's no existing state, so whatever's returned is used
+	actual = flattenKafkaRestProxyProperty(&hdinsight.KafkaRestProperties{
+		ClientGroupInfo: &hdinsight.ClientGroupInfo{
+			GroupID: utils.String("00000000-0000-0000-0000-000000000000"),
+		},
+	}, nil)
+	expected = []interface{}{
+		map[string]interface{}{
+			"security_group_id":   "00000000-0000-0000-0000-000000000000",
+			"security_group_name": "",
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+}
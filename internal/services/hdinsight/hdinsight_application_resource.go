@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/go-azure-helpers/lang/response"
+	"github.com/hashicorp/terraform-provider-azurerm/helpers/tf"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/validation"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+func resourceHDInsightApplication() *pluginsdk.Resource {
+	// the install script actions of an Application are only run when it's created - so (as with the uninstall script
+	// actions, HTTPS endpoints and the rest of the Application) changing them recreates the Application
+	installScriptActions := SchemaHDInsightsScriptActions()
+	installScriptActions.Optional = false
+	installScriptActions.Required = true
+
+	return &pluginsdk.Resource{
+		Create: resourceHDInsightApplicationCreate,
+		Read:   resourceHDInsightApplicationRead,
+		Delete: resourceHDInsightApplicationDelete,
+
+		Importer: pluginsdk.ImporterValidatingResourceId(func(id string) error {
+			_, err := parse.ApplicationID(id)
+			return err
+		}),
+
+		// installing (and uninstalling) an Application runs its script actions on the edge nodes, which can take a while
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Create: pluginsdk.DefaultTimeout(60 * time.Minute),
+			Read:   pluginsdk.DefaultTimeout(5 * time.Minute),
+			Delete: pluginsdk.DefaultTimeout(60 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"name": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validate.HDInsightName,
+			},
+
+			"cluster_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validate.ClusterID,
+			},
+
+			"vm_size": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validate.NodeDefinitionVMSizeIn(validate.NodeDefinitionVMSize, "edge_node"),
+			},
+
+			"target_instance_count": {
+				Type:         pluginsdk.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      1,
+				ValidateFunc: validate.NodeDefinitionInstanceCount("", "edge_node", 1, 25),
+			},
+
+			"install_script_action": hdinsightApplicationSchemaForceNew(installScriptActions),
+
+			"uninstall_script_actions": hdinsightApplicationSchemaForceNew(SchemaHDInsightsScriptActions()),
+
+			"https_endpoints": hdinsightApplicationSchemaForceNew(SchemaHDInsightsHttpsEndpoints()),
+
+			"application_type": {
+				Type:     pluginsdk.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "CustomApplication",
+				ValidateFunc: validation.StringInSlice([]string{
+					"CustomApplication",
+					"RServer",
+				}, false),
+			},
+		},
+	}
+}
+
+func resourceHDInsightApplicationCreate(d *pluginsdk.ResourceData, meta interface{}) error {
+	clustersClient := meta.(*clients.Client).HDInsight.ClustersClient
+	client := meta.(*clients.Client).HDInsight.ApplicationsClient
+	ctx, cancel := timeouts.ForCreate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	clusterId, err := parse.ClusterID(d.Get("cluster_id").(string))
+	if err != nil {
+		return err
+	}
+	id := parse.NewApplicationID(clusterId.SubscriptionId, clusterId.ResourceGroup, clusterId.Name, d.Get("name").(string))
+
+	existing, err := client.Get(ctx, id.ResourceGroup, id.ClusterName, id.Name)
+	if err != nil {
+		if !utils.ResponseWasNotFound(existing.Response) {
+			return fmt.Errorf("checking for the existing %s: %+v", id, err)
+		}
+	}
+	if !utils.ResponseWasNotFound(existing.Response) {
+		return tf.ImportAsExistsError("azurerm_hdinsight_application", id.ID())
+	}
+
+	input := map[string]interface{}{
+		"vm_size":                  d.Get("vm_size").(string),
+		"target_instance_count":    d.Get("target_instance_count").(int),
+		"install_script_action":    d.Get("install_script_action").([]interface{}),
+		"uninstall_script_actions": d.Get("uninstall_script_actions").([]interface{}),
+		"https_endpoints":          d.Get("https_endpoints").([]interface{}),
+	}
+	application := expandHDInsightEdgeNodeApplication(input, d.Get("application_type").(string))
+
+	future, err := client.Create(ctx, id.ResourceGroup, id.ClusterName, id.Name, application)
+	if err != nil {
+		return fmt.Errorf("creating %s: %+v", id, err)
+	}
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting for the creation of %s: %+v", id, err)
+	}
+
+	// the cluster goes on to apply the Application once it's been created, during which it can't be changed
+	log.Printf("[DEBUG] Waiting for %s to finish applying %s", clusterId, id)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fmt.Errorf("internal-error: context had no deadline")
+	}
+	stateConf := &pluginsdk.StateChangeConf{
+		Pending:    hdInsightClusterTransitionalStates,
+		Target:     []string{"Running"},
+		Refresh:    hdInsightWaitForReadyRefreshFunc(ctx, clustersClient, id.ResourceGroup, id.ClusterName),
+		MinTimeout: 15 * time.Second,
+		Timeout:    time.Until(deadline),
+	}
+	if _, err := stateConf.WaitForStateContext(ctx); err != nil {
+		return fmt.Errorf("waiting for %s to be running: %+v", clusterId, err)
+	}
+
+	d.SetId(id.ID())
+
+	return resourceHDInsightApplicationRead(d, meta)
+}
+
+func resourceHDInsightApplicationRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ApplicationsClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ApplicationID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Get(ctx, id.ResourceGroup, id.ClusterName, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(resp.Response) {
+			log.Printf("[DEBUG] %s was not found - removing from state", id)
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("retrieving %s: %+v", id, err)
+	}
+
+	d.Set("name", id.Name)
+	d.Set("cluster_id", parse.NewClusterID(id.SubscriptionId, id.ResourceGroup, id.ClusterName).ID())
+
+	if props := resp.Properties; props != nil {
+		// Applications are deployed onto edge nodes, so are flattened in the same way as the `edge_node` of a cluster
+		edgeNode := flattenHDInsightEdgeNode(props)
+		d.Set("vm_size", edgeNode["vm_size"])
+		d.Set("target_instance_count", edgeNode["target_instance_count"])
+
+		if err := d.Set("install_script_action", flattenHDInsightApplicationEdgeNodeScriptActions(props.InstallScriptActions)); err != nil {
+			return fmt.Errorf("setting `install_script_action`: %+v", err)
+		}
+		if err := d.Set("uninstall_script_actions", flattenHDInsightApplicationEdgeNodeScriptActions(props.UninstallScriptActions)); err != nil {
+			return fmt.Errorf("setting `uninstall_script_actions`: %+v", err)
+		}
+		if err := d.Set("https_endpoints", flattenHDInsightApplicationEdgeNodeHttpsEndpoints(props.HTTPSEndpoints)); err != nil {
+			return fmt.Errorf("setting `https_endpoints`: %+v", err)
+		}
+
+		applicationType := "CustomApplication"
+		if props.ApplicationType != nil && *props.ApplicationType != "" {
+			applicationType = *props.ApplicationType
+		}
+		d.Set("application_type", applicationType)
+	}
+
+	return nil
+}
+
+func resourceHDInsightApplicationDelete(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ApplicationsClient
+	ctx, cancel := timeouts.ForDelete(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ApplicationID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	// deleting the Application runs its uninstall script actions before the edge nodes are removed
+	future, err := client.Delete(ctx, id.ResourceGroup, id.ClusterName, id.Name)
+	if err != nil {
+		if response.WasNotFound(future.Response()) {
+			return nil
+		}
+		return fmt.Errorf("deleting %s: %+v", id, err)
+	}
+	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return fmt.Errorf("waiting for the deletion of %s: %+v", id, err)
+	}
+
+	return nil
+}
+
+// hdinsightApplicationSchemaForceNew marks a block and each of its nested arguments as ForceNew, since there's no
+// way to update an Application
+func hdinsightApplicationSchemaForceNew(s *pluginsdk.Schema) *pluginsdk.Schema {
+	s.ForceNew = true
+	if r, ok := s.Elem.(*pluginsdk.Resource); ok {
+		for _, v := range r.Schema {
+			hdinsightApplicationSchemaForceNew(v)
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+type HDInsightApplicationResource struct{}
+
+func TestAccHDInsightApplication_basic(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_application", "test")
+	r := HDInsightApplicationResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("application_type").HasValue("CustomApplication"),
+				check.That(data.ResourceName).Key("target_instance_count").HasValue("1"),
+			),
+		},
+		data.ImportStep(),
+	})
+}
+
+func TestAccHDInsightApplication_complete(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_application", "test")
+	r := HDInsightApplicationResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.complete(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("install_script_action.#").HasValue("2"),
+				check.That(data.ResourceName).Key("uninstall_script_actions.#").HasValue("1"),
+				check.That(data.ResourceName).Key("https_endpoints.0.destination_port").HasValue("8888"),
+			),
+		},
+		data.ImportStep(),
+	})
+}
+
+func TestAccHDInsightApplication_requiresImport(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_application", "test")
+	r := HDInsightApplicationResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.RequiresImportErrorStep(r.requiresImport),
+	})
+}
+
+func (HDInsightApplicationResource) Exists(ctx context.Context, clients *clients.Client, state *pluginsdk.InstanceState) (*bool, error) {
+	id, err := parse.ApplicationID(state.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := clients.HDInsight.ApplicationsClient.Get(ctx, id.ResourceGroup, id.ClusterName, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(resp.Response) {
+			return utils.Bool(false), nil
+		}
+		return nil, fmt.Errorf("retrieving %s: %+v", id, err)
+	}
+
+	return utils.Bool(resp.ID != nil), nil
+}
+
+func (r HDInsightApplicationResource) basic(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_application" "test" {
+  name       = "acctestapp-%d"
+  cluster_id = azurerm_hdinsight_spark_cluster.test.id
+  vm_size    = "Standard_D3_V2"
+
+  install_script_action {
+    name = "install"
+    uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightApplicationResource) complete(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_application" "test" {
+  name                  = "acctestapp-%d"
+  cluster_id            = azurerm_hdinsight_spark_cluster.test.id
+  vm_size               = "Standard_D3_V2"
+  target_instance_count = 2
+  application_type      = "CustomApplication"
+
+  install_script_action {
+    name = "install"
+    uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  }
+
+  install_script_action {
+    name       = "configure"
+    uri        = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+    parameters = "--verbose"
+  }
+
+  uninstall_script_actions {
+    name = "deregister"
+    uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  }
+
+  https_endpoints {
+    access_modes         = ["WebPage"]
+    destination_port     = 8888
+    disable_gateway_auth = false
+    sub_domain_suffix    = "app"
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightApplicationResource) requiresImport(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_application" "import" {
+  name       = azurerm_hdinsight_application.test.name
+  cluster_id = azurerm_hdinsight_application.test.cluster_id
+  vm_size    = azurerm_hdinsight_application.test.vm_size
+
+  install_script_action {
+    name = "install"
+    uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  }
+}
+`, r.basic(data))
+}
+
+func (HDInsightApplicationResource) template(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_spark_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    spark = "2.4"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_A4_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_A4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 3
+    }
+
+    zookeeper_node {
+      vm_size  = "Medium"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+
+  lifecycle {
+    # the Application shows up as an edge_node of the cluster
+    ignore_changes = [roles[0].edge_node]
+  }
+}
+`, HDInsightSparkClusterResource{}.template(data), data.RandomInteger)
+}
@@ -0,0 +1,356 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/validation"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+func dataSourceHDInsightClusterApplications() *pluginsdk.Resource {
+	return &pluginsdk.Resource{
+		Read: dataSourceHDInsightClusterApplicationsRead,
+
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Read: pluginsdk.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"cluster_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ValidateFunc: validate.ClusterID,
+			},
+
+			"name_prefix": {
+				Type:         pluginsdk.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"applications": {
+				Type:     pluginsdk.TypeList,
+				Computed: true,
+				Elem: &pluginsdk.Resource{
+					Schema: map[string]*pluginsdk.Schema{
+						"id": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"name": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"application_type": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"provisioning_state": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"application_state": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"vm_size": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"target_instance_count": {
+							Type:     pluginsdk.TypeInt,
+							Computed: true,
+						},
+
+						"https_endpoints": {
+							Type:     pluginsdk.TypeList,
+							Computed: true,
+							Elem: &pluginsdk.Resource{
+								Schema: map[string]*pluginsdk.Schema{
+									"location": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+
+									"public_port": {
+										Type:     pluginsdk.TypeInt,
+										Computed: true,
+									},
+
+									"destination_port": {
+										Type:     pluginsdk.TypeInt,
+										Computed: true,
+									},
+
+									"private_ip_address": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+
+									"sub_domain_suffix": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+
+									"access_modes": {
+										Type:     pluginsdk.TypeList,
+										Computed: true,
+										Elem: &pluginsdk.Schema{
+											Type: pluginsdk.TypeString,
+										},
+									},
+
+									"disable_gateway_auth": {
+										Type:     pluginsdk.TypeBool,
+										Computed: true,
+									},
+								},
+							},
+						},
+
+						"ssh_endpoints": {
+							Type:     pluginsdk.TypeList,
+							Computed: true,
+							Elem: &pluginsdk.Resource{
+								Schema: map[string]*pluginsdk.Schema{
+									"location": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+
+									"public_port": {
+										Type:     pluginsdk.TypeInt,
+										Computed: true,
+									},
+
+									"destination_port": {
+										Type:     pluginsdk.TypeInt,
+										Computed: true,
+									},
+
+									"private_ip_address": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+
+						"errors": {
+							Type:     pluginsdk.TypeList,
+							Computed: true,
+							Elem: &pluginsdk.Resource{
+								Schema: map[string]*pluginsdk.Schema{
+									"code": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+
+									"message": {
+										Type:     pluginsdk.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceHDInsightClusterApplicationsRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ApplicationsClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Get("cluster_id").(string))
+	if err != nil {
+		return err
+	}
+
+	iterator, err := client.ListByClusterComplete(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(iterator.Response().Response) {
+			return fmt.Errorf("%s was not found", id)
+		}
+
+		return fmt.Errorf("listing the Applications for %s: %+v", id, err)
+	}
+
+	applications := make([]hdinsight.Application, 0)
+	for iterator.NotDone() {
+		applications = append(applications, iterator.Value())
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return fmt.Errorf("listing the Applications for %s: %+v", id, err)
+		}
+	}
+
+	d.SetId(id.ID())
+	d.Set("cluster_id", id.ID())
+
+	if err := d.Set("applications", flattenHDInsightClusterApplications(*id, applications, d.Get("name_prefix").(string))); err != nil {
+		return fmt.Errorf("setting `applications`: %+v", err)
+	}
+
+	return nil
+}
+
+// flattenHDInsightClusterApplications flattens the Applications installed on the cluster whose name starts with the
+// prefix (ignoring the casing, as with the names of Azure resources) - or all of them when there's no prefix
+func flattenHDInsightClusterApplications(clusterId parse.ClusterId, input []hdinsight.Application, namePrefix string) []interface{} {
+	output := make([]interface{}, 0)
+	for _, application := range input {
+		if application.Name == nil {
+			continue
+		}
+
+		name := *application.Name
+		// the name can be prefixed with the name of the cluster
+		if i := strings.LastIndex(name, "/"); i != -1 {
+			name = name[i+1:]
+		}
+		if !strings.HasPrefix(strings.ToLower(name), strings.ToLower(namePrefix)) {
+			continue
+		}
+
+		result := map[string]interface{}{
+			"id":                    parse.NewApplicationID(clusterId.SubscriptionId, clusterId.ResourceGroup, clusterId.Name, name).ID(),
+			"name":                  name,
+			"application_type":      "",
+			"provisioning_state":    "",
+			"application_state":     "",
+			"vm_size":               "",
+			"target_instance_count": 0,
+			"https_endpoints":       make([]interface{}, 0),
+			"ssh_endpoints":         make([]interface{}, 0),
+			"errors":                make([]interface{}, 0),
+		}
+
+		if props := application.Properties; props != nil {
+			result["application_type"] = utils.NormalizeNilableString(props.ApplicationType)
+			result["provisioning_state"] = utils.NormalizeNilableString(props.ProvisioningState)
+			result["application_state"] = utils.NormalizeNilableString(props.ApplicationState)
+
+			if computeProfile := props.ComputeProfile; computeProfile != nil && computeProfile.Roles != nil {
+				for _, role := range *computeProfile.Roles {
+					if role.HardwareProfile != nil && role.HardwareProfile.VMSize != nil {
+						result["vm_size"] = *role.HardwareProfile.VMSize
+					}
+					if role.TargetInstanceCount != nil {
+						result["target_instance_count"] = int(*role.TargetInstanceCount)
+					}
+				}
+			}
+
+			result["https_endpoints"] = flattenHDInsightClusterApplicationHttpsEndpoints(props.HTTPSEndpoints)
+			result["ssh_endpoints"] = flattenHDInsightClusterApplicationSshEndpoints(props.SSHEndpoints)
+			result["errors"] = flattenHDInsightClusterApplicationErrors(props.Errors)
+		}
+
+		output = append(output, result)
+	}
+
+	return output
+}
+
+func flattenHDInsightClusterApplicationHttpsEndpoints(input *[]hdinsight.ApplicationGetHTTPSEndpoint) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, endpoint := range *input {
+		publicPort := 0
+		if endpoint.PublicPort != nil {
+			publicPort = int(*endpoint.PublicPort)
+		}
+
+		destinationPort := 0
+		if endpoint.DestinationPort != nil {
+			destinationPort = int(*endpoint.DestinationPort)
+		}
+
+		disableGatewayAuth := false
+		if endpoint.DisableGatewayAuth != nil {
+			disableGatewayAuth = *endpoint.DisableGatewayAuth
+		}
+
+		output = append(output, map[string]interface{}{
+			"location":             utils.NormalizeNilableString(endpoint.Location),
+			"public_port":          publicPort,
+			"destination_port":     destinationPort,
+			"private_ip_address":   utils.NormalizeNilableString(endpoint.PrivateIPAddress),
+			"sub_domain_suffix":    utils.NormalizeNilableString(endpoint.SubDomainSuffix),
+			"access_modes":         utils.FlattenStringSlice(endpoint.AccessModes),
+			"disable_gateway_auth": disableGatewayAuth,
+		})
+	}
+
+	return output
+}
+
+func flattenHDInsightClusterApplicationSshEndpoints(input *[]hdinsight.ApplicationGetEndpoint) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, endpoint := range *input {
+		publicPort := 0
+		if endpoint.PublicPort != nil {
+			publicPort = int(*endpoint.PublicPort)
+		}
+
+		destinationPort := 0
+		if endpoint.DestinationPort != nil {
+			destinationPort = int(*endpoint.DestinationPort)
+		}
+
+		output = append(output, map[string]interface{}{
+			"location":           utils.NormalizeNilableString(endpoint.Location),
+			"public_port":        publicPort,
+			"destination_port":   destinationPort,
+			"private_ip_address": utils.NormalizeNilableString(endpoint.PrivateIPAddress),
+		})
+	}
+
+	return output
+}
+
+// flattenHDInsightClusterApplicationErrors flattens the errors returned when an Application fails to install, which
+// is the only place the reason is exposed without looking at the logs of the edge nodes
+func flattenHDInsightClusterApplicationErrors(input *[]hdinsight.Errors) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, v := range *input {
+		output = append(output, map[string]interface{}{
+			"code":    utils.NormalizeNilableString(v.Code),
+			"message": utils.NormalizeNilableString(v.Message),
+		})
+	}
+
+	return output
+}
@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+)
+
+type HDInsightClusterApplicationsDataSource struct{}
+
+func TestAccDataSourceHDInsightClusterApplications_namePrefix(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster_applications", "test")
+	r := HDInsightClusterApplicationsDataSource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.namePrefix(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("applications.#").HasValue("1"),
+				check.That(data.ResourceName).Key("applications.0.name").HasValue(fmt.Sprintf("acctestapp-%d", data.RandomInteger)),
+				check.That(data.ResourceName).Key("applications.0.application_type").HasValue("CustomApplication"),
+				check.That(data.ResourceName).Key("applications.0.provisioning_state").HasValue("Succeeded"),
+				check.That(data.ResourceName).Key("applications.0.vm_size").IsSet(),
+				check.That(data.ResourceName).Key("applications.0.errors.#").HasValue("0"),
+			),
+		},
+	})
+}
+
+func (HDInsightClusterApplicationsDataSource) namePrefix(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_hdinsight_cluster_applications" "test" {
+  cluster_id  = azurerm_hdinsight_application.test.cluster_id
+  name_prefix = "acctestapp-"
+}
+`, HDInsightApplicationResource{}.basic(data))
+}
@@ -0,0 +1,388 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonschema"
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/location"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/validation"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+)
+
+// hdinsightCostEstimateRoles are the roles which can be estimated for each kind of cluster, along with the number of
+// nodes the role has when this is fixed by HDInsight
+var hdinsightCostEstimateRoles = map[string]map[string]int{
+	"hadoop":           {"head_node": 2, "worker_node": 0, "zookeeper_node": 3, "edge_node": 0},
+	"hbase":            {"head_node": 2, "worker_node": 0, "zookeeper_node": 3},
+	"interactivequery": {"head_node": 2, "worker_node": 0, "zookeeper_node": 3},
+	"kafka":            {"head_node": 2, "worker_node": 0, "zookeeper_node": 3, "kafka_management_node": 2},
+	"spark":            {"head_node": 2, "worker_node": 0, "zookeeper_node": 3, "edge_node": 0},
+}
+
+type hdinsightCostEstimateRole struct {
+	Name          string
+	VMSize        string
+	InstanceCount int
+	DisksPerNode  int
+}
+
+type hdinsightCostEstimateMeter struct {
+	hdinsightCostEstimateRole
+	Cores               int
+	MeterId             string
+	MeterUnit           string
+	HourlyMeterQuantity int
+	DiskMeterId         string
+	DiskSku             string
+}
+
+func dataSourceHDInsightClusterCostEstimate() *pluginsdk.Resource {
+	return &pluginsdk.Resource{
+		Read: dataSourceHDInsightClusterCostEstimateRead,
+
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Read: pluginsdk.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"location": commonschema.Location(),
+
+			"kind": {
+				Type:     pluginsdk.TypeString,
+				Required: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					"hadoop",
+					"hbase",
+					"interactivequery",
+					"kafka",
+					"spark",
+				}, false),
+			},
+
+			"tier": {
+				Type:     pluginsdk.TypeString,
+				Required: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					string(hdinsight.TierStandard),
+					string(hdinsight.TierPremium),
+				}, false),
+			},
+
+			"role": {
+				Type:     pluginsdk.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &pluginsdk.Resource{
+					Schema: map[string]*pluginsdk.Schema{
+						"name": {
+							Type:     pluginsdk.TypeString,
+							Required: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"head_node",
+								"worker_node",
+								"zookeeper_node",
+								"edge_node",
+								"kafka_management_node",
+							}, false),
+						},
+
+						"vm_size": {
+							Type:         pluginsdk.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
+						},
+
+						"instance_count": {
+							Type:         pluginsdk.TypeInt,
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validation.IntAtLeast(1),
+						},
+
+						"disks_per_node": {
+							Type:         pluginsdk.TypeInt,
+							Optional:     true,
+							ValidateFunc: validation.IntBetween(1, 8),
+						},
+
+						"cores": {
+							Type:     pluginsdk.TypeInt,
+							Computed: true,
+						},
+
+						"meter_id": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"meter_unit": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"hourly_meter_quantity": {
+							Type:     pluginsdk.TypeInt,
+							Computed: true,
+						},
+
+						"disk_meter_id": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"disk_sku": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+
+			"total_cores": {
+				Type:     pluginsdk.TypeInt,
+				Computed: true,
+			},
+
+			"total_hourly_meter_quantities": {
+				Type:     pluginsdk.TypeMap,
+				Computed: true,
+				Elem: &pluginsdk.Schema{
+					Type: pluginsdk.TypeInt,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceHDInsightClusterCostEstimateRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.LocationsClient
+	subscriptionId := meta.(*clients.Client).Account.SubscriptionId
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	loc := location.Normalize(d.Get("location").(string))
+	kind := d.Get("kind").(string)
+
+	roles, err := expandHDInsightCostEstimateRoles(kind, d.Get("role").([]interface{}))
+	if err != nil {
+		return err
+	}
+
+	billingSpecs, err := client.ListBillingSpecs(ctx, loc)
+	if err != nil {
+		return fmt.Errorf("retrieving the HDInsight Billing Specs for %q: %+v", loc, err)
+	}
+
+	meters, err := hdinsightClusterCostEstimate(billingSpecs, loc, hdinsight.Tier(d.Get("tier").(string)), roles)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.HDInsight/locations/%s/billingSpecs", subscriptionId, loc))
+	d.Set("location", loc)
+
+	totalCores := 0
+	totals := make(map[string]interface{})
+	output := make([]interface{}, 0, len(meters))
+	for _, meter := range meters {
+		totalCores += meter.Cores * meter.InstanceCount
+
+		total, _ := totals[meter.MeterUnit].(int)
+		totals[meter.MeterUnit] = total + meter.HourlyMeterQuantity
+
+		output = append(output, map[string]interface{}{
+			"name":                  meter.Name,
+			"vm_size":               meter.VMSize,
+			"instance_count":        meter.InstanceCount,
+			"cores":                 meter.Cores,
+			"meter_id":              meter.MeterId,
+			"meter_unit":            meter.MeterUnit,
+			"hourly_meter_quantity": meter.HourlyMeterQuantity,
+			"disks_per_node":        meter.DisksPerNode,
+			"disk_meter_id":         meter.DiskMeterId,
+			"disk_sku":              meter.DiskSku,
+		})
+	}
+
+	if err := d.Set("role", output); err != nil {
+		return fmt.Errorf("setting `role`: %+v", err)
+	}
+	d.Set("total_cores", totalCores)
+	if err := d.Set("total_hourly_meter_quantities", totals); err != nil {
+		return fmt.Errorf("setting `total_hourly_meter_quantities`: %+v", err)
+	}
+
+	return nil
+}
+
+// expandHDInsightCostEstimateRoles validates the roles against the kind of cluster, defaulting the number of nodes for
+// the roles where this is fixed by HDInsight
+func expandHDInsightCostEstimateRoles(kind string, input []interface{}) ([]hdinsightCostEstimateRole, error) {
+	supported := hdinsightCostEstimateRoles[kind]
+
+	roles := make([]hdinsightCostEstimateRole, 0)
+	specified := make(map[string]struct{})
+	for _, raw := range input {
+		if raw == nil {
+			continue
+		}
+		v := raw.(map[string]interface{})
+
+		role := hdinsightCostEstimateRole{
+			Name:          v["name"].(string),
+			VMSize:        v["vm_size"].(string),
+			InstanceCount: v["instance_count"].(int),
+			DisksPerNode:  v["disks_per_node"].(int),
+		}
+
+		fixedCount, ok := supported[role.Name]
+		if !ok {
+			return nil, fmt.Errorf("the role %q isn't supported for a %q cluster", role.Name, kind)
+		}
+		if _, exists := specified[role.Name]; exists {
+			return nil, fmt.Errorf("the role %q is specified more than once", role.Name)
+		}
+		specified[role.Name] = struct{}{}
+
+		if role.DisksPerNode != 0 && (kind != "kafka" || role.Name != "worker_node") {
+			return nil, fmt.Errorf("`disks_per_node` can only be specified for the \"worker_node\" role of a \"kafka\" cluster")
+		}
+		if kind == "kafka" && role.Name == "worker_node" && role.DisksPerNode == 0 {
+			return nil, fmt.Errorf("`disks_per_node` must be specified for the \"worker_node\" role of a \"kafka\" cluster")
+		}
+
+		switch {
+		case fixedCount != 0 && role.InstanceCount == 0:
+			role.InstanceCount = fixedCount
+		case fixedCount != 0 && role.InstanceCount != fixedCount:
+			return nil, fmt.Errorf("the %q role of a %q cluster always has %d nodes but got an `instance_count` of %d", role.Name, kind, fixedCount, role.InstanceCount)
+		case role.InstanceCount == 0:
+			return nil, fmt.Errorf("`instance_count` must be specified for the %q role", role.Name)
+		}
+
+		roles = append(roles, role)
+	}
+
+	for _, name := range []string{"head_node", "worker_node", "zookeeper_node"} {
+		if _, ok := specified[name]; !ok {
+			return nil, fmt.Errorf("the %q role must be specified, since every HDInsight cluster has one", name)
+		}
+	}
+
+	return roles, nil
+}
+
+// hdinsightClusterCostEstimate joins the roles against the billing meters for the location. The meters are billed in
+// either VM hours or core hours, so the hourly quantity of each meter is calculated from the number of nodes and cores.
+// The tier only selects the managed disk meter, which is used by the Kafka worker nodes.
+func hdinsightClusterCostEstimate(billingSpecs hdinsight.BillingResponseListResult, loc string, tier hdinsight.Tier, roles []hdinsightCostEstimateRole) ([]hdinsightCostEstimateMeter, error) {
+	var meters []hdinsight.BillingMeters
+	var diskMeter *hdinsight.DiskBillingMeters
+	if billingSpecs.BillingResources != nil {
+		for _, resource := range *billingSpecs.BillingResources {
+			if resource.Region == nil || location.Normalize(*resource.Region) != loc || resource.BillingMeters == nil {
+				continue
+			}
+			meters = *resource.BillingMeters
+			if resource.DiskBillingMeters != nil {
+				for i, v := range *resource.DiskBillingMeters {
+					if strings.EqualFold(string(v.Tier), string(tier)) {
+						diskMeter = &(*resource.DiskBillingMeters)[i]
+						break
+					}
+				}
+			}
+			break
+		}
+	}
+	if len(meters) == 0 {
+		return nil, fmt.Errorf("no HDInsight billing meters were returned for %q", loc)
+	}
+
+	output := make([]hdinsightCostEstimateMeter, 0, len(roles))
+	for _, role := range roles {
+		var meter *hdinsight.BillingMeters
+		for i, v := range meters {
+			if v.MeterParameter != nil && strings.EqualFold(*v.MeterParameter, role.VMSize) {
+				meter = &meters[i]
+				break
+			}
+		}
+		if meter == nil {
+			available := make([]string, 0)
+			for _, v := range meters {
+				if v.MeterParameter != nil {
+					available = append(available, *v.MeterParameter)
+				}
+			}
+			sort.Strings(available)
+			return nil, fmt.Errorf("no HDInsight billing meter was found for the VM Size %q of the %q role in %q - the VM Sizes with a billing meter are %s", role.VMSize, role.Name, loc, strings.Join(available, ", "))
+		}
+
+		cores, ok := hdinsightBillingVMSizeCores(billingSpecs.VMSizeProperties, role.VMSize)
+		if !ok {
+			return nil, fmt.Errorf("the number of cores for the VM Size %q of the %q role isn't known", role.VMSize, role.Name)
+		}
+
+		result := hdinsightCostEstimateMeter{
+			hdinsightCostEstimateRole: role,
+			Cores:                     cores,
+			HourlyMeterQuantity:       role.InstanceCount,
+		}
+		if meter.Meter != nil {
+			result.MeterId = *meter.Meter
+		}
+		if meter.Unit != nil {
+			result.MeterUnit = *meter.Unit
+		}
+		if strings.EqualFold(result.MeterUnit, "CoreHours") {
+			result.HourlyMeterQuantity = role.InstanceCount * cores
+		}
+
+		if role.DisksPerNode > 0 {
+			if diskMeter == nil {
+				return nil, fmt.Errorf("no HDInsight managed disk billing meter was found for the %q tier in %q", tier, loc)
+			}
+			if diskMeter.DiskRpMeter != nil {
+				result.DiskMeterId = *diskMeter.DiskRpMeter
+			}
+			if diskMeter.Sku != nil {
+				result.DiskSku = *diskMeter.Sku
+			}
+		}
+
+		output = append(output, result)
+	}
+
+	return output, nil
+}
+
+// hdinsightBillingVMSizeCores returns the number of cores for the VM Size from the billing specs, falling back to the
+// VM Sizes known to the provider
+func hdinsightBillingVMSizeCores(properties *[]hdinsight.VMSizeProperty, vmSize string) (int, bool) {
+	if properties != nil {
+		for _, v := range *properties {
+			if v.Name != nil && v.Cores != nil && strings.EqualFold(*v.Name, vmSize) {
+				return int(*v.Cores), true
+			}
+		}
+	}
+
+	for k, v := range validate.NodeDefinitionVMSizeCores {
+		if strings.EqualFold(k, vmSize) {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
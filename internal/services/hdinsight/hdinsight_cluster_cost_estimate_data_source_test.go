@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+)
+
+type HDInsightClusterCostEstimateDataSource struct{}
+
+func TestAccDataSourceHDInsightClusterCostEstimate_spark(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster_cost_estimate", "test")
+	r := HDInsightClusterCostEstimateDataSource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.spark(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("role.#").HasValue("3"),
+				check.That(data.ResourceName).Key("role.0.instance_count").HasValue("2"),
+				check.That(data.ResourceName).Key("role.0.meter_id").Exists(),
+				check.That(data.ResourceName).Key("role.0.meter_unit").Exists(),
+				check.That(data.ResourceName).Key("role.1.instance_count").HasValue("3"),
+				check.That(data.ResourceName).Key("role.2.instance_count").HasValue("3"),
+				check.That(data.ResourceName).Key("total_cores").HasValue("32"),
+			),
+		},
+	})
+}
+
+func TestAccDataSourceHDInsightClusterCostEstimate_kafka(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster_cost_estimate", "test")
+	r := HDInsightClusterCostEstimateDataSource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.kafka(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("role.#").HasValue("4"),
+				check.That(data.ResourceName).Key("role.1.disk_meter_id").Exists(),
+				check.That(data.ResourceName).Key("role.1.disk_sku").Exists(),
+				check.That(data.ResourceName).Key("role.3.instance_count").HasValue("2"),
+			),
+		},
+	})
+}
+
+func (HDInsightClusterCostEstimateDataSource) spark(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+provider "azurerm" {
+  features {}
+}
+
+data "azurerm_hdinsight_cluster_cost_estimate" "test" {
+  location = %q
+  kind     = "spark"
+  tier     = "Standard"
+
+  role {
+    name    = "head_node"
+    vm_size = "Standard_A4_V2"
+  }
+
+  role {
+    name           = "worker_node"
+    vm_size        = "Standard_A4_V2"
+    instance_count = 3
+  }
+
+  role {
+    name    = "zookeeper_node"
+    vm_size = "Standard_A4_V2"
+  }
+}
+`, data.Locations.Primary)
+}
+
+func (HDInsightClusterCostEstimateDataSource) kafka(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+provider "azurerm" {
+  features {}
+}
+
+data "azurerm_hdinsight_cluster_cost_estimate" "test" {
+  location = %q
+  kind     = "kafka"
+  tier     = "Standard"
+
+  role {
+    name    = "head_node"
+    vm_size = "Standard_D3_V2"
+  }
+
+  role {
+    name           = "worker_node"
+    vm_size        = "Standard_D3_V2"
+    instance_count = 3
+    disks_per_node = 2
+  }
+
+  role {
+    name    = "zookeeper_node"
+    vm_size = "Standard_D3_V2"
+  }
+
+  role {
+    name    = "kafka_management_node"
+    vm_size = "Standard_D3_V2"
+  }
+}
+`, data.Locations.Primary)
+}
@@ -5,7 +5,7 @@ package hdinsight
 
 import (
 	"fmt"
-	"strings"
+	"log"
 	"time"
 
 	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonschema"
@@ -61,6 +61,21 @@ func dataSourceHDInsightSparkCluster() *pluginsdk.Resource {
 				Computed: true,
 			},
 
+			"encryption_in_transit_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Computed: true,
+			},
+
+			"customer_managed_key_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Computed: true,
+			},
+
+			"cluster_state": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
 			"gateway": {
 				Type:     pluginsdk.TypeList,
 				Computed: true,
@@ -95,6 +110,21 @@ func dataSourceHDInsightSparkCluster() *pluginsdk.Resource {
 				Computed: true,
 			},
 
+			"https_url": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"endpoint_dns_label": SchemaHDInsightEndpointDNSLabel(),
+
+			"application_endpoints": {
+				Type:     pluginsdk.TypeMap,
+				Computed: true,
+				Elem: &pluginsdk.Schema{
+					Type: pluginsdk.TypeString,
+				},
+			},
+
 			"kafka_rest_proxy_endpoint": {
 				Type:     pluginsdk.TypeString,
 				Computed: true,
@@ -104,6 +134,18 @@ func dataSourceHDInsightSparkCluster() *pluginsdk.Resource {
 				Type:     pluginsdk.TypeString,
 				Computed: true,
 			},
+
+			"current_worker_count": {
+				Type:     pluginsdk.TypeInt,
+				Computed: true,
+			},
+
+			"max_worker_count": {
+				Type:     pluginsdk.TypeInt,
+				Computed: true,
+			},
+
+			"autoscale": SchemaHDInsightDataSourceAutoscale(),
 		},
 	}
 }
@@ -112,6 +154,7 @@ func dataSourceHDInsightClusterRead(d *pluginsdk.ResourceData, meta interface{})
 	clustersClient := meta.(*clients.Client).HDInsight.ClustersClient
 	subscriptionId := meta.(*clients.Client).Account.SubscriptionId
 	configurationsClient := meta.(*clients.Client).HDInsight.ConfigurationsClient
+	virtualMachinesClient := meta.(*clients.Client).HDInsight.VirtualMachinesClient
 	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
 	defer cancel()
 
@@ -122,12 +165,18 @@ func dataSourceHDInsightClusterRead(d *pluginsdk.ResourceData, meta interface{})
 			return fmt.Errorf("%s was not found", id)
 		}
 
-		return fmt.Errorf("retrieving %s: %+v", id, err)
+		return hdinsightClusterReadError(ctx, id.String(), err)
 	}
 
 	configuration, err := configurationsClient.Get(ctx, id.ResourceGroup, id.Name, "gateway")
+	configurationAvailable := true
 	if err != nil {
-		return fmt.Errorf("retrieving Configuration for %s: %+v", id, err)
+		if !hdinsightClusterConfigurationsNotReady(configuration.Response) {
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("Configuration for %s", id), err)
+		}
+
+		log.Printf("[DEBUG] Configuration for %s isn't available since the cluster isn't ready - skipping `gateway`: %+v", id, err)
+		configurationAvailable = false
 	}
 
 	d.SetId(id.ID())
@@ -138,16 +187,24 @@ func dataSourceHDInsightClusterRead(d *pluginsdk.ResourceData, meta interface{})
 
 	if props := resp.Properties; props != nil {
 		d.Set("cluster_version", props.ClusterVersion)
-		d.Set("tier", string(props.Tier))
+		d.Set("tier", normalizeHDInsightTier(string(props.Tier)))
 		d.Set("tls_min_version", props.MinSupportedTLSVersion)
 
+		d.Set("encryption_in_transit_enabled", FlattenHDInsightEncryptionInTransitProperties(props.EncryptionInTransitProperties))
+		d.Set("customer_managed_key_enabled", FlattenHDInsightCustomerManagedKeyEnabled(props.DiskEncryptionProperties))
+		d.Set("cluster_state", utils.NormalizeNilableString(props.ClusterState))
+
+		kind := ""
 		if def := props.ClusterDefinition; def != nil {
 			d.Set("component_versions", flattenHDInsightsDataSourceComponentVersions(def.ComponentVersion))
-			if kind := def.Kind; kind != nil {
-				d.Set("kind", strings.ToLower(*kind))
+			if def.Kind != nil {
+				kind = normalizeHDInsightClusterKind(*def.Kind)
 			}
-			if err := d.Set("gateway", FlattenHDInsightsConfigurations(configuration.Value, d)); err != nil {
-				return fmt.Errorf("flattening `gateway`: %+v", err)
+			d.Set("kind", kind)
+			if configurationAvailable {
+				if err := d.Set("gateway", FlattenHDInsightsConfigurations(configuration.Value, d)); err != nil {
+					return fmt.Errorf("flattening `gateway`: %+v", err)
+				}
 			}
 		}
 
@@ -155,10 +212,24 @@ func dataSourceHDInsightClusterRead(d *pluginsdk.ResourceData, meta interface{})
 		d.Set("edge_ssh_endpoint", edgeNodeSshEndpoint)
 		httpEndpoint := FindHDInsightConnectivityEndpoint("HTTPS", props.ConnectivityEndpoints)
 		d.Set("https_endpoint", httpEndpoint)
+		d.Set("https_url", hdinsightClusterHttpsUrl(httpEndpoint))
+		d.Set("endpoint_dns_label", hdinsightClusterEndpointDNSLabel(id.Name, httpEndpoint))
+		d.Set("application_endpoints", flattenHDInsightClusterApplicationEndpoints(kind, httpEndpoint))
 		sshEndpoint := FindHDInsightConnectivityEndpoint("SSH", props.ConnectivityEndpoints)
 		d.Set("ssh_endpoint", sshEndpoint)
 		kafkaRestProxyEndpoint := FindHDInsightConnectivityEndpoint("KafkaRestProxyPublicEndpoint", props.ConnectivityEndpoints)
 		d.Set("kafka_rest_proxy_endpoint", kafkaRestProxyEndpoint)
+
+		if err := d.Set("autoscale", FlattenHDInsightNodeAutoscaleDefinition(hdinsightClusterWorkerNodeAutoscale(props))); err != nil {
+			return fmt.Errorf("setting `autoscale`: %+v", err)
+		}
+		d.Set("max_worker_count", hdinsightClusterMaxWorkerCount(props))
+
+		currentWorkerCount, err := hdinsightClusterCurrentWorkerCount(ctx, virtualMachinesClient, id.ResourceGroup, id.Name, props.ComputeProfile)
+		if err != nil {
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("hosts for %s", id), err)
+		}
+		d.Set("current_worker_count", currentWorkerCount)
 	}
 
 	return tags.FlattenAndSet(d, resp.Tags)
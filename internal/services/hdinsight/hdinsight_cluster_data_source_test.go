@@ -24,7 +24,13 @@ func TestAccDataSourceHDInsightCluster_hadoop(t *testing.T) {
 				check.That(data.ResourceName).Key("tier").HasValue("standard"),
 				check.That(data.ResourceName).Key("edge_ssh_endpoint").HasValue(""),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("https_url").Exists(),
+				check.That(data.ResourceName).Key("application_endpoints.ambari").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("current_worker_count").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("customer_managed_key_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("cluster_state").HasValue("Running"),
 			),
 		},
 	})
@@ -41,7 +47,10 @@ func TestAccDataSourceHDInsightCluster_hbase(t *testing.T) {
 				check.That(data.ResourceName).Key("tier").HasValue("standard"),
 				check.That(data.ResourceName).Key("edge_ssh_endpoint").HasValue(""),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("https_url").Exists(),
+				check.That(data.ResourceName).Key("application_endpoints.ambari").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
 			),
 		},
 	})
@@ -58,7 +67,10 @@ func TestAccDataSourceHDInsightCluster_interactiveQuery(t *testing.T) {
 				check.That(data.ResourceName).Key("tier").HasValue("standard"),
 				check.That(data.ResourceName).Key("edge_ssh_endpoint").HasValue(""),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("https_url").Exists(),
+				check.That(data.ResourceName).Key("application_endpoints.ambari").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
 			),
 		},
 	})
@@ -75,7 +87,10 @@ func TestAccDataSourceHDInsightCluster_kafka(t *testing.T) {
 				check.That(data.ResourceName).Key("tier").HasValue("standard"),
 				check.That(data.ResourceName).Key("edge_ssh_endpoint").HasValue(""),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("https_url").Exists(),
+				check.That(data.ResourceName).Key("application_endpoints.ambari").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
 			),
 		},
 	})
@@ -92,7 +107,10 @@ func TestAccDataSourceHDInsightCluster_kafkaWithRestProxy(t *testing.T) {
 				check.That(data.ResourceName).Key("tier").HasValue("standard"),
 				check.That(data.ResourceName).Key("edge_ssh_endpoint").HasValue(""),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("https_url").Exists(),
+				check.That(data.ResourceName).Key("application_endpoints.ambari").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
 				check.That(data.ResourceName).Key("kafka_rest_proxy_endpoint").Exists(),
 			),
 		},
@@ -110,7 +128,41 @@ func TestAccDataSourceHDInsightCluster_spark(t *testing.T) {
 				check.That(data.ResourceName).Key("tier").HasValue("standard"),
 				check.That(data.ResourceName).Key("edge_ssh_endpoint").HasValue(""),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("https_url").Exists(),
+				check.That(data.ResourceName).Key("application_endpoints.ambari").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
+			),
+		},
+	})
+}
+
+func TestAccDataSourceHDInsightCluster_autoscaleCapacity(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster", "test")
+	r := HDInsightClusterDataSourceResource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.autoscaleCapacity(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("autoscale.0.capacity.0.min_instance_count").HasValue("2"),
+				check.That(data.ResourceName).Key("autoscale.0.capacity.0.max_instance_count").HasValue("3"),
+				check.That(data.ResourceName).Key("max_worker_count").HasValue("3"),
+				check.That(data.ResourceName).Key("current_worker_count").Exists(),
+			),
+		},
+	})
+}
+
+func TestAccDataSourceHDInsightCluster_autoscaleRecurrence(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster", "test")
+	r := HDInsightClusterDataSourceResource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.autoscaleRecurrence(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("autoscale.0.recurrence.0.timezone").HasValue("Pacific Standard Time"),
+				check.That(data.ResourceName).Key("autoscale.0.recurrence.0.schedule.#").HasValue("2"),
+				check.That(data.ResourceName).Key("max_worker_count").HasValue("5"),
 			),
 		},
 	})
@@ -181,3 +233,25 @@ data "azurerm_hdinsight_cluster" "test" {
 }
 `, HDInsightSparkClusterResource{}.basic(data))
 }
+
+func (HDInsightClusterDataSourceResource) autoscaleCapacity(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_hdinsight_cluster" "test" {
+  name                = azurerm_hdinsight_spark_cluster.test.name
+  resource_group_name = azurerm_hdinsight_spark_cluster.test.resource_group_name
+}
+`, HDInsightSparkClusterResource{}.autoscale_capacity(data))
+}
+
+func (HDInsightClusterDataSourceResource) autoscaleRecurrence(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_hdinsight_cluster" "test" {
+  name                = azurerm_hdinsight_spark_cluster.test.name
+  resource_group_name = azurerm_hdinsight_spark_cluster.test.resource_group_name
+}
+`, HDInsightSparkClusterResource{}.autoscale_schedule(data))
+}
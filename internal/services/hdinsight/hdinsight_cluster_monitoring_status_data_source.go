@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+func dataSourceHDInsightClusterMonitoringStatus() *pluginsdk.Resource {
+	return &pluginsdk.Resource{
+		Read: dataSourceHDInsightClusterMonitoringStatusRead,
+
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Read: pluginsdk.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"cluster_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ValidateFunc: validate.ClusterID,
+			},
+
+			"log_analytics_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Computed: true,
+			},
+
+			"log_analytics_workspace_id": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"azure_monitor_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Computed: true,
+			},
+
+			"azure_monitor_workspace_id": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceHDInsightClusterMonitoringStatusRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	extensionsClient := meta.(*clients.Client).HDInsight.ExtensionsClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Get("cluster_id").(string))
+	if err != nil {
+		return err
+	}
+
+	monitor, err := extensionsClient.GetMonitoringStatus(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(monitor.Response) {
+			return fmt.Errorf("%s was not found", id)
+		}
+
+		return hdinsightClusterReadError(ctx, fmt.Sprintf("Monitoring Status for %s", id), err)
+	}
+
+	extension, err := extensionsClient.GetAzureMonitorStatus(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		return hdinsightClusterReadError(ctx, fmt.Sprintf("Azure Monitor Status for %s", id), err)
+	}
+
+	d.SetId(id.ID())
+	d.Set("cluster_id", id.ID())
+
+	logAnalyticsEnabled := monitor.ClusterMonitoringEnabled != nil && *monitor.ClusterMonitoringEnabled
+	d.Set("log_analytics_enabled", logAnalyticsEnabled)
+	logAnalyticsWorkspaceId := ""
+	if logAnalyticsEnabled && monitor.WorkspaceID != nil {
+		logAnalyticsWorkspaceId = *monitor.WorkspaceID
+	}
+	d.Set("log_analytics_workspace_id", logAnalyticsWorkspaceId)
+
+	azureMonitorEnabled := extension.ClusterMonitoringEnabled != nil && *extension.ClusterMonitoringEnabled
+	d.Set("azure_monitor_enabled", azureMonitorEnabled)
+	azureMonitorWorkspaceId := ""
+	if azureMonitorEnabled && extension.WorkspaceID != nil {
+		azureMonitorWorkspaceId = *extension.WorkspaceID
+	}
+	d.Set("azure_monitor_workspace_id", azureMonitorWorkspaceId)
+
+	return nil
+}
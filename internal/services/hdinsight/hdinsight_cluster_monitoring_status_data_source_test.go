@@ -0,0 +1,66 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+)
+
+type HDInsightClusterMonitoringStatusDataSource struct{}
+
+func TestAccDataSourceHDInsightClusterMonitoringStatus_logAnalytics(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster_monitoring_status", "test")
+	r := HDInsightClusterMonitoringStatusDataSource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.logAnalytics(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("log_analytics_enabled").HasValue("true"),
+				check.That(data.ResourceName).Key("log_analytics_workspace_id").Exists(),
+				check.That(data.ResourceName).Key("azure_monitor_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("azure_monitor_workspace_id").HasValue(""),
+			),
+		},
+	})
+}
+
+func TestAccDataSourceHDInsightClusterMonitoringStatus_azureMonitor(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster_monitoring_status", "test")
+	r := HDInsightClusterMonitoringStatusDataSource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.azureMonitor(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("log_analytics_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("log_analytics_workspace_id").HasValue(""),
+				check.That(data.ResourceName).Key("azure_monitor_enabled").HasValue("true"),
+				check.That(data.ResourceName).Key("azure_monitor_workspace_id").Exists(),
+			),
+		},
+	})
+}
+
+func (HDInsightClusterMonitoringStatusDataSource) logAnalytics(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_hdinsight_cluster_monitoring_status" "test" {
+  cluster_id = azurerm_hdinsight_spark_cluster.test.id
+}
+`, HDInsightSparkClusterResource{}.monitor(data))
+}
+
+func (HDInsightClusterMonitoringStatusDataSource) azureMonitor(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_hdinsight_cluster_monitoring_status" "test" {
+  cluster_id = azurerm_hdinsight_spark_cluster.test.id
+}
+`, HDInsightSparkClusterResource{}.azureMonitor(data))
+}
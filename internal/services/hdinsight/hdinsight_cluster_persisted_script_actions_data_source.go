@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+func dataSourceHDInsightClusterPersistedScriptActions() *pluginsdk.Resource {
+	return &pluginsdk.Resource{
+		Read: dataSourceHDInsightClusterPersistedScriptActionsRead,
+
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Read: pluginsdk.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"cluster_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ValidateFunc: validate.ClusterID,
+			},
+
+			"script_actions": {
+				Type:     pluginsdk.TypeList,
+				Computed: true,
+				Elem: &pluginsdk.Resource{
+					Schema: map[string]*pluginsdk.Schema{
+						"name": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"uri": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"parameters": {
+							Type:     pluginsdk.TypeString,
+							Computed: true,
+						},
+
+						"roles": {
+							Type:     pluginsdk.TypeList,
+							Computed: true,
+							Elem: &pluginsdk.Schema{
+								Type: pluginsdk.TypeString,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceHDInsightClusterPersistedScriptActionsRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Get("cluster_id").(string))
+	if err != nil {
+		return err
+	}
+
+	iterator, err := client.ListByClusterComplete(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		if utils.ResponseWasNotFound(iterator.Response().Response) {
+			return fmt.Errorf("%s was not found", id)
+		}
+
+		return fmt.Errorf("listing the Persisted Script Actions for %s: %+v", id, err)
+	}
+
+	scriptActions := make([]hdinsight.RuntimeScriptActionDetail, 0)
+	for iterator.NotDone() {
+		scriptActions = append(scriptActions, iterator.Value())
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return fmt.Errorf("listing the Persisted Script Actions for %s: %+v", id, err)
+		}
+	}
+
+	d.SetId(id.ID())
+	d.Set("cluster_id", id.ID())
+
+	if err := d.Set("script_actions", flattenHDInsightPersistedScriptActions(scriptActions)); err != nil {
+		return fmt.Errorf("setting `script_actions`: %+v", err)
+	}
+
+	return nil
+}
+
+func flattenHDInsightPersistedScriptActions(input []hdinsight.RuntimeScriptActionDetail) []interface{} {
+	output := make([]interface{}, 0)
+	for _, v := range input {
+		name := ""
+		if v.Name != nil {
+			name = *v.Name
+		}
+
+		uri := ""
+		if v.URI != nil {
+			uri = *v.URI
+		}
+
+		parameters := ""
+		if v.Parameters != nil {
+			parameters = *v.Parameters
+		}
+
+		roles := make([]interface{}, 0)
+		if v.Roles != nil {
+			for _, role := range *v.Roles {
+				roles = append(roles, role)
+			}
+		}
+
+		output = append(output, map[string]interface{}{
+			"name":       name,
+			"uri":        uri,
+			"parameters": parameters,
+			"roles":      roles,
+		})
+	}
+
+	return output
+}
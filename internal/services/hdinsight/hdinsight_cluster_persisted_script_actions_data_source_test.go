@@ -0,0 +1,42 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+)
+
+type HDInsightClusterPersistedScriptActionsDataSource struct{}
+
+func TestAccDataSourceHDInsightClusterPersistedScriptActions_basic(t *testing.T) {
+	data := acceptance.BuildTestData(t, "data.azurerm_hdinsight_cluster_persisted_script_actions", "test")
+	r := HDInsightClusterPersistedScriptActionsDataSource{}
+	data.DataSourceTest(t, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("script_actions.#").HasValue("1"),
+				check.That(data.ResourceName).Key("script_actions.0.name").HasValue("scriptactiontest"),
+				check.That(data.ResourceName).Key("script_actions.0.uri").HasValue("https://hdiconfigactions.blob.core.windows.net/linuxgiraphconfigactionv01/giraph-installer-v01.sh"),
+				check.That(data.ResourceName).Key("script_actions.0.parameters").HasValue("headnode"),
+				check.That(data.ResourceName).Key("script_actions.0.roles.#").HasValue("1"),
+				check.That(data.ResourceName).Key("script_actions.0.roles.0").HasValue("headnode"),
+			),
+		},
+	})
+}
+
+func (HDInsightClusterPersistedScriptActionsDataSource) basic(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+data "azurerm_hdinsight_cluster_persisted_script_actions" "test" {
+  cluster_id = azurerm_hdinsight_spark_cluster.test.id
+}
+`, HDInsightSparkClusterResource{}.roleScriptActions(data))
+}
@@ -5,8 +5,11 @@ package hdinsight_test
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
 )
 
@@ -223,3 +226,102 @@ resource "azurerm_virtual_network_dns_servers" "test" {
 }
 `, data.RandomInteger, data.Locations.Primary, data.RandomString)
 }
+
+// preCheckHDInsightExistingAADDS skips the test unless a long-lived Azure Active Directory Domain Services environment
+// has been configured, since provisioning a domain takes over an hour and is limited to one per tenant:
+// - ARM_TEST_HDINSIGHT_AADDS_RESOURCE_ID is the ID of the AADDS, which must have Secure LDAP enabled
+// - ARM_TEST_HDINSIGHT_AADDS_DOMAIN_NAME and ARM_TEST_HDINSIGHT_AADDS_LDAPS_URL are the domain name and LDAPS URL of the AADDS
+// - ARM_TEST_HDINSIGHT_AADDS_DOMAIN_USERNAME and ARM_TEST_HDINSIGHT_AADDS_DOMAIN_USER_PASSWORD are the credentials of a domain user
+// - ARM_TEST_HDINSIGHT_AADDS_CLUSTER_USERS_GROUP_DN is the name of a group synchronised to the domain
+// - ARM_TEST_HDINSIGHT_AADDS_MSI_RESOURCE_ID is the ID of a User Assigned Identity with the `HDInsight Domain Services Contributor` role on the AADDS
+// - ARM_TEST_HDINSIGHT_AADDS_SUBNET_ID is the ID of a Subnet in a Virtual Network which uses the AADDS as its DNS servers
+// The Resource Group created by the tests must be in the same region as the Subnet, so ARM_TEST_LOCATION should match it.
+// Checkout https://learn.microsoft.com/en-us/azure/hdinsight/domain-joined/apache-domain-joined-create-configure-enterprise-security-cluster for details.
+func preCheckHDInsightExistingAADDS(t *testing.T) {
+	variables := []string{
+		"ARM_TEST_HDINSIGHT_AADDS_RESOURCE_ID",
+		"ARM_TEST_HDINSIGHT_AADDS_DOMAIN_NAME",
+		"ARM_TEST_HDINSIGHT_AADDS_LDAPS_URL",
+		"ARM_TEST_HDINSIGHT_AADDS_DOMAIN_USERNAME",
+		"ARM_TEST_HDINSIGHT_AADDS_DOMAIN_USER_PASSWORD",
+		"ARM_TEST_HDINSIGHT_AADDS_CLUSTER_USERS_GROUP_DN",
+		"ARM_TEST_HDINSIGHT_AADDS_MSI_RESOURCE_ID",
+		"ARM_TEST_HDINSIGHT_AADDS_SUBNET_ID",
+	}
+
+	for _, variable := range variables {
+		value := os.Getenv(variable)
+		if value == "" {
+			t.Skipf("`%s` must be set for acceptance tests!", variable)
+		}
+	}
+
+	if _, err := commonids.ParseSubnetID(os.Getenv("ARM_TEST_HDINSIGHT_AADDS_SUBNET_ID")); err != nil {
+		t.Fatalf("parsing `ARM_TEST_HDINSIGHT_AADDS_SUBNET_ID`: %+v", err)
+	}
+}
+
+// hdInsightExistingAADDSTemplate exposes the environment configured for preCheckHDInsightExistingAADDS as locals, alongside
+// the Resource Group and Storage Container used by the cluster.
+func hdInsightExistingAADDSTemplate(data acceptance.TestData) string {
+	subnetId, _ := commonids.ParseSubnetID(os.Getenv("ARM_TEST_HDINSIGHT_AADDS_SUBNET_ID"))
+	virtualNetworkId := commonids.NewVirtualNetworkID(subnetId.SubscriptionId, subnetId.ResourceGroupName, subnetId.VirtualNetworkName)
+
+	return fmt.Sprintf(`
+provider "azurerm" {
+  features {}
+}
+
+locals {
+  aadds_resource_id      = %[4]q
+  domain_name            = %[5]q
+  ldaps_url              = %[6]q
+  domain_username        = %[7]q
+  domain_user_password   = %[8]q
+  cluster_users_group_dn = %[9]q
+  msi_resource_id        = %[10]q
+  subnet_id              = %[11]q
+  virtual_network_id     = %[12]q
+}
+
+resource "azurerm_resource_group" "test" {
+  name     = "acctestRG-%[1]d"
+  location = "%[2]s"
+}
+
+resource "azurerm_storage_account" "test" {
+  name                     = "acctestsa%[3]s"
+  resource_group_name      = azurerm_resource_group.test.name
+  location                 = azurerm_resource_group.test.location
+  account_tier             = "Standard"
+  account_replication_type = "LRS"
+}
+
+resource "azurerm_storage_container" "test" {
+  name                  = "acctest"
+  storage_account_name  = azurerm_storage_account.test.name
+  container_access_type = "private"
+}
+`, data.RandomInteger, data.Locations.Primary, data.RandomString,
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_RESOURCE_ID"),
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_DOMAIN_NAME"),
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_LDAPS_URL"),
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_DOMAIN_USERNAME"),
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_DOMAIN_USER_PASSWORD"),
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_CLUSTER_USERS_GROUP_DN"),
+		os.Getenv("ARM_TEST_HDINSIGHT_AADDS_MSI_RESOURCE_ID"),
+		subnetId.ID(),
+		virtualNetworkId.ID())
+}
+
+// withStrictValidation enables `strict_validation` within the `hdinsight` block of the provider `features`, so that the
+// warnings raised when planning the configuration fail the plan
+func withStrictValidation(config string) string {
+	return withHDInsightFeatures(config, "strict_validation = true")
+}
+
+// withHDInsightFeatures replaces the empty provider `features` block within the configuration with one containing an
+// `hdinsight` block with the specified arguments
+func withHDInsightFeatures(config string, arguments string) string {
+	return strings.Replace(config, "  features {}\n", fmt.Sprintf("  features {\n    hdinsight {\n      %s\n    }\n  }\n", arguments), 1)
+}
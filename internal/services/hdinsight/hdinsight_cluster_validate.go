@@ -0,0 +1,1528 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2021-09-01/storage"     // nolint: staticcheck
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/commonids"
+	"github.com/hashicorp/go-azure-helpers/resourcemanager/location"
+	"github.com/hashicorp/go-azure-sdk/resource-manager/resources/2022-09-01/providers"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/features"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+)
+
+// hdInsightRolesWithUsernames are the roles within the `roles` block which configure an SSH user
+var hdInsightRolesWithUsernames = []string{
+	"head_node",
+	"worker_node",
+	"zookeeper_node",
+	"kafka_management_node",
+}
+
+// hdinsightClusterPlanWarnings reports the warnings raised by the checks run when planning a cluster. These are logged
+// by default, since they flag configurations which are likely mistakes but still provision - unless `strict_validation`
+// is enabled within the `hdinsight` block of the provider `features`, in which case they fail the plan. Checks should
+// report their warnings using this rather than logging them, so that they're escalated consistently.
+func hdinsightClusterPlanWarnings(meta interface{}, warnings ...string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	if hdinsightStrictValidationEnabled(meta) {
+		return fmt.Errorf("%s\n\nthis is an error rather than a warning since `strict_validation` is enabled within the `hdinsight` block of the provider `features`", strings.Join(warnings, "\n"))
+	}
+
+	for _, warning := range warnings {
+		log.Printf("[WARN] %s", warning)
+	}
+
+	return nil
+}
+
+func hdinsightStrictValidationEnabled(meta interface{}) bool {
+	client, ok := meta.(*clients.Client)
+	return ok && client != nil && client.Features.HDInsight.StrictValidation
+}
+
+// hdinsightClusterGatewayUsernameDiff ensures that the gateway (Ambari) username isn't also used as the SSH username
+// for any of the roles - the API rejects this combination, but only once provisioning is well underway. Since the
+// gateway settings can only be updated with the existing username, changing it on an existing cluster is an error.
+func hdinsightClusterGatewayUsernameDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	if d.Id() != "" && d.HasChange("gateway.0.username") {
+		old, _ := d.GetChange("gateway.0.username")
+		return fmt.Errorf("`gateway.0.username` can't be changed from %q on an existing HDInsight Cluster - only the `password` and `enabled` fields of the `gateway` block can be updated. To use a different username either revert this change, or replace the cluster (for example using `terraform apply -replace`)", old.(string))
+	}
+
+	gatewayUsername := d.Get("gateway.0.username").(string)
+	if gatewayUsername == "" {
+		return nil
+	}
+
+	rolesRaw := d.Get("roles").([]interface{})
+	if len(rolesRaw) == 0 || rolesRaw[0] == nil {
+		return nil
+	}
+	roles := rolesRaw[0].(map[string]interface{})
+
+	for _, role := range hdInsightRolesWithUsernames {
+		nodesRaw, ok := roles[role]
+		if !ok {
+			continue
+		}
+
+		nodes := nodesRaw.([]interface{})
+		if len(nodes) == 0 || nodes[0] == nil {
+			continue
+		}
+
+		username := nodes[0].(map[string]interface{})["username"].(string)
+		if strings.EqualFold(username, gatewayUsername) {
+			return fmt.Errorf("`roles.0.%s.0.username` must be different from `gateway.0.username` - HDInsight requires the SSH username to differ from the cluster login (Ambari) username", role)
+		}
+	}
+
+	return nil
+}
+
+// hdinsightClusterPasswordReuseDiff ensures the same password isn't used for more than one of the gateway (Ambari), the
+// SSH users of the roles and the external metastores - HDInsight behaves inconsistently when the gateway and SSH users
+// share a password on clusters using the Enterprise Security Package. The SSH users of the different roles (and the
+// different metastores) commonly share credentials, so values are only compared between these groups.
+func hdinsightClusterPasswordReuseDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	collisions := hdinsightClusterReusedPasswords(d.Get("gateway").([]interface{}), d.Get("roles").([]interface{}), d.Get("metastores").([]interface{}))
+	if len(collisions) > 0 {
+		return fmt.Errorf("the same password can't be used for more than one of the gateway, the SSH users and the metastores of an HDInsight Cluster - but %s", strings.Join(collisions, ", "))
+	}
+
+	return nil
+}
+
+// hdinsightClusterReusedPasswords returns a description of each pair of fields, from different groups, which specify the
+// same password - without including the password itself
+func hdinsightClusterReusedPasswords(gatewayRaw, rolesRaw, metastoresRaw []interface{}) []string {
+	type passwordField struct {
+		group string
+		field string
+		value string
+	}
+	fields := make([]passwordField, 0)
+
+	if len(gatewayRaw) > 0 && gatewayRaw[0] != nil {
+		if v, _ := gatewayRaw[0].(map[string]interface{})["password"].(string); v != "" {
+			fields = append(fields, passwordField{group: "gateway", field: "gateway.0.password", value: v})
+		}
+	}
+
+	if len(rolesRaw) > 0 && rolesRaw[0] != nil {
+		roles := rolesRaw[0].(map[string]interface{})
+		for _, role := range hdInsightRolesWithUsernames {
+			nodes, ok := roles[role].([]interface{})
+			if !ok || len(nodes) == 0 || nodes[0] == nil {
+				continue
+			}
+
+			if v, _ := nodes[0].(map[string]interface{})["password"].(string); v != "" {
+				fields = append(fields, passwordField{group: "roles", field: fmt.Sprintf("roles.0.%s.0.password", role), value: v})
+			}
+		}
+	}
+
+	if len(metastoresRaw) > 0 && metastoresRaw[0] != nil {
+		metastores := metastoresRaw[0].(map[string]interface{})
+		for _, metastore := range []string{"hive", "oozie", "ambari"} {
+			v, ok := metastores[metastore].([]interface{})
+			if !ok || len(v) == 0 || v[0] == nil {
+				continue
+			}
+
+			if password, _ := v[0].(map[string]interface{})["password"].(string); password != "" {
+				fields = append(fields, passwordField{group: "metastores", field: fmt.Sprintf("metastores.0.%s.0.password", metastore), value: password})
+			}
+		}
+	}
+
+	collisions := make([]string, 0)
+	for i, first := range fields {
+		for _, second := range fields[i+1:] {
+			if first.group != second.group && first.value == second.value {
+				collisions = append(collisions, fmt.Sprintf("`%s` and `%s` are the same", first.field, second.field))
+			}
+		}
+	}
+
+	return collisions
+}
+
+// hdinsightClusterNodeTagsDiff ensures the same key isn't specified in both `tags` and `node_tags`, since they're
+// merged together when sent to the API and couldn't be told apart when reading them back
+func hdinsightClusterNodeTagsDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	clusterTags := d.Get("tags").(map[string]interface{})
+	nodeTags := d.Get("node_tags").(map[string]interface{})
+
+	keys := make([]string, 0)
+	for k := range nodeTags {
+		if _, ok := clusterTags[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return fmt.Errorf("the keys %q are specified in both `tags` and `node_tags` - each key must only be specified once", strings.Join(keys, ", "))
+	}
+
+	return nil
+}
+
+// hdinsightClusterStorageAccountsDiff ensures that exactly one of the accounts specified in the `storage_account` and
+// `storage_account_gen2` blocks is the default account, since the cluster's file system is built around it
+func hdinsightClusterStorageAccountsDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	return validateHDInsightsStorageAccountDefaults(d.Get("storage_account").([]interface{}), d.Get("storage_account_gen2").([]interface{}))
+}
+
+func validateHDInsightsStorageAccountDefaults(storageAccounts []interface{}, gen2StorageAccounts []interface{}) error {
+	if len(storageAccounts) == 0 && len(gen2StorageAccounts) == 0 {
+		return nil
+	}
+
+	defaults := make([]string, 0)
+	for blockName, accounts := range map[string][]interface{}{"storage_account": storageAccounts, "storage_account_gen2": gen2StorageAccounts} {
+		for i, raw := range accounts {
+			if raw == nil {
+				continue
+			}
+
+			if raw.(map[string]interface{})["is_default"].(bool) {
+				defaults = append(defaults, fmt.Sprintf("`%s.%d`", blockName, i))
+			}
+		}
+	}
+
+	switch len(defaults) {
+	case 1:
+		return nil
+	case 0:
+		return fmt.Errorf("one of the `storage_account` or `storage_account_gen2` blocks must have `is_default` set to `true`")
+	default:
+		sort.Strings(defaults)
+		return fmt.Errorf("only one of the `storage_account` or `storage_account_gen2` blocks can have `is_default` set to `true` but got %s", strings.Join(defaults, ", "))
+	}
+}
+
+// hdinsightClusterStorageAccountFirewallDiff ensures the firewall of the default storage account allows the cluster to
+// access it - since otherwise the cluster fails to provision, after an hour or so, which is a common cause of failed
+// creations. The check is skipped when the storage account can't be retrieved, e.g. due to insufficient permissions.
+func hdinsightClusterStorageAccountFirewallDiff(ctx context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" {
+		return nil
+	}
+
+	storageAccountId := hdinsightClusterDefaultStorageAccountId(d.Get("storage_account").([]interface{}), d.Get("storage_account_gen2").([]interface{}))
+	if storageAccountId == "" {
+		return nil
+	}
+
+	id, err := commonids.ParseStorageAccountIDInsensitively(storageAccountId)
+	if err != nil {
+		return nil
+	}
+
+	client := meta.(*clients.Client).Storage.AccountsClient
+	account, err := client.GetProperties(ctx, id.ResourceGroupName, id.StorageAccountName, "")
+	if err != nil {
+		log.Printf("[DEBUG] unable to retrieve %s, skipping validation of the storage account firewall: %+v", id, err)
+		return nil
+	}
+
+	if account.AccountProperties == nil {
+		return nil
+	}
+
+	subnetIds := make([]string, 0)
+	if rolesRaw := d.Get("roles").([]interface{}); len(rolesRaw) > 0 && rolesRaw[0] != nil {
+		roles := rolesRaw[0].(map[string]interface{})
+		for _, role := range hdInsightRolesWithUsernames {
+			if subnetId, ok := hdinsightClusterRolesAsMap(roles[role])["subnet_id"].(string); ok && subnetId != "" {
+				subnetIds = append(subnetIds, subnetId)
+			}
+		}
+	}
+
+	return validateHDInsightStorageAccountNetworkRules(id.StorageAccountName, account.AccountProperties.NetworkRuleSet, subnetIds)
+}
+
+// hdinsightClusterDefaultStorageAccountId returns the ID of the default storage account, when it's known
+func hdinsightClusterDefaultStorageAccountId(storageAccounts []interface{}, gen2StorageAccounts []interface{}) string {
+	for _, raw := range append(storageAccounts, gen2StorageAccounts...) {
+		if raw == nil {
+			continue
+		}
+
+		account := raw.(map[string]interface{})
+		if isDefault, ok := account["is_default"].(bool); ok && isDefault {
+			storageAccountId, _ := account["storage_resource_id"].(string)
+			return storageAccountId
+		}
+	}
+
+	return ""
+}
+
+func validateHDInsightStorageAccountNetworkRules(storageAccountName string, input *storage.NetworkRuleSet, subnetIds []string) error {
+	if input == nil || input.DefaultAction != storage.DefaultActionDeny {
+		return nil
+	}
+
+	for _, v := range strings.Split(string(input.Bypass), ",") {
+		if strings.EqualFold(strings.TrimSpace(v), string(storage.BypassAzureServices)) {
+			return nil
+		}
+	}
+
+	if input.VirtualNetworkRules != nil {
+		for _, rule := range *input.VirtualNetworkRules {
+			if rule.VirtualNetworkResourceID == nil {
+				continue
+			}
+
+			for _, subnetId := range subnetIds {
+				if strings.EqualFold(*rule.VirtualNetworkResourceID, subnetId) {
+					return nil
+				}
+			}
+		}
+	}
+
+	message := fmt.Sprintf("the firewall of the default storage account %q denies access by default, which prevents the HDInsight Cluster from being provisioned - either allow trusted Azure services (the `AzureServices` bypass)", storageAccountName)
+	if len(subnetIds) > 0 {
+		message = fmt.Sprintf("%s, or allow the cluster's subnet %q (which requires the `Microsoft.Storage` service endpoint)", message, subnetIds[0])
+	}
+
+	return fmt.Errorf("%s", message)
+}
+
+// hdInsightEnterpriseSecurityPackageSupportedVersions maps each cluster kind to the cluster versions (`major.minor`)
+// which support the Enterprise Security Package (ESP) - configured via the `security_profile` block.
+// See: https://learn.microsoft.com/azure/hdinsight/domain-joined/hdinsight-security-overview
+var hdInsightEnterpriseSecurityPackageSupportedVersions = map[string][]string{
+	"Hadoop":            {"3.6", "4.0", "5.0", "5.1"},
+	"HBase":             {"4.0", "5.0", "5.1"},
+	"Interactive Query": {"3.6", "4.0", "5.0", "5.1"},
+	"Kafka":             {"4.0", "5.0", "5.1"},
+	"Spark":             {"3.6", "4.0", "5.0", "5.1"},
+}
+
+func hdinsightEnterpriseSecurityPackageSupportedVersions(clusterKind string) []string {
+	for kind, versions := range hdInsightEnterpriseSecurityPackageSupportedVersions {
+		if hdinsightClusterKindEqual(kind, clusterKind) {
+			return versions
+		}
+	}
+
+	return nil
+}
+
+// hdinsightClusterSecurityProfileDiff ensures that the Enterprise Security Package is only configured for combinations
+// of cluster kind, version and tier which support it, since otherwise the cluster fails to provision after an hour or so
+func hdinsightClusterSecurityProfileDiff(clusterKind string) pluginsdk.CustomizeDiffFunc {
+	return func(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+		if len(d.Get("security_profile").([]interface{})) == 0 {
+			return nil
+		}
+
+		if tier := d.Get("tier").(string); tier != "" && !strings.EqualFold(tier, string(hdinsight.TierPremium)) {
+			return fmt.Errorf("`security_profile` (the Enterprise Security Package) requires the `tier` to be %q but got %q", string(hdinsight.TierPremium), tier)
+		}
+
+		clusterVersion := d.Get("cluster_version").(string)
+		if clusterVersion == "" {
+			return nil
+		}
+
+		supportedVersions := hdinsightEnterpriseSecurityPackageSupportedVersions(clusterKind)
+		for _, v := range supportedVersions {
+			if hdinsightClusterVersionDiffSuppressFunc("", v, clusterVersion, nil) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("`security_profile` (the Enterprise Security Package) is not supported for HDInsight %s Clusters with a `cluster_version` of %q - supported versions are: %s", clusterKind, clusterVersion, strings.Join(supportedVersions, ", "))
+	}
+}
+
+// hdInsightKafkaRestProxyEndpointSuffix is appended to the cluster name to build the first label of the Kafka REST proxy
+// endpoint, for example `example-kafkarest.azurehdinsight.net` within Azure Public
+const hdInsightKafkaRestProxyEndpointSuffix = "-kafkarest"
+
+// hdinsightKafkaClusterRestProxyDiff ensures the Kafka REST proxy endpoint derived from the cluster name fits within
+// the 63 character limit of a DNS label, since otherwise the cluster fails during creation
+func hdinsightKafkaClusterRestProxyDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if len(d.Get("rest_proxy").([]interface{})) == 0 {
+		return nil
+	}
+
+	name := d.Get("name").(string)
+	if name == "" {
+		return nil
+	}
+
+	maxLength := 63 - len(hdInsightKafkaRestProxyEndpointSuffix)
+	if len(name) > maxLength {
+		endpoint := name + hdInsightKafkaRestProxyEndpointSuffix
+		if client, ok := meta.(*clients.Client); ok {
+			if suffix, ok := hdinsightEnvironmentEndpointDNSSuffix(client.Account.Environment.Name); ok {
+				endpoint = fmt.Sprintf("%s.%s", endpoint, suffix)
+			}
+		}
+		return fmt.Errorf("`name` must be %d characters or less when `rest_proxy` is specified, since the Kafka REST proxy endpoint %q must fit within a 63 character DNS label - got %d characters", maxLength, endpoint, len(name))
+	}
+
+	return nil
+}
+
+// hdinsightKafkaClusterRestProxyReplacementDiff flags changes to the Kafka REST proxy on an existing cluster - the API
+// only accepts the REST proxy configuration when the cluster is created, so adding, removing or changing the security
+// group replaces the cluster and all of the topics within it, which is easily missed amongst the rest of the plan
+func hdinsightKafkaClusterRestProxyReplacementDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("rest_proxy") {
+		return nil
+	}
+
+	old, new := d.GetChange("rest_proxy")
+	return hdinsightClusterPlanWarnings(meta, hdinsightKafkaClusterRestProxyReplacementWarnings(old.([]interface{}), new.([]interface{}))...)
+}
+
+func hdinsightKafkaClusterRestProxyReplacementWarnings(old, new []interface{}) []string {
+	group := func(input []interface{}) (string, string) {
+		if len(input) == 0 || input[0] == nil {
+			return "", ""
+		}
+		raw := input[0].(map[string]interface{})
+		return raw["security_group_id"].(string), raw["security_group_name"].(string)
+	}
+
+	oldId, oldName := group(old)
+	newId, newName := group(new)
+	// the Object ID is sent to the API lower-cased, so a difference in casing isn't a change
+	if strings.EqualFold(oldId, newId) && oldName == newName {
+		return nil
+	}
+
+	if len(new) == 0 || new[0] == nil {
+		return []string{
+			"removing the `rest_proxy` block replaces the HDInsight Kafka Cluster, deleting all of its topics - since the Kafka REST proxy can't be disabled once the cluster is created",
+		}
+	}
+	if len(old) == 0 || old[0] == nil {
+		return []string{
+			"adding the `rest_proxy` block replaces the HDInsight Kafka Cluster, deleting all of its topics - since the Kafka REST proxy can only be enabled when the cluster is created",
+		}
+	}
+
+	return []string{
+		fmt.Sprintf("changing the security group of the Kafka REST proxy from %q (%q) to %q (%q) replaces the HDInsight Kafka Cluster, deleting all of its topics - since the group can only be specified when the cluster is created. Updating the membership of the existing group instead avoids this.", oldName, oldId, newName, newId),
+	}
+}
+
+// hdinsightKafkaClusterKafkaManagementNodeDiff ensures the `kafka_management_node` - which hosts the Kafka REST proxy -
+// is specified when the `rest_proxy` block is. Specifying it without the `rest_proxy` block has historically been
+// accepted, so this only raises a warning. The two Kafka Management Nodes are always provisioned, so there's no instance
+// count to configure.
+func hdinsightKafkaClusterKafkaManagementNodeDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if features.FourPointOh() {
+		// the Kafka Management Nodes are no longer configurable in 4.0
+		return nil
+	}
+
+	restProxySpecified := len(d.Get("rest_proxy").([]interface{})) > 0
+	managementNodeSpecified := len(d.Get("roles.0.kafka_management_node").([]interface{})) > 0
+
+	if restProxySpecified && !managementNodeSpecified {
+		return fmt.Errorf("`roles.0.kafka_management_node` must be specified when `rest_proxy` is specified, since the Kafka REST proxy runs on the two Kafka Management Nodes")
+	}
+	if managementNodeSpecified && !restProxySpecified {
+		return hdinsightClusterPlanWarnings(meta, "`roles.0.kafka_management_node` is specified without `rest_proxy` - the Kafka Management Nodes are only used to run the Kafka REST proxy")
+	}
+
+	return nil
+}
+
+// hdInsightKafkaDisksPerNodeLimits lists the maximum `number_of_disks_per_node` for the Worker Nodes of an HDInsight
+// Kafka Cluster by cluster version (`major.minor`), in ascending order of cluster version - since the older stacks cap
+// the number of managed disks lower. Cluster versions which aren't listed use the limit from the schema.
+var hdInsightKafkaDisksPerNodeLimits = []struct {
+	clusterVersion  string
+	maxDisksPerNode int
+}{
+	{clusterVersion: "3.6", maxDisksPerNode: 4},
+	{clusterVersion: "4.0", maxDisksPerNode: 8},
+	{clusterVersion: "5.0", maxDisksPerNode: 8},
+	{clusterVersion: "5.1", maxDisksPerNode: 8},
+}
+
+// hdinsightKafkaClusterDisksPerNodeDiff ensures the `number_of_disks_per_node` of the Worker Nodes is supported by the
+// `cluster_version`, since otherwise the cluster fails to provision
+func hdinsightKafkaClusterDisksPerNodeDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	disksPerNode, ok := d.Get("roles.0.worker_node.0.number_of_disks_per_node").(int)
+	if !ok {
+		return nil
+	}
+
+	return validateHDInsightKafkaDisksPerNode(d.Get("cluster_version").(string), disksPerNode)
+}
+
+func validateHDInsightKafkaDisksPerNode(clusterVersion string, disksPerNode int) error {
+	if clusterVersion == "" || disksPerNode == 0 {
+		return nil
+	}
+
+	for i, limit := range hdInsightKafkaDisksPerNodeLimits {
+		if !hdinsightClusterVersionDiffSuppressFunc("", limit.clusterVersion, clusterVersion, nil) {
+			continue
+		}
+
+		if disksPerNode <= limit.maxDisksPerNode {
+			return nil
+		}
+
+		message := fmt.Sprintf("`roles.0.worker_node.0.number_of_disks_per_node` can be at most %d for HDInsight Kafka Clusters with a `cluster_version` of %q - got %d", limit.maxDisksPerNode, clusterVersion, disksPerNode)
+		for _, newer := range hdInsightKafkaDisksPerNodeLimits[i+1:] {
+			if disksPerNode <= newer.maxDisksPerNode {
+				message = fmt.Sprintf("%s. A `cluster_version` of %q or later is required for %d disks per node", message, newer.clusterVersion, disksPerNode)
+				break
+			}
+		}
+
+		return fmt.Errorf("%s", message)
+	}
+
+	return nil
+}
+
+// hdinsightClusterPrivateLinkDiff ensures that Private Link - which removes the public gateway endpoints from the cluster -
+// is only enabled when the resource provider connection is Outbound, since the API otherwise rejects this during creation
+func hdinsightClusterPrivateLinkDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	if !d.Get("network.0.private_link_enabled").(bool) {
+		return nil
+	}
+
+	if connectionDirection := d.Get("network.0.connection_direction").(string); connectionDirection != string(hdinsight.ResourceProviderConnectionOutbound) {
+		return fmt.Errorf("`network.0.connection_direction` must be %q when `network.0.private_link_enabled` is `true` but got %q", string(hdinsight.ResourceProviderConnectionOutbound), connectionDirection)
+	}
+
+	return nil
+}
+
+// hdinsightClusterSecurityProfileNetworkDiff ensures the network configuration of a cluster using the Enterprise Security
+// Package meets the prerequisites for joining the nodes to the Azure AD Domain Services domain - since otherwise the
+// cluster fails to provision after an hour or so with a domain join timeout, which doesn't describe the cause
+func hdinsightClusterSecurityProfileNetworkDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	securityProfile := d.Get("security_profile").([]interface{})
+	if len(securityProfile) == 0 || securityProfile[0] == nil {
+		return nil
+	}
+
+	// existing clusters are only checked when the network settings change, since they've already joined the domain
+	if d.Id() != "" && !d.HasChanges("security_profile", "network", "roles.0.head_node.0.subnet_id", "roles.0.worker_node.0.subnet_id", "roles.0.zookeeper_node.0.subnet_id") {
+		return nil
+	}
+
+	// the subnets are commonly created alongside the cluster, in which case the IDs aren't known until apply
+	for _, role := range []string{"head_node", "worker_node", "zookeeper_node"} {
+		if !d.NewValueKnown(fmt.Sprintf("roles.0.%s.0.subnet_id", role)) {
+			return nil
+		}
+	}
+
+	warnings, err := validateHDInsightSecurityProfileNetwork(securityProfile[0].(map[string]interface{}), d.Get("network").([]interface{}), d.Get("roles").([]interface{}))
+	if err != nil {
+		return err
+	}
+
+	return hdinsightClusterPlanWarnings(meta, warnings...)
+}
+
+func validateHDInsightSecurityProfileNetwork(securityProfile map[string]interface{}, networkRaw []interface{}, rolesRaw []interface{}) ([]string, error) {
+	domainName, _ := securityProfile["domain_name"].(string)
+
+	// the nodes can only be joined to the domain from within a Virtual Network which can reach Azure AD Domain Services
+	roles := hdinsightClusterRolesAsMap(rolesRaw)
+	for _, role := range []string{"head_node", "worker_node", "zookeeper_node"} {
+		node := hdinsightClusterRolesAsMap(roles[role])
+		if len(node) == 0 {
+			continue
+		}
+
+		if subnetId, _ := node["subnet_id"].(string); subnetId == "" {
+			return nil, fmt.Errorf("`roles.0.%s.0.subnet_id` must be specified when `security_profile` (the Enterprise Security Package) is specified, since the nodes are joined to the Azure AD Domain Services domain %q from a Virtual Network which can reach it", role, domainName)
+		}
+	}
+
+	connectionDirection := string(hdinsight.ResourceProviderConnectionInbound)
+	privateLinkEnabled := false
+	if len(networkRaw) > 0 && networkRaw[0] != nil {
+		network := networkRaw[0].(map[string]interface{})
+		if v, ok := network["connection_direction"].(string); ok && v != "" {
+			connectionDirection = v
+		}
+		privateLinkEnabled, _ = network["private_link_enabled"].(bool)
+	}
+
+	warnings := make([]string, 0)
+	if connectionDirection == string(hdinsight.ResourceProviderConnectionOutbound) {
+		warnings = append(warnings, fmt.Sprintf("`network.0.connection_direction` is %q and `security_profile` (the Enterprise Security Package) is specified - the nodes must be able to reach Azure AD Domain Services (LDAPS and Kerberos) and Azure AD over the network from the subnet, and the DNS servers of the Virtual Network must resolve the domain %q, otherwise the cluster fails to provision with a domain join timeout", connectionDirection, domainName))
+	}
+
+	if privateLinkEnabled {
+		warnings = append(warnings, "`network.0.private_link_enabled` is `true` and `security_profile` (the Enterprise Security Package) is specified - since the cluster has no public IP addresses, outbound access to Azure AD must be configured on the subnet (for example using a NAT Gateway or Azure Firewall), otherwise the nodes can't be joined to the domain")
+	}
+
+	return warnings, nil
+}
+
+// hdinsightClusterComponentVersionDiff requires an explicit opt-in before changing the `component_version` of an existing
+// cluster, since the components can't be upgraded in-place - and since the block is ForceNew the cluster (including any
+// data stored on the cluster itself) would be destroyed, which is easy to miss when reviewing the plan
+func hdinsightClusterComponentVersionDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("component_version") {
+		return nil
+	}
+
+	if !meta.(*clients.Client).Features.HDInsight.PreventReplacementOnComponentVersionChange {
+		return nil
+	}
+
+	oldRaw, newRaw := d.GetChange("component_version")
+	changes := hdinsightClusterComponentVersionChanges(oldRaw.([]interface{}), newRaw.([]interface{}))
+
+	return fmt.Errorf("changing %s isn't supported in-place and would replace this HDInsight Cluster, destroying the cluster and any data stored on it (data in the storage accounts is retained). To confirm the cluster should be replaced, set `prevent_replacement_on_component_version_change` to `false` within the `hdinsight` block of the provider `features`", strings.Join(changes, ", "))
+}
+
+// hdinsightClusterComponentVersionChanges returns a description of each component whose version differs
+func hdinsightClusterComponentVersionChanges(oldRaw, newRaw []interface{}) []string {
+	oldVersions := make(map[string]interface{})
+	if len(oldRaw) > 0 && oldRaw[0] != nil {
+		oldVersions = oldRaw[0].(map[string]interface{})
+	}
+	newVersions := make(map[string]interface{})
+	if len(newRaw) > 0 && newRaw[0] != nil {
+		newVersions = newRaw[0].(map[string]interface{})
+	}
+
+	changes := make([]string, 0)
+	for component, v := range newVersions {
+		newVersion, _ := v.(string)
+		oldVersion, _ := oldVersions[component].(string)
+		if oldVersion != newVersion {
+			changes = append(changes, fmt.Sprintf("`component_version.0.%s` from %q to %q", component, oldVersion, newVersion))
+		}
+	}
+	sort.Strings(changes)
+
+	if len(changes) == 0 {
+		changes = append(changes, "`component_version`")
+	}
+
+	return changes
+}
+
+// hdinsightClusterScriptActionUrisDiff optionally checks that the script action URIs can be retrieved at plan time, so
+// that a mistyped URI or an expired SAS token is caught before the cluster is created or scaled out
+func hdinsightClusterScriptActionUrisDiff(ctx context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	if !d.Get("script_action_reachability_check_enabled").(bool) {
+		return nil
+	}
+
+	if d.Id() != "" && !d.HasChange("roles") {
+		return nil
+	}
+
+	for _, uri := range hdinsightClusterScriptActionUris(d.Get("roles").([]interface{})) {
+		if err := checkHDInsightScriptActionUriIsReachable(ctx, uri); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hdinsightClusterScriptActionUris returns the URIs of the script actions defined across all of the roles, including
+// the install and uninstall script actions for each of the edge nodes
+func hdinsightClusterScriptActionUris(input []interface{}) []string {
+	uris := make([]string, 0)
+	if len(input) == 0 || input[0] == nil {
+		return uris
+	}
+
+	roles := input[0].(map[string]interface{})
+	for _, nodesRaw := range roles {
+		nodes, ok := nodesRaw.([]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, nodeRaw := range nodes {
+			node, ok := nodeRaw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, key := range []string{"script_actions", "install_script_action", "uninstall_script_actions"} {
+				actions, ok := node[key].([]interface{})
+				if !ok {
+					continue
+				}
+
+				for _, actionRaw := range actions {
+					action, ok := actionRaw.(map[string]interface{})
+					if !ok {
+						continue
+					}
+
+					// the uri isn't known until apply when it's interpolated from another resource
+					if uri := action["uri"].(string); uri != "" {
+						uris = append(uris, uri)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Strings(uris)
+	return uris
+}
+
+// hdinsightClusterScriptActionStorageAccountsDiff warns when a script action is downloaded from a storage account which
+// isn't attached to the cluster, since the nodes are only granted access to the attached storage accounts
+func hdinsightClusterScriptActionStorageAccountsDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" && !d.HasChanges("roles", "storage_account", "storage_account_gen2") {
+		return nil
+	}
+
+	warnings := hdinsightClusterScriptActionStorageAccountWarnings(d.Get("roles").([]interface{}), d.Get("storage_account").([]interface{}), d.Get("storage_account_gen2").([]interface{}))
+	return hdinsightClusterPlanWarnings(meta, warnings...)
+}
+
+// hdInsightPublicScriptActionsStorageAccountName is the storage account which Microsoft publishes the HDInsight script
+// actions in (for example the Giraph installer), which can be read without being attached to the cluster
+const hdInsightPublicScriptActionsStorageAccountName = "hdiconfigactions"
+
+func hdinsightClusterScriptActionStorageAccountWarnings(roles, storageAccounts, gen2StorageAccounts []interface{}) []string {
+	attached, known := hdinsightClusterAttachedStorageAccountNames(storageAccounts, gen2StorageAccounts)
+	if !known {
+		return nil
+	}
+
+	warnings := make([]string, 0)
+	previous := ""
+	for _, uri := range hdinsightClusterScriptActionUris(roles) {
+		// the same script can be run on several roles
+		if uri == previous {
+			continue
+		}
+		previous = uri
+
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			continue
+		}
+
+		name, ok := validate.HDInsightStorageAccountName(parsed.Host)
+		if !ok || attached[name] || name == hdInsightPublicScriptActionsStorageAccountName {
+			continue
+		}
+
+		// a SAS token grants access to the blob regardless of which storage accounts are attached
+		if parsed.Query().Get("sig") != "" {
+			continue
+		}
+
+		parsed.RawQuery = ""
+		warnings = append(warnings, fmt.Sprintf("the script action URI %q is on the storage account %q, which isn't attached to the cluster using a `storage_account` or `storage_account_gen2` block - unless the blob is public, the nodes may not be able to download the script", parsed.String(), name))
+	}
+
+	return warnings
+}
+
+// hdinsightClusterAttachedStorageAccountNames returns the names of the storage accounts attached to the cluster, and
+// whether all of these are known - they aren't at plan time when interpolated from storage accounts being created
+func hdinsightClusterAttachedStorageAccountNames(storageAccounts, gen2StorageAccounts []interface{}) (map[string]bool, bool) {
+	names := make(map[string]bool)
+	for key, accounts := range map[string][]interface{}{"storage_container_id": storageAccounts, "filesystem_id": gen2StorageAccounts} {
+		for _, raw := range accounts {
+			account, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			found := false
+			if v, ok := account[key].(string); ok && v != "" {
+				if parsed, err := url.Parse(v); err == nil {
+					if name, ok := validate.HDInsightStorageAccountName(parsed.Host); ok {
+						names[name] = true
+						found = true
+					}
+				}
+			}
+			if v, ok := account["storage_resource_id"].(string); ok && v != "" {
+				if id, err := commonids.ParseStorageAccountIDInsensitively(v); err == nil {
+					names[strings.ToLower(id.StorageAccountName)] = true
+					found = true
+				}
+			}
+
+			if !found {
+				return nil, false
+			}
+		}
+	}
+
+	return names, true
+}
+
+func checkHDInsightScriptActionUriIsReachable(ctx context.Context, uri string) error {
+	// the query string can contain a SAS token, which shouldn't be output
+	redacted := uri
+	if parsed, err := url.Parse(uri); err == nil {
+		parsed.RawQuery = ""
+		redacted = parsed.String()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, uri, nil)
+	if err != nil {
+		return fmt.Errorf("building request to check the script action URI %q is reachable: %+v", redacted, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("checking the script action URI %q is reachable - if the script is only reachable from within the cluster's network, set `script_action_reachability_check_enabled` to `false`: %+v", redacted, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("the script action URI %q isn't reachable, received a %d status code - if the script is only reachable from within the cluster's network, set `script_action_reachability_check_enabled` to `false`", redacted, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// hdInsightSupportedLocations caches the locations in which HDInsight Clusters are available by Subscription ID, since
+// this is looked up during every plan which creates a cluster - and the provider can be configured with aliases for
+// different subscriptions, which can have different locations available
+var hdInsightSupportedLocations = make(map[string][]string)
+var hdInsightSupportedLocationsLock = &sync.Mutex{}
+
+// hdinsightClusterLocationDiff ensures that HDInsight Clusters are available in the specified location, since otherwise
+// the API returns a generic error once the cluster is being created
+func hdinsightClusterLocationDiff(ctx context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" {
+		return nil
+	}
+
+	requested := location.Normalize(d.Get("location").(string))
+	if requested == "" {
+		return nil
+	}
+
+	supportedLocations, err := hdinsightClusterSupportedLocations(ctx, meta.(*clients.Client))
+	if err != nil {
+		log.Printf("[DEBUG] unable to retrieve the locations in which HDInsight Clusters are available, skipping validation of `location`: %+v", err)
+		return nil
+	}
+
+	if len(supportedLocations) == 0 {
+		return nil
+	}
+
+	for _, v := range supportedLocations {
+		if v == requested {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("HDInsight Clusters aren't available in the location %q - the nearest supported locations are: %s", requested, strings.Join(hdinsightClusterNearestLocations(requested, supportedLocations), ", "))
+}
+
+func hdinsightClusterSupportedLocations(ctx context.Context, client *clients.Client) ([]string, error) {
+	hdInsightSupportedLocationsLock.Lock()
+	defer hdInsightSupportedLocationsLock.Unlock()
+
+	subscriptionId := client.Account.SubscriptionId
+	if supportedLocations, ok := hdInsightSupportedLocations[subscriptionId]; ok {
+		return supportedLocations, nil
+	}
+
+	id := providers.NewSubscriptionProviderID(subscriptionId, "Microsoft.HDInsight")
+	resp, err := client.Resource.ResourceProvidersClient.Get(ctx, id, providers.DefaultGetOperationOptions())
+	if err != nil {
+		return nil, fmt.Errorf("retrieving %s: %+v", id, err)
+	}
+
+	supportedLocations := make([]string, 0)
+	if model := resp.Model; model != nil && model.ResourceTypes != nil {
+		for _, resourceType := range *model.ResourceTypes {
+			if resourceType.ResourceType == nil || !strings.EqualFold(*resourceType.ResourceType, "clusters") || resourceType.Locations == nil {
+				continue
+			}
+
+			for _, v := range *resourceType.Locations {
+				supportedLocations = append(supportedLocations, location.Normalize(v))
+			}
+		}
+	}
+
+	sort.Strings(supportedLocations)
+	hdInsightSupportedLocations[subscriptionId] = supportedLocations
+
+	return supportedLocations, nil
+}
+
+// hdInsightLocationGeographies are the keywords used within location names to identify the geography they're within,
+// which are used to suggest nearby locations - longer keywords are listed first so that e.g. `australia` matches
+// before `us`
+var hdInsightLocationGeographies = []string{
+	"southafrica",
+	"switzerland",
+	"australia",
+	"germany",
+	"norway",
+	"sweden",
+	"brazil",
+	"canada",
+	"europe",
+	"france",
+	"israel",
+	"mexico",
+	"poland",
+	"qatar",
+	"india",
+	"italy",
+	"japan",
+	"korea",
+	"spain",
+	"asia",
+	"uae",
+	"uk",
+	"us",
+}
+
+// hdinsightClusterNearestLocations returns the supported locations within the same geography as the requested
+// location, falling back to all of the supported locations when none are found
+func hdinsightClusterNearestLocations(requested string, supportedLocations []string) []string {
+	geography := hdinsightLocationGeography(requested)
+	if geography == "" {
+		return supportedLocations
+	}
+
+	nearest := make([]string, 0)
+	for _, v := range supportedLocations {
+		if hdinsightLocationGeography(v) == geography {
+			nearest = append(nearest, v)
+		}
+	}
+
+	if len(nearest) == 0 {
+		return supportedLocations
+	}
+
+	return nearest
+}
+
+func hdinsightLocationGeography(input string) string {
+	for _, geography := range hdInsightLocationGeographies {
+		if strings.Contains(input, geography) {
+			return geography
+		}
+	}
+
+	return ""
+}
+
+// hdinsightClusterScriptActionsPersistedDiff ensures that script actions sharing a name (which identifies a persisted
+// script action) are either all persisted or not, and warns when a script action for an autoscaled Worker Node isn't
+// persisted - since it won't be run on the nodes added when scaling out
+func hdinsightClusterScriptActionsPersistedDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	rolesRaw := d.Get("roles").([]interface{})
+	if len(rolesRaw) == 0 || rolesRaw[0] == nil {
+		return nil
+	}
+	roles := rolesRaw[0].(map[string]interface{})
+
+	persistedByName := make(map[string]bool)
+	warnings := make([]string, 0)
+	for _, role := range hdInsightRolesWithUsernames {
+		nodes, ok := roles[role].([]interface{})
+		if !ok || len(nodes) == 0 || nodes[0] == nil {
+			continue
+		}
+		node := nodes[0].(map[string]interface{})
+
+		scriptActions, ok := node["script_actions"].([]interface{})
+		if !ok {
+			continue
+		}
+
+		autoscaled := false
+		if autoscale, ok := node["autoscale"].([]interface{}); ok && len(autoscale) > 0 {
+			autoscaled = true
+		}
+
+		for _, v := range scriptActions {
+			scriptAction := v.(map[string]interface{})
+			name := scriptAction["name"].(string)
+			persisted := scriptAction["persisted"].(bool)
+
+			if existing, ok := persistedByName[name]; ok && existing != persisted {
+				return fmt.Errorf("the script action %q must either be `persisted` for all roles or none, since persisted script actions are identified by their name", name)
+			}
+			persistedByName[name] = persisted
+
+			if role == "worker_node" && autoscaled && !persisted {
+				warnings = append(warnings, fmt.Sprintf("the script action %q for `roles.0.worker_node` isn't persisted, so won't be run on the Worker Nodes added when autoscaling", name))
+			}
+		}
+	}
+
+	return hdinsightClusterPlanWarnings(meta, warnings...)
+}
+
+// hdinsightClusterAutoscaleScheduleDiff ensures that the schedules within `roles.0.worker_node.0.autoscale.0.recurrence`
+// don't specify the same time more than once for a day - since the API would pick one of the target instance counts
+// arbitrarily - and warns when entries for the same day are close enough together that one is likely a mistake
+func hdinsightClusterAutoscaleScheduleDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	schedules, ok := d.Get("roles.0.worker_node.0.autoscale.0.recurrence.0.schedule").([]interface{})
+	if !ok || len(schedules) == 0 {
+		return nil
+	}
+
+	warnings, err := validateHDInsightAutoscaleSchedules(schedules)
+	if err != nil {
+		return err
+	}
+
+	return hdinsightClusterPlanWarnings(meta, warnings...)
+}
+
+// hdinsightClusterAutoscaleCapacityDiff ensures that the bounds within `roles.0.worker_node.0.autoscale.0.capacity` are
+// consistent with each other and with the `target_instance_count` - since the API only rejects these once the cluster
+// is being provisioned. Once capacity autoscale is enabled the number of nodes is managed by autoscale, so the
+// `target_instance_count` is only checked when creating the cluster or enabling capacity autoscale.
+func hdinsightClusterAutoscaleCapacityDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	capacity, ok := d.Get("roles.0.worker_node.0.autoscale.0.capacity").([]interface{})
+	if !ok || len(capacity) == 0 || capacity[0] == nil {
+		return nil
+	}
+
+	targetInstanceCount := 0
+	oldCapacity, _ := d.GetChange("roles.0.worker_node.0.autoscale.0.capacity")
+	if v, ok := oldCapacity.([]interface{}); d.Id() == "" || !ok || len(v) == 0 {
+		// when omitted the `target_instance_count` is either computed from the capacity or the current number of nodes
+		if workerNode, ok := hdinsightClusterWorkerNodeRawConfig(d.GetRawConfig()); ok && !workerNode.GetAttr("target_instance_count").IsNull() {
+			targetInstanceCount = d.Get("roles.0.worker_node.0.target_instance_count").(int)
+		}
+	}
+
+	return validateHDInsightAutoscaleCapacity(capacity[0].(map[string]interface{}), targetInstanceCount)
+}
+
+// validateHDInsightAutoscaleCapacity validates the bounds of the capacity autoscale, and when `targetInstanceCount` is
+// non-zero that it's within these bounds
+func validateHDInsightAutoscaleCapacity(capacity map[string]interface{}, targetInstanceCount int) error {
+	minInstanceCount, _ := capacity["min_instance_count"].(int)
+	maxInstanceCount, _ := capacity["max_instance_count"].(int)
+
+	if minInstanceCount > maxInstanceCount {
+		return fmt.Errorf("`roles.0.worker_node.0.autoscale.0.capacity.0.min_instance_count` (%d) must be less than or equal to `roles.0.worker_node.0.autoscale.0.capacity.0.max_instance_count` (%d)", minInstanceCount, maxInstanceCount)
+	}
+
+	if targetInstanceCount != 0 && (targetInstanceCount < minInstanceCount || targetInstanceCount > maxInstanceCount) {
+		return fmt.Errorf("`roles.0.worker_node.0.target_instance_count` (%d) must be between `roles.0.worker_node.0.autoscale.0.capacity.0.min_instance_count` (%d) and `roles.0.worker_node.0.autoscale.0.capacity.0.max_instance_count` (%d) when capacity autoscale is enabled", targetInstanceCount, minInstanceCount, maxInstanceCount)
+	}
+
+	return nil
+}
+
+// hdinsightClusterEdgeNodesDiff ensures that each `edge_node` block is deployed as a different Application, since the
+// blocks are matched to the Applications by name - so at most one of them can omit the name (and use the cluster's)
+func hdinsightClusterEdgeNodesDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("name") || !d.NewValueKnown("roles") {
+		return nil
+	}
+
+	return validateHDInsightEdgeNodeNames(d.Get("name").(string), d.Get("roles.0.edge_node").([]interface{}))
+}
+
+func validateHDInsightEdgeNodeNames(clusterName string, edgeNodes []interface{}) error {
+	names := make(map[string]struct{})
+	for _, raw := range edgeNodes {
+		v, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		applicationName := hdinsightEdgeNodeApplicationName(clusterName, v)
+		if _, exists := names[applicationName]; exists {
+			if applicationName == clusterName {
+				return fmt.Errorf("the name of each `edge_node` must be unique but %q is used more than once - an `edge_node` without a `name` uses the name of the cluster", applicationName)
+			}
+			return fmt.Errorf("the name of each `edge_node` must be unique but %q is specified more than once", applicationName)
+		}
+		names[applicationName] = struct{}{}
+	}
+
+	return nil
+}
+
+// hdinsightClusterYarnQueuesDiff ensures the `yarn_queue` blocks describe a valid set of queues beneath the root queue,
+// since the `capacity-scheduler` configuration is only validated by YARN once the cluster has been provisioned - at
+// which point the ResourceManager fails to start
+func hdinsightClusterYarnQueuesDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	if !d.NewValueKnown("yarn_queue") {
+		return nil
+	}
+
+	return validateHDInsightYarnQueues(d.Get("yarn_queue").([]interface{}))
+}
+
+func validateHDInsightYarnQueues(queues []interface{}) error {
+	if len(queues) == 0 {
+		return nil
+	}
+
+	names := make(map[string]struct{})
+	total := 0.0
+	for i, raw := range queues {
+		if raw == nil {
+			continue
+		}
+		v := raw.(map[string]interface{})
+
+		name := v["name"].(string)
+		if _, exists := names[name]; exists {
+			return fmt.Errorf("the name of each `yarn_queue` must be unique but %q is specified more than once", name)
+		}
+		names[name] = struct{}{}
+
+		capacity := v["capacity"].(float64)
+		maxCapacity := v["max_capacity"].(float64)
+		if maxCapacity < capacity {
+			return fmt.Errorf("`yarn_queue.%d.max_capacity` (%g) must be greater than or equal to `yarn_queue.%d.capacity` (%g)", i, maxCapacity, i, capacity)
+		}
+		total += capacity
+	}
+
+	// the capacities are percentages which can have decimals (e.g. 33.3), so allow for floating point rounding
+	if math.Abs(total-100) > 0.001 {
+		return fmt.Errorf("the `capacity` of the `yarn_queue` blocks must add up to 100 but got %g", total)
+	}
+
+	return nil
+}
+
+// hdinsightClusterWorkerTargetInstanceCountDiff ensures the `target_instance_count` of the Worker Nodes is specified
+// when autoscale isn't configured - since this is Optional (and Computed) so that it can be omitted when autoscale
+// manages the number of nodes
+func hdinsightClusterWorkerTargetInstanceCountDiff(_ context.Context, d *pluginsdk.ResourceDiff, _ interface{}) error {
+	workerNode, ok := hdinsightClusterWorkerNodeRawConfig(d.GetRawConfig())
+	if !ok {
+		return nil
+	}
+
+	return validateHDInsightWorkerTargetInstanceCount(workerNode)
+}
+
+func validateHDInsightWorkerTargetInstanceCount(workerNode cty.Value) error {
+	if workerNode.GetAttr("target_instance_count").IsNull() {
+		autoscale := workerNode.GetAttr("autoscale")
+		if !autoscale.IsKnown() {
+			return nil
+		}
+		if autoscale.IsNull() || autoscale.LengthInt() == 0 {
+			return fmt.Errorf("`roles.0.worker_node.0.target_instance_count` must be specified when `roles.0.worker_node.0.autoscale` isn't")
+		}
+	}
+
+	return nil
+}
+
+// hdinsightClusterWorkerNodeRawConfig returns the `roles.0.worker_node` block from the raw configuration, which allows
+// checking whether Optional and Computed attributes have been specified - returning false when it isn't known yet
+func hdinsightClusterWorkerNodeRawConfig(config cty.Value) (cty.Value, bool) {
+	value := config
+	for _, attribute := range []string{"roles", "worker_node"} {
+		if !value.IsKnown() || value.IsNull() || !value.Type().IsObjectType() || !value.Type().HasAttribute(attribute) {
+			return cty.NilVal, false
+		}
+
+		blocks := value.GetAttr(attribute)
+		if !blocks.IsKnown() || blocks.IsNull() || blocks.LengthInt() == 0 {
+			return cty.NilVal, false
+		}
+		value = blocks.AsValueSlice()[0]
+	}
+
+	if !value.IsKnown() || value.IsNull() {
+		return cty.NilVal, false
+	}
+
+	return value, true
+}
+
+// hdinsightClusterWorkerRecommendedMinimumDiff warns when the Worker Nodes are configured (or can be autoscaled) below the
+// number HDInsight recommends for the kind of cluster - e.g. Kafka with fewer than 3 brokers - since this is accepted by
+// the API but is operationally fragile, so is surfaced to reviewers of the plan rather than being rejected
+func hdinsightClusterWorkerRecommendedMinimumDiff(definition HDInsightNodeDefinition) pluginsdk.CustomizeDiffFunc {
+	return func(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+		if definition.RecommendedMinInstanceCount == 0 || !d.NewValueKnown("roles") {
+			return nil
+		}
+
+		// existing clusters are only checked when the number of Worker Nodes changes, so that clusters provisioned
+		// below the recommendation don't raise this on every plan
+		if d.Id() != "" && !d.HasChanges("roles.0.worker_node.0.target_instance_count", "roles.0.worker_node.0.autoscale") {
+			return nil
+		}
+
+		workerNodes := d.Get("roles.0.worker_node").([]interface{})
+		if len(workerNodes) == 0 || workerNodes[0] == nil {
+			return nil
+		}
+
+		warnings := make([]string, 0)
+		for _, warning := range hdinsightClusterWorkerRecommendedMinimumWarnings(definition, workerNodes[0].(map[string]interface{})) {
+			warnings = append(warnings, fmt.Sprintf("HDInsight %s Cluster %q: %s", definition.ClusterKind, d.Get("name").(string), warning))
+		}
+
+		return hdinsightClusterPlanWarnings(meta, warnings...)
+	}
+}
+
+func hdinsightClusterWorkerRecommendedMinimumWarnings(definition HDInsightNodeDefinition, workerNode map[string]interface{}) []string {
+	minimum := definition.RecommendedMinInstanceCount
+	warnings := make([]string, 0)
+	warn := func(key string, count int) {
+		if count > 0 && count < minimum {
+			warnings = append(warnings, fmt.Sprintf("`roles.0.worker_node.0.%s` (%d) is below the %d Worker Nodes recommended for %s Clusters", key, count, minimum, definition.ClusterKind))
+		}
+	}
+
+	autoscale, _ := workerNode["autoscale"].([]interface{})
+	if len(autoscale) == 0 || autoscale[0] == nil {
+		// the `target_instance_count` is managed by autoscale when it's configured
+		count, _ := workerNode["target_instance_count"].(int)
+		warn("target_instance_count", count)
+		return warnings
+	}
+
+	v := autoscale[0].(map[string]interface{})
+	if capacity, _ := v["capacity"].([]interface{}); len(capacity) > 0 && capacity[0] != nil {
+		count, _ := capacity[0].(map[string]interface{})["min_instance_count"].(int)
+		warn("autoscale.0.capacity.0.min_instance_count", count)
+	}
+
+	if recurrence, _ := v["recurrence"].([]interface{}); len(recurrence) > 0 && recurrence[0] != nil {
+		schedules, _ := recurrence[0].(map[string]interface{})["schedule"].([]interface{})
+		for i, raw := range schedules {
+			if schedule, ok := raw.(map[string]interface{}); ok {
+				count, _ := schedule["target_instance_count"].(int)
+				warn(fmt.Sprintf("autoscale.0.recurrence.0.schedule.%d.target_instance_count", i), count)
+			}
+		}
+	}
+
+	return warnings
+}
+
+// hdinsightHBaseClusterWorkerScaleDownDiff warns when the number of worker nodes of an existing HBase cluster is
+// reduced - since the regions (and any region replicas) hosted on the removed nodes are unavailable until HBase has
+// reassigned them, and removing more nodes than the remaining ones can host leaves regions offline.
+func hdinsightHBaseClusterWorkerScaleDownDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("roles.0.worker_node.0.target_instance_count") {
+		return nil
+	}
+
+	oldRaw, newRaw := d.GetChange("roles.0.worker_node.0.target_instance_count")
+	oldCount, newCount := oldRaw.(int), newRaw.(int)
+	if newCount >= oldCount {
+		return nil
+	}
+
+	message := fmt.Sprintf("reducing the number of worker nodes of the HDInsight HBase Cluster %q from %d to %d removes the Region Servers running on those nodes without draining them first - the regions they host are unavailable until HBase has reassigned them, and if the remaining %d worker nodes can't host all of the regions (including any region replicas) data will be unavailable", d.Get("name").(string), oldCount, newCount, newCount)
+	return hdinsightClusterPlanWarnings(meta, message)
+}
+
+type hdinsightAutoscaleScheduleEntry struct {
+	index               int
+	time                string
+	minutes             int
+	targetInstanceCount int
+}
+
+func validateHDInsightAutoscaleSchedules(schedules []interface{}) ([]string, error) {
+	entriesByDay := make(map[string][]hdinsightAutoscaleScheduleEntry)
+	days := make([]string, 0)
+
+	for i, raw := range schedules {
+		if raw == nil {
+			continue
+		}
+		schedule := raw.(map[string]interface{})
+
+		scheduleTime := schedule["time"].(string)
+		parsed, err := time.Parse("15:04", scheduleTime)
+		if err != nil {
+			// the format is validated by the schema, however the value may not be known yet
+			continue
+		}
+
+		entry := hdinsightAutoscaleScheduleEntry{
+			index:               i,
+			time:                scheduleTime,
+			minutes:             parsed.Hour()*60 + parsed.Minute(),
+			targetInstanceCount: schedule["target_instance_count"].(int),
+		}
+
+		for _, v := range schedule["days"].([]interface{}) {
+			day, ok := v.(string)
+			if !ok || day == "" {
+				continue
+			}
+
+			for _, existing := range entriesByDay[day] {
+				if existing.index == i {
+					return nil, fmt.Errorf("`schedule.%d` specifies the day %q more than once", i, day)
+				}
+				if existing.minutes == entry.minutes {
+					return nil, fmt.Errorf("`schedule.%d` and `schedule.%d` both specify %q at %q - each time can only be specified once per day", existing.index, i, day, scheduleTime)
+				}
+			}
+
+			if _, ok := entriesByDay[day]; !ok {
+				days = append(days, day)
+			}
+			entriesByDay[day] = append(entriesByDay[day], entry)
+		}
+	}
+
+	warnings := make([]string, 0)
+	for _, day := range days {
+		entries := entriesByDay[day]
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].minutes < entries[j].minutes
+		})
+
+		for i := 1; i < len(entries); i++ {
+			previous, current := entries[i-1], entries[i]
+			if current.minutes-previous.minutes <= 30 && current.targetInstanceCount != previous.targetInstanceCount {
+				warnings = append(warnings, fmt.Sprintf("`schedule.%d` (%d instances at %q) and `schedule.%d` (%d instances at %q) are within 30 minutes of each other on %q - the cluster may not have finished scaling before the second takes effect", previous.index, previous.targetInstanceCount, previous.time, current.index, current.targetInstanceCount, current.time, day))
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+// hdinsightClusterAutoscaleQuotaDiff warns when scaling the worker nodes out to the autoscale `max_instance_count` needs
+// more cores than are left in the regional HDInsight quota of the subscription - since once the quota is reached the
+// cluster silently stops scaling out. Reading the quota requires permissions on the subscription, so this is skipped
+// when it can't be retrieved.
+func hdinsightClusterAutoscaleQuotaDiff(ctx context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if !d.HasChanges("location", "roles.0.worker_node.0.vm_size", "roles.0.worker_node.0.autoscale") {
+		return nil
+	}
+
+	maxInstanceCount, _ := d.Get("roles.0.worker_node.0.autoscale.0.capacity.0.max_instance_count").(int)
+	if maxInstanceCount == 0 {
+		return nil
+	}
+
+	vmSize := d.Get("roles.0.worker_node.0.vm_size").(string)
+	coresPerNode, ok := hdinsightNodeVMSizeCores(vmSize)
+	if !ok {
+		log.Printf("[DEBUG] the number of cores for the VM Size %q isn't known, skipping the check of the HDInsight core quota", vmSize)
+		return nil
+	}
+
+	loc := location.Normalize(d.Get("location").(string))
+	if loc == "" {
+		return nil
+	}
+
+	client := meta.(*clients.Client).HDInsight.LocationsClient
+	usages, err := client.ListUsages(ctx, loc)
+	if err != nil {
+		log.Printf("[DEBUG] unable to retrieve the HDInsight usages for %q, skipping the check of the HDInsight core quota: %+v", loc, err)
+		return nil
+	}
+
+	available, ok := hdinsightClusterAvailableCores(usages.Value)
+	if !ok {
+		return nil
+	}
+
+	// the worker nodes of an existing cluster already count against the quota, unless the cluster is being replaced
+	if d.Id() != "" && !d.HasChanges("location", "roles.0.worker_node.0.vm_size") {
+		currentCount, _ := d.GetChange("roles.0.worker_node.0.target_instance_count")
+		available += int64(currentCount.(int) * coresPerNode)
+	}
+
+	required := int64(maxInstanceCount * coresPerNode)
+	if required > available {
+		return hdinsightClusterPlanWarnings(meta, fmt.Sprintf("scaling the worker nodes of the HDInsight Cluster %q out to the `max_instance_count` of %d requires %d cores (%d cores for each %q node) but only %d cores are available in the HDInsight quota for %q - the cluster will stop scaling out %d cores short, consider requesting a quota increase", d.Get("name").(string), maxInstanceCount, required, coresPerNode, vmSize, available, loc, required-available))
+	}
+
+	return nil
+}
+
+// hdinsightNodeVMSizeCores returns the number of cores for the specified VM Size, when known
+func hdinsightNodeVMSizeCores(vmSize string) (int, bool) {
+	for k, v := range validate.NodeDefinitionVMSizeCores {
+		if strings.EqualFold(k, vmSize) {
+			return v, true
+		}
+	}
+
+	return 0, false
+}
+
+// hdinsightClusterAvailableCores returns the number of cores left in the regional HDInsight quota, when it's returned
+func hdinsightClusterAvailableCores(usages *[]hdinsight.Usage) (int64, bool) {
+	if usages == nil {
+		return 0, false
+	}
+
+	for _, usage := range *usages {
+		if usage.Name == nil || usage.Name.Value == nil || !strings.EqualFold(*usage.Name.Value, "cores") {
+			continue
+		}
+
+		if usage.Limit == nil || usage.CurrentValue == nil {
+			return 0, false
+		}
+
+		available := *usage.Limit - *usage.CurrentValue
+		if available < 0 {
+			available = 0
+		}
+
+		return available, true
+	}
+
+	return 0, false
+}
+
+// hdInsightNodeDefinitionForceNewAttributes are the attributes of the roles which can't be updated, and so replace the cluster
+// when changed - listed so that the attribute responsible for the replacement can be reported
+var hdInsightNodeDefinitionForceNewAttributes = []string{
+	"vm_size",
+	"username",
+	"password",
+	"password_key_vault_secret_id",
+	"ssh_keys",
+	"subnet_id",
+	"virtual_network_id",
+	"number_of_disks_per_node",
+}
+
+// hdinsightClusterRolesReplacementDiff reports which attributes within the `roles` block are responsible for replacing
+// the cluster - since replacing the cluster takes an hour or so, and the changes within the nested blocks can be subtle
+func hdinsightClusterRolesReplacementDiff(_ context.Context, d *pluginsdk.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("roles") {
+		return nil
+	}
+
+	oldRoles, newRoles := d.GetChange("roles")
+	reasons := hdinsightClusterRolesReplacementReasons(oldRoles.([]interface{}), newRoles.([]interface{}))
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return hdinsightClusterPlanWarnings(meta, fmt.Sprintf("the HDInsight Cluster %q will be replaced since %s", d.Get("name").(string), strings.Join(reasons, ", ")))
+}
+
+func hdinsightClusterRolesReplacementReasons(oldRaw, newRaw []interface{}) []string {
+	oldRoles := hdinsightClusterRolesAsMap(oldRaw)
+	newRoles := hdinsightClusterRolesAsMap(newRaw)
+
+	reasons := make([]string, 0)
+	for _, role := range hdInsightRolesWithUsernames {
+		oldNode := hdinsightClusterRolesAsMap(oldRoles[role])
+		newNode := hdinsightClusterRolesAsMap(newRoles[role])
+		if len(oldNode) == 0 && len(newNode) == 0 {
+			continue
+		}
+
+		if len(oldNode) == 0 || len(newNode) == 0 {
+			reasons = append(reasons, fmt.Sprintf("`roles.0.%s` was added or removed", role))
+			continue
+		}
+
+		for _, attribute := range hdInsightNodeDefinitionForceNewAttributes {
+			oldValue, newValue := oldNode[attribute], newNode[attribute]
+			key := fmt.Sprintf("`roles.0.%s.0.%s`", role, attribute)
+
+			switch attribute {
+			case "password", "ssh_keys":
+				// the values are sensitive so only the attribute is reported
+				if !hdinsightClusterRoleAttributeEqual(oldValue, newValue) {
+					reasons = append(reasons, fmt.Sprintf("%s changed", key))
+				}
+			case "subnet_id", "virtual_network_id":
+				oldId, _ := oldValue.(string)
+				newId, _ := newValue.(string)
+				// the `virtual_network_id` is computed from the `subnet_id` when omitted
+				if attribute == "virtual_network_id" && newId == "" {
+					continue
+				}
+				if !strings.EqualFold(oldId, newId) {
+					reasons = append(reasons, fmt.Sprintf("%s changed from %q to %q", key, oldId, newId))
+				}
+			default:
+				if !hdinsightClusterRoleAttributeEqual(oldValue, newValue) {
+					reasons = append(reasons, fmt.Sprintf("%s changed from %v to %v", key, oldValue, newValue))
+				}
+			}
+		}
+	}
+
+	return reasons
+}
+
+func hdinsightClusterRolesAsMap(input interface{}) map[string]interface{} {
+	raw, ok := input.([]interface{})
+	if !ok || len(raw) == 0 || raw[0] == nil {
+		return map[string]interface{}{}
+	}
+
+	return raw[0].(map[string]interface{})
+}
+
+func hdinsightClusterRoleAttributeEqual(oldValue, newValue interface{}) bool {
+	oldSet, oldIsSet := oldValue.(*pluginsdk.Set)
+	newSet, newIsSet := newValue.(*pluginsdk.Set)
+	if oldIsSet && newIsSet {
+		// the sets are hashed using their normalized values (e.g. `ssh_keys` ignores whitespace)
+		return oldSet.HashEqual(newSet)
+	}
+
+	return oldValue == newValue
+}
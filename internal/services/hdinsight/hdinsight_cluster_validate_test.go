@@ -0,0 +1,1155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2021-09-01/storage"     // nolint: staticcheck
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/features"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+func TestHDInsightClusterPlanWarnings(t *testing.T) {
+	strict := &clients.Client{
+		Features: features.UserFeatures{
+			HDInsight: features.HDInsightFeatures{
+				StrictValidation: true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		meta     interface{}
+		warnings []string
+		err      string
+	}{
+		{
+			name:     "no warnings in strict mode",
+			meta:     strict,
+			warnings: nil,
+		},
+		{
+			name:     "warnings are logged by default",
+			meta:     &clients.Client{Features: features.Default()},
+			warnings: []string{"first", "second"},
+		},
+		{
+			name:     "warnings are logged without a client",
+			meta:     nil,
+			warnings: []string{"first"},
+		},
+		{
+			name:     "warnings are errors in strict mode",
+			meta:     strict,
+			warnings: []string{"first", "second"},
+			err:      "first\nsecond\n\nthis is an error rather than a warning since `strict_validation` is enabled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := hdinsightClusterPlanWarnings(tt.meta, tt.warnings...)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got: %+v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected an error containing %q but got: %+v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterScriptActionUris(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{
+			"head_node": []interface{}{
+				map[string]interface{}{
+					"script_actions": []interface{}{
+						map[string]interface{}{
+							"name": "head",
+							"uri":  "https://example.com/head.sh",
+						},
+					},
+				},
+			},
+			"worker_node": []interface{}{},
+			"edge_node": []interface{}{
+				map[string]interface{}{
+					"install_script_action": []interface{}{
+						map[string]interface{}{
+							"name": "install",
+							"uri":  "https://example.com/install.sh",
+						},
+					},
+					"uninstall_script_actions": []interface{}{
+						map[string]interface{}{
+							"name": "uninstall",
+							"uri":  "",
+						},
+					},
+				},
+				map[string]interface{}{
+					"name": "jobs",
+					"install_script_action": []interface{}{
+						map[string]interface{}{
+							"name": "install",
+							"uri":  "https://example.com/install-jobs.sh",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []string{"https://example.com/head.sh", "https://example.com/install-jobs.sh", "https://example.com/install.sh"}
+	if actual := hdinsightClusterScriptActionUris(input); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+}
+
+func TestHDInsightClusterScriptActionStorageAccountWarnings(t *testing.T) {
+	scriptActions := func(uris ...string) []interface{} {
+		actions := make([]interface{}, 0)
+		for _, uri := range uris {
+			actions = append(actions, map[string]interface{}{"name": "script", "uri": uri})
+		}
+		return []interface{}{
+			map[string]interface{}{
+				"head_node":   []interface{}{map[string]interface{}{"script_actions": actions}},
+				"worker_node": []interface{}{map[string]interface{}{"script_actions": actions}},
+			},
+		}
+	}
+	storageAccounts := []interface{}{
+		map[string]interface{}{
+			"storage_container_id": "https://attached.blob.core.windows.net/data",
+		},
+		map[string]interface{}{
+			"storage_container_id": "",
+			"storage_resource_id":  "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Storage/storageAccounts/Secondary",
+		},
+	}
+	gen2StorageAccounts := []interface{}{
+		map[string]interface{}{
+			"filesystem_id": "https://lake.dfs.core.windows.net/data",
+		},
+	}
+
+	roles := scriptActions(
+		"https://attached.blob.core.windows.net/scripts/install.sh",
+		"https://secondary.blob.core.windows.net/scripts/install.sh",
+		"https://lake.blob.core.windows.net/scripts/install.sh",
+		"https://raw.githubusercontent.com/example/install.sh",
+		"https://hdiconfigactions.blob.core.windows.net/linuxgiraphconfigactionv01/giraph-installer-v01.sh",
+		"https://other.blob.core.windows.net/scripts/with-sas.sh?sv=2021-06-08&sig=secret",
+		"https://other.blob.core.windows.net/scripts/install.sh",
+	)
+	actual := hdinsightClusterScriptActionStorageAccountWarnings(roles, storageAccounts, gen2StorageAccounts)
+	if len(actual) != 1 {
+		t.Fatalf("Expected a single warning (once, despite the script running on two roles) but got %+v", actual)
+	}
+	if !strings.Contains(actual[0], `"https://other.blob.core.windows.net/scripts/install.sh"`) || !strings.Contains(actual[0], `storage account "other"`) {
+		t.Fatalf("Expected the warning to name the URI and the storage account but got %q", actual[0])
+	}
+
+	// the check is skipped when the attached storage accounts aren't known yet
+	unknown := []interface{}{map[string]interface{}{"storage_container_id": "", "storage_resource_id": ""}}
+	if actual := hdinsightClusterScriptActionStorageAccountWarnings(roles, unknown, nil); len(actual) != 0 {
+		t.Fatalf("Expected no warnings when the storage accounts aren't known but got %+v", actual)
+	}
+}
+
+func TestCheckHDInsightScriptActionUriIsReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != "/script.sh" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	if err := checkHDInsightScriptActionUriIsReachable(context.Background(), server.URL+"/script.sh"); err != nil {
+		t.Fatalf("Expected the script to be reachable but got: %+v", err)
+	}
+
+	err := checkHDInsightScriptActionUriIsReachable(context.Background(), server.URL+"/expired.sh?sig=secret")
+	if err == nil {
+		t.Fatalf("Expected the script to be unreachable")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("Expected the query string to be redacted but got: %+v", err)
+	}
+}
+
+func TestHDInsightClusterNearestLocations(t *testing.T) {
+	supported := []string{"australiaeast", "eastus", "northeurope", "westeurope", "westus2"}
+
+	tests := []struct {
+		requested string
+		expected  []string
+	}{
+		{
+			requested: "westus3",
+			expected:  []string{"eastus", "westus2"},
+		},
+		{
+			requested: "australiacentral2",
+			expected:  []string{"australiaeast"},
+		},
+		{
+			requested: "swedencentral",
+			expected:  supported,
+		},
+		{
+			requested: "someplace",
+			expected:  supported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			if actual := hdinsightClusterNearestLocations(tt.requested, supported); !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("Expected %+v but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateHDInsightsStorageAccountDefaults(t *testing.T) {
+	account := func(isDefault bool) interface{} {
+		return map[string]interface{}{
+			"is_default": isDefault,
+		}
+	}
+
+	tests := []struct {
+		name                string
+		storageAccounts     []interface{}
+		gen2StorageAccounts []interface{}
+		valid               bool
+	}{
+		{
+			name:  "no storage accounts",
+			valid: true,
+		},
+		{
+			name:            "single default blob storage account",
+			storageAccounts: []interface{}{account(true)},
+			valid:           true,
+		},
+		{
+			name:                "gen2 default with additional blob storage accounts",
+			storageAccounts:     []interface{}{account(false), account(false)},
+			gen2StorageAccounts: []interface{}{account(true)},
+			valid:               true,
+		},
+		{
+			name:                "no default",
+			storageAccounts:     []interface{}{account(false)},
+			gen2StorageAccounts: []interface{}{account(false)},
+			valid:               false,
+		},
+		{
+			name:            "multiple blob storage defaults",
+			storageAccounts: []interface{}{account(true), account(true)},
+			valid:           false,
+		},
+		{
+			name:                "blob storage and gen2 defaults",
+			storageAccounts:     []interface{}{account(true)},
+			gen2StorageAccounts: []interface{}{account(true)},
+			valid:               false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHDInsightsStorageAccountDefaults(tt.storageAccounts, tt.gen2StorageAccounts)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("Expected %q to be valid %t but got %t (%v)", tt.name, tt.valid, valid, err)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterComponentVersionChanges(t *testing.T) {
+	oldVersions := []interface{}{
+		map[string]interface{}{
+			"spark": "2.4",
+		},
+	}
+	newVersions := []interface{}{
+		map[string]interface{}{
+			"spark": "3.3",
+		},
+	}
+
+	expected := []string{"`component_version.0.spark` from \"2.4\" to \"3.3\""}
+	if actual := hdinsightClusterComponentVersionChanges(oldVersions, newVersions); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	expected = []string{"`component_version`"}
+	if actual := hdinsightClusterComponentVersionChanges(newVersions, newVersions); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+}
+
+func TestValidateHDInsightAutoscaleSchedules(t *testing.T) {
+	schedule := func(scheduleTime string, targetInstanceCount int, days ...interface{}) interface{} {
+		return map[string]interface{}{
+			"time":                  scheduleTime,
+			"target_instance_count": targetInstanceCount,
+			"days":                  days,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		schedules []interface{}
+		valid     bool
+		warnings  int
+	}{
+		{
+			name: "distinct times",
+			schedules: []interface{}{
+				schedule("08:00", 5, "Monday", "Tuesday"),
+				schedule("18:00", 2, "Monday", "Tuesday"),
+			},
+			valid: true,
+		},
+		{
+			name: "same time on different days",
+			schedules: []interface{}{
+				schedule("08:00", 5, "Monday"),
+				schedule("08:00", 3, "Saturday"),
+			},
+			valid: true,
+		},
+		{
+			name: "same time on the same day",
+			schedules: []interface{}{
+				schedule("08:00", 5, "Monday", "Tuesday"),
+				schedule("08:00", 3, "Tuesday"),
+			},
+			valid: false,
+		},
+		{
+			name: "day repeated within a schedule",
+			schedules: []interface{}{
+				schedule("08:00", 5, "Monday", "Monday"),
+			},
+			valid: false,
+		},
+		{
+			name: "within 30 minutes with conflicting counts",
+			schedules: []interface{}{
+				schedule("08:30", 3, "Monday"),
+				schedule("08:00", 5, "Monday"),
+			},
+			valid:    true,
+			warnings: 1,
+		},
+		{
+			name: "within 30 minutes with the same count",
+			schedules: []interface{}{
+				schedule("08:00", 5, "Monday"),
+				schedule("08:30", 5, "Monday"),
+			},
+			valid: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := validateHDInsightAutoscaleSchedules(tt.schedules)
+			if valid := err == nil; valid != tt.valid {
+				t.Fatalf("Expected %q to be valid %t but got %t (%v)", tt.name, tt.valid, valid, err)
+			}
+			if len(warnings) != tt.warnings {
+				t.Fatalf("Expected %d warnings for %q but got %d: %+v", tt.warnings, tt.name, len(warnings), warnings)
+			}
+		})
+	}
+}
+
+func TestHDInsightKafkaClusterRestProxyReplacementWarnings(t *testing.T) {
+	restProxy := func(id, name string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"security_group_id":   id,
+				"security_group_name": name,
+			},
+		}
+	}
+	existing := restProxy("00000000-0000-0000-0000-00000000000a", "kafka-clients")
+
+	if actual := hdinsightKafkaClusterRestProxyReplacementWarnings(existing, restProxy("00000000-0000-0000-0000-00000000000A", "kafka-clients")); len(actual) != 0 {
+		t.Fatalf("Expected no warnings when only the casing of the group ID changes but got %+v", actual)
+	}
+
+	testData := []struct {
+		name string
+		new  []interface{}
+	}{
+		{
+			name: "group rotated",
+			new:  restProxy("11111111-1111-1111-1111-111111111111", "kafka-clients-v2"),
+		},
+		{
+			name: "group renamed",
+			new:  restProxy("00000000-0000-0000-0000-00000000000a", "kafka-producers"),
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q", v.name)
+
+		actual := hdinsightKafkaClusterRestProxyReplacementWarnings(existing, v.new)
+		if len(actual) != 1 || !strings.Contains(actual[0], "deleting all of its topics") {
+			t.Fatalf("Expected a single warning about the cluster being replaced but got %+v", actual)
+		}
+	}
+
+	actual := hdinsightKafkaClusterRestProxyReplacementWarnings(existing, []interface{}{})
+	if len(actual) != 1 || !strings.HasPrefix(actual[0], "removing the `rest_proxy` block replaces the HDInsight Kafka Cluster") {
+		t.Fatalf("Expected a single warning about removing the REST proxy but got %+v", actual)
+	}
+
+	actual = hdinsightKafkaClusterRestProxyReplacementWarnings(nil, existing)
+	if len(actual) != 1 || !strings.HasPrefix(actual[0], "adding the `rest_proxy` block replaces the HDInsight Kafka Cluster") {
+		t.Fatalf("Expected a single warning about adding the REST proxy but got %+v", actual)
+	}
+}
+
+func TestValidateHDInsightKafkaDisksPerNode(t *testing.T) {
+	tests := []struct {
+		clusterVersion string
+		disksPerNode   int
+		valid          bool
+	}{
+		{
+			clusterVersion: "3.6",
+			disksPerNode:   4,
+			valid:          true,
+		},
+		{
+			clusterVersion: "3.6.1000.67",
+			disksPerNode:   8,
+			valid:          false,
+		},
+		{
+			clusterVersion: "4.0",
+			disksPerNode:   8,
+			valid:          true,
+		},
+		{
+			clusterVersion: "5.1",
+			disksPerNode:   3,
+			valid:          true,
+		},
+		{
+			clusterVersion: "6.0",
+			disksPerNode:   8,
+			valid:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.clusterVersion, tt.disksPerNode), func(t *testing.T) {
+			err := validateHDInsightKafkaDisksPerNode(tt.clusterVersion, tt.disksPerNode)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("Expected %d disks for %q to be valid %t but got %t (%v)", tt.disksPerNode, tt.clusterVersion, tt.valid, valid, err)
+			}
+		})
+	}
+
+	err := validateHDInsightKafkaDisksPerNode("3.6", 6)
+	if err == nil || !strings.Contains(err.Error(), "at most 4") || !strings.Contains(err.Error(), `"4.0" or later`) {
+		t.Fatalf("Expected the error to name the maximum and the minimum cluster version but got %v", err)
+	}
+}
+
+func TestHDInsightClusterReusedPasswords(t *testing.T) {
+	block := func(password string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"username": "user",
+				"password": password,
+			},
+		}
+	}
+	roles := []interface{}{
+		map[string]interface{}{
+			"head_node":      block("SshP@ssw0rd"),
+			"worker_node":    block("SshP@ssw0rd"),
+			"zookeeper_node": block("SshP@ssw0rd"),
+		},
+	}
+	metastores := func(password string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"hive":   block(password),
+				"oozie":  block(password),
+				"ambari": []interface{}{},
+			},
+		}
+	}
+
+	if actual := hdinsightClusterReusedPasswords(block("GatewayP@ssw0rd"), roles, metastores("SqlP@ssw0rd")); len(actual) != 0 {
+		t.Fatalf("Expected no collisions but got %+v", actual)
+	}
+
+	expected := []string{
+		"`gateway.0.password` and `roles.0.head_node.0.password` are the same",
+		"`gateway.0.password` and `roles.0.worker_node.0.password` are the same",
+		"`gateway.0.password` and `roles.0.zookeeper_node.0.password` are the same",
+	}
+	if actual := hdinsightClusterReusedPasswords(block("SshP@ssw0rd"), roles, nil); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	expected = []string{
+		"`gateway.0.password` and `metastores.0.hive.0.password` are the same",
+		"`gateway.0.password` and `metastores.0.oozie.0.password` are the same",
+	}
+	actual := hdinsightClusterReusedPasswords(block("GatewayP@ssw0rd"), roles, metastores("GatewayP@ssw0rd"))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+	for _, v := range actual {
+		if strings.Contains(v, "GatewayP@ssw0rd") {
+			t.Fatalf("Expected the password not to be included but got %q", v)
+		}
+	}
+
+	// passwords which aren't known yet (e.g. those retrieved from Key Vault) aren't compared
+	if actual := hdinsightClusterReusedPasswords(block(""), roles, metastores("")); len(actual) != 0 {
+		t.Fatalf("Expected no collisions but got %+v", actual)
+	}
+}
+
+func TestHDInsightClusterRolesReplacementReasons(t *testing.T) {
+	node := func(vmSize, password, subnetId string) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"vm_size":            vmSize,
+				"username":           "sshuser",
+				"password":           password,
+				"ssh_keys":           pluginsdk.NewSet(pluginsdk.HashString, []interface{}{}),
+				"subnet_id":          subnetId,
+				"virtual_network_id": "",
+			},
+		}
+	}
+	roles := func(workerNode []interface{}) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"head_node":   node("Standard_D3_V2", "P@ssw0rd", "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1"),
+				"worker_node": workerNode,
+			},
+		}
+	}
+
+	existing := roles(node("Standard_D4_V2", "P@ssw0rd", ""))
+
+	if actual := hdinsightClusterRolesReplacementReasons(existing, existing); len(actual) != 0 {
+		t.Fatalf("Expected no reasons but got %+v", actual)
+	}
+
+	expected := []string{
+		"`roles.0.worker_node.0.vm_size` changed from Standard_D4_V2 to Standard_D5_V2",
+		"`roles.0.worker_node.0.password` changed",
+	}
+	if actual := hdinsightClusterRolesReplacementReasons(existing, roles(node("Standard_D5_V2", "An0therP@ssw0rd", ""))); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+
+	casing := roles(node("Standard_D4_V2", "P@ssw0rd", ""))
+	casing[0].(map[string]interface{})["head_node"] = node("Standard_D3_V2", "P@ssw0rd", "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/GROUP1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1")
+	if actual := hdinsightClusterRolesReplacementReasons(existing, casing); len(actual) != 0 {
+		t.Fatalf("Expected differences in casing not to be reasons but got %+v", actual)
+	}
+}
+
+func TestValidateHDInsightStorageAccountNetworkRules(t *testing.T) {
+	subnetId := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1"
+
+	tests := []struct {
+		name      string
+		input     *storage.NetworkRuleSet
+		subnetIds []string
+		valid     bool
+	}{
+		{
+			name:  "no network rules",
+			valid: true,
+		},
+		{
+			name: "allow by default",
+			input: &storage.NetworkRuleSet{
+				DefaultAction: storage.DefaultActionAllow,
+			},
+			valid: true,
+		},
+		{
+			name: "deny by default with the azure services bypass",
+			input: &storage.NetworkRuleSet{
+				DefaultAction: storage.DefaultActionDeny,
+				Bypass:        storage.Bypass("Logging, AzureServices"),
+			},
+			valid: true,
+		},
+		{
+			name: "deny by default allowing the cluster subnet",
+			input: &storage.NetworkRuleSet{
+				DefaultAction: storage.DefaultActionDeny,
+				Bypass:        storage.BypassNone,
+				VirtualNetworkRules: &[]storage.VirtualNetworkRule{
+					{
+						VirtualNetworkResourceID: utils.String(strings.ToUpper(subnetId)),
+					},
+				},
+			},
+			subnetIds: []string{subnetId},
+			valid:     true,
+		},
+		{
+			name: "deny by default allowing another subnet",
+			input: &storage.NetworkRuleSet{
+				DefaultAction: storage.DefaultActionDeny,
+				Bypass:        storage.BypassNone,
+				VirtualNetworkRules: &[]storage.VirtualNetworkRule{
+					{
+						VirtualNetworkResourceID: utils.String(subnetId + "-other"),
+					},
+				},
+			},
+			subnetIds: []string{subnetId},
+			valid:     false,
+		},
+		{
+			name: "deny by default without a subnet",
+			input: &storage.NetworkRuleSet{
+				DefaultAction: storage.DefaultActionDeny,
+			},
+			valid: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHDInsightStorageAccountNetworkRules("example", tt.input, tt.subnetIds)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("Expected %q to be valid %t but got %t (%v)", tt.name, tt.valid, valid, err)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterDefaultStorageAccountId(t *testing.T) {
+	account := func(storageAccountId string, isDefault bool) interface{} {
+		return map[string]interface{}{
+			"storage_resource_id": storageAccountId,
+			"is_default":          isDefault,
+		}
+	}
+
+	if actual := hdinsightClusterDefaultStorageAccountId([]interface{}{account("blob", false)}, []interface{}{account("gen2", true)}); actual != "gen2" {
+		t.Fatalf("Expected %q but got %q", "gen2", actual)
+	}
+
+	if actual := hdinsightClusterDefaultStorageAccountId([]interface{}{account("", true)}, nil); actual != "" {
+		t.Fatalf("Expected no storage account ID but got %q", actual)
+	}
+}
+
+func TestHDInsightClusterAvailableCores(t *testing.T) {
+	usage := func(name string, current, limit int64) hdinsight.Usage {
+		return hdinsight.Usage{
+			Name: &hdinsight.LocalizedName{
+				Value: utils.String(name),
+			},
+			CurrentValue: utils.Int64(current),
+			Limit:        utils.Int64(limit),
+		}
+	}
+
+	testData := []struct {
+		name      string
+		input     *[]hdinsight.Usage
+		available int64
+		found     bool
+	}{
+		{
+			name:  "no usages",
+			input: nil,
+			found: false,
+		},
+		{
+			name:  "no cores usage",
+			input: &[]hdinsight.Usage{usage("clusters", 1, 10)},
+			found: false,
+		},
+		{
+			name:      "cores available",
+			input:     &[]hdinsight.Usage{usage("clusters", 1, 10), usage("cores", 40, 100)},
+			available: 60,
+			found:     true,
+		},
+		{
+			name:      "quota exceeded",
+			input:     &[]hdinsight.Usage{usage("Cores", 120, 100)},
+			available: 0,
+			found:     true,
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.name)
+
+		available, found := hdinsightClusterAvailableCores(v.input)
+		if found != v.found || available != v.available {
+			t.Fatalf("Expected %d cores (found %t) but got %d cores (found %t)", v.available, v.found, available, found)
+		}
+	}
+}
+
+func TestHDInsightNodeVMSizeCores(t *testing.T) {
+	if cores, ok := hdinsightNodeVMSizeCores("standard_d4_v2"); !ok || cores != 8 {
+		t.Fatalf("Expected 8 cores for `standard_d4_v2` but got %d (found %t)", cores, ok)
+	}
+
+	if _, ok := hdinsightNodeVMSizeCores("Standard_Unknown"); ok {
+		t.Fatalf("Expected the cores for an unknown VM Size not to be found")
+	}
+}
+
+func TestValidateHDInsightAutoscaleCapacity(t *testing.T) {
+	tests := []struct {
+		name                string
+		min                 int
+		max                 int
+		targetInstanceCount int
+		err                 string
+	}{
+		{
+			name:                "within the bounds",
+			min:                 2,
+			max:                 5,
+			targetInstanceCount: 3,
+		},
+		{
+			name:                "target not checked",
+			min:                 4,
+			max:                 5,
+			targetInstanceCount: 0,
+		},
+		{
+			name:                "below the minimum",
+			min:                 4,
+			max:                 5,
+			targetInstanceCount: 2,
+			err:                 "`roles.0.worker_node.0.target_instance_count` (2) must be between `roles.0.worker_node.0.autoscale.0.capacity.0.min_instance_count` (4)",
+		},
+		{
+			name:                "above the maximum",
+			min:                 1,
+			max:                 3,
+			targetInstanceCount: 4,
+			err:                 "and `roles.0.worker_node.0.autoscale.0.capacity.0.max_instance_count` (3)",
+		},
+		{
+			name: "minimum greater than the maximum",
+			min:  5,
+			max:  3,
+			err:  "`roles.0.worker_node.0.autoscale.0.capacity.0.min_instance_count` (5) must be less than or equal to",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHDInsightAutoscaleCapacity(map[string]interface{}{
+				"min_instance_count": tt.min,
+				"max_instance_count": tt.max,
+			}, tt.targetInstanceCount)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got %+v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected an error containing %q but got %+v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateHDInsightWorkerTargetInstanceCount(t *testing.T) {
+	autoscaleType := cty.List(cty.Object(map[string]cty.Type{
+		"capacity": cty.List(cty.Object(map[string]cty.Type{
+			"min_instance_count": cty.Number,
+			"max_instance_count": cty.Number,
+		})),
+	}))
+	capacity := cty.ListVal([]cty.Value{
+		cty.ObjectVal(map[string]cty.Value{
+			"capacity": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"min_instance_count": cty.NumberIntVal(1),
+					"max_instance_count": cty.NumberIntVal(3),
+				}),
+			}),
+		}),
+	})
+	config := func(targetInstanceCount, autoscale cty.Value) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"roles": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"worker_node": cty.ListVal([]cty.Value{
+						cty.ObjectVal(map[string]cty.Value{
+							"target_instance_count": targetInstanceCount,
+							"autoscale":             autoscale,
+						}),
+					}),
+				}),
+			}),
+		})
+	}
+
+	tests := []struct {
+		name   string
+		config cty.Value
+		err    bool
+	}{
+		{
+			name:   "specified without autoscale",
+			config: config(cty.NumberIntVal(3), cty.ListValEmpty(autoscaleType.ElementType())),
+		},
+		{
+			name:   "specified with autoscale",
+			config: config(cty.NumberIntVal(3), capacity),
+		},
+		{
+			name:   "omitted with autoscale",
+			config: config(cty.NullVal(cty.Number), capacity),
+		},
+		{
+			name:   "omitted with autoscale which isn't known yet",
+			config: config(cty.NullVal(cty.Number), cty.UnknownVal(autoscaleType)),
+		},
+		{
+			name:   "omitted without autoscale",
+			config: config(cty.NullVal(cty.Number), cty.ListValEmpty(autoscaleType.ElementType())),
+			err:    true,
+		},
+		{
+			name:   "omitted without autoscale block",
+			config: config(cty.NullVal(cty.Number), cty.NullVal(autoscaleType)),
+			err:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workerNode, ok := hdinsightClusterWorkerNodeRawConfig(tt.config)
+			if !ok {
+				t.Fatalf("Expected the `worker_node` block to be found")
+			}
+
+			err := validateHDInsightWorkerTargetInstanceCount(workerNode)
+			if tt.err && err == nil {
+				t.Fatalf("Expected an error but didn't get one")
+			}
+			if !tt.err && err != nil {
+				t.Fatalf("Expected no error but got %+v", err)
+			}
+		})
+	}
+
+	if _, ok := hdinsightClusterWorkerNodeRawConfig(cty.UnknownVal(config(cty.NullVal(cty.Number), capacity).Type())); ok {
+		t.Fatalf("Expected the `worker_node` block not to be found when the configuration isn't known")
+	}
+}
+
+func TestValidateHDInsightYarnQueues(t *testing.T) {
+	queue := func(name string, capacity, maxCapacity float64) interface{} {
+		return map[string]interface{}{
+			"name":         name,
+			"capacity":     capacity,
+			"max_capacity": maxCapacity,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		queues []interface{}
+		err    string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:   "single queue",
+			queues: []interface{}{queue("default", 100, 100)},
+		},
+		{
+			name:   "capacities with decimals",
+			queues: []interface{}{queue("default", 33.3, 100), queue("etl", 33.3, 50), queue("adhoc", 33.4, 50)},
+		},
+		{
+			name:   "capacities add up to less than 100",
+			queues: []interface{}{queue("default", 50, 100), queue("etl", 40, 100)},
+			err:    "must add up to 100 but got 90",
+		},
+		{
+			name:   "capacities add up to more than 100",
+			queues: []interface{}{queue("default", 60, 100), queue("etl", 60, 100)},
+			err:    "must add up to 100 but got 120",
+		},
+		{
+			name:   "max capacity below the capacity",
+			queues: []interface{}{queue("default", 70, 100), queue("etl", 30, 20)},
+			err:    "`yarn_queue.1.max_capacity` (20) must be greater than or equal to `yarn_queue.1.capacity` (30)",
+		},
+		{
+			name:   "duplicate names",
+			queues: []interface{}{queue("default", 50, 100), queue("default", 50, 100)},
+			err:    `"default" is specified more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHDInsightYarnQueues(tt.queues)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got %+v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected an error containing %q but got %+v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateHDInsightSecurityProfileNetwork(t *testing.T) {
+	securityProfile := map[string]interface{}{
+		"domain_name": "example.onmicrosoft.com",
+	}
+	roles := func(subnetId string) []interface{} {
+		node := []interface{}{
+			map[string]interface{}{
+				"subnet_id": subnetId,
+			},
+		}
+		return []interface{}{
+			map[string]interface{}{
+				"head_node":      node,
+				"worker_node":    node,
+				"zookeeper_node": node,
+			},
+		}
+	}
+	network := func(connectionDirection string, privateLinkEnabled bool) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"connection_direction": connectionDirection,
+				"private_link_enabled": privateLinkEnabled,
+			},
+		}
+	}
+	subnetId := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1/subnets/subnet1"
+
+	tests := []struct {
+		name     string
+		network  []interface{}
+		roles    []interface{}
+		warnings []string
+		err      string
+	}{
+		{
+			name:  "inbound within a virtual network",
+			roles: roles(subnetId),
+		},
+		{
+			name:  "not within a virtual network",
+			roles: roles(""),
+			err:   "`roles.0.head_node.0.subnet_id` must be specified when `security_profile`",
+		},
+		{
+			name:     "outbound",
+			network:  network("Outbound", false),
+			roles:    roles(subnetId),
+			warnings: []string{"the DNS servers of the Virtual Network must resolve the domain \"example.onmicrosoft.com\""},
+		},
+		{
+			name:     "outbound with private link",
+			network:  network("Outbound", true),
+			roles:    roles(subnetId),
+			warnings: []string{"domain join timeout", "outbound access to Azure AD must be configured on the subnet"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := validateHDInsightSecurityProfileNetwork(securityProfile, tt.network, tt.roles)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected an error containing %q but got %+v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got %+v", err)
+			}
+			if len(warnings) != len(tt.warnings) {
+				t.Fatalf("Expected %d warnings but got %+v", len(tt.warnings), warnings)
+			}
+			for i, expected := range tt.warnings {
+				if !strings.Contains(warnings[i], expected) {
+					t.Fatalf("Expected warning %d to contain %q but got %q", i, expected, warnings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateHDInsightEdgeNodeNames(t *testing.T) {
+	edgeNode := func(name string) interface{} {
+		return map[string]interface{}{
+			"name":                  name,
+			"target_instance_count": 1,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		edgeNodes []interface{}
+		err       string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:      "single unnamed edge node",
+			edgeNodes: []interface{}{edgeNode("")},
+		},
+		{
+			name:      "unnamed and named edge nodes",
+			edgeNodes: []interface{}{edgeNode(""), edgeNode("analysts"), edgeNode("jobs")},
+		},
+		{
+			name:      "duplicate names",
+			edgeNodes: []interface{}{edgeNode("jobs"), edgeNode("jobs")},
+			err:       `"jobs" is specified more than once`,
+		},
+		{
+			name:      "multiple unnamed edge nodes",
+			edgeNodes: []interface{}{edgeNode(""), edgeNode("")},
+			err:       "an `edge_node` without a `name` uses the name of the cluster",
+		},
+		{
+			name:      "named after the cluster alongside an unnamed edge node",
+			edgeNodes: []interface{}{edgeNode("acctesthdi"), edgeNode("")},
+			err:       `"acctesthdi" is used more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHDInsightEdgeNodeNames("acctesthdi", tt.edgeNodes)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got %+v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected an error containing %q but got %+v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestHDInsightClusterWorkerRecommendedMinimumWarnings(t *testing.T) {
+	definition := HDInsightNodeDefinition{
+		ClusterKind:                 "Kafka",
+		RecommendedMinInstanceCount: 3,
+	}
+
+	autoscale := func(key string, value interface{}) []interface{} {
+		return []interface{}{map[string]interface{}{key: value}}
+	}
+
+	tests := []struct {
+		name       string
+		workerNode map[string]interface{}
+		expected   []string
+	}{
+		{
+			name:       "target instance count at the recommendation",
+			workerNode: map[string]interface{}{"target_instance_count": 3},
+			expected:   []string{},
+		},
+		{
+			name:       "target instance count below the recommendation",
+			workerNode: map[string]interface{}{"target_instance_count": 2},
+			expected:   []string{"`roles.0.worker_node.0.target_instance_count` (2) is below the 3 Worker Nodes recommended for Kafka Clusters"},
+		},
+		{
+			name: "autoscale capacity minimum below the recommendation",
+			workerNode: map[string]interface{}{
+				"target_instance_count": 1,
+				"autoscale": autoscale("capacity", []interface{}{map[string]interface{}{
+					"min_instance_count": 1,
+					"max_instance_count": 5,
+				}}),
+			},
+			expected: []string{"`roles.0.worker_node.0.autoscale.0.capacity.0.min_instance_count` (1) is below the 3 Worker Nodes recommended for Kafka Clusters"},
+		},
+		{
+			name: "autoscale schedule below the recommendation",
+			workerNode: map[string]interface{}{
+				"autoscale": autoscale("recurrence", []interface{}{map[string]interface{}{
+					"schedule": []interface{}{
+						map[string]interface{}{"target_instance_count": 5},
+						map[string]interface{}{"target_instance_count": 2},
+					},
+				}}),
+			},
+			expected: []string{"`roles.0.worker_node.0.autoscale.0.recurrence.0.schedule.1.target_instance_count` (2) is below the 3 Worker Nodes recommended for Kafka Clusters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := hdinsightClusterWorkerRecommendedMinimumWarnings(definition, tt.workerNode)
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("Expected the warnings %+v but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
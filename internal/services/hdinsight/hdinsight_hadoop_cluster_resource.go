@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -36,6 +37,7 @@ var hdInsightHadoopClusterHeadNodeDefinition = HDInsightNodeDefinition{
 }
 
 var hdInsightHadoopClusterWorkerNodeDefinition = HDInsightNodeDefinition{
+	ClusterKind:             "Hadoop",
 	CanSpecifyInstanceCount: true,
 	MinInstanceCount:        1,
 	CanSpecifyDisks:         false,
@@ -59,18 +61,41 @@ func resourceHDInsightHadoopCluster() *pluginsdk.Resource {
 		Update: hdinsightClusterUpdate("Hadoop", resourceHDInsightHadoopClusterRead),
 		Delete: hdinsightClusterDelete("Hadoop"),
 
-		Importer: pluginsdk.ImporterValidatingResourceId(func(id string) error {
-			_, err := parse.ClusterID(id)
+		Importer: pluginsdk.ImporterValidatingResourceIdThen(func(id string) error {
+			_, err := parseHDInsightClusterImportId(id, "")
 			return err
-		}),
+		}, importHDInsightCluster),
 
 		Timeouts: &pluginsdk.ResourceTimeout{
 			Create: pluginsdk.DefaultTimeout(60 * time.Minute),
-			Read:   pluginsdk.DefaultTimeout(5 * time.Minute),
+			Read:   pluginsdk.DefaultTimeout(10 * time.Minute),
 			Update: pluginsdk.DefaultTimeout(60 * time.Minute),
 			Delete: pluginsdk.DefaultTimeout(60 * time.Minute),
 		},
 
+		CustomizeDiff: pluginsdk.CustomDiffWithAll(
+			hdinsightClusterGatewayUsernameDiff,
+			hdinsightClusterPasswordReuseDiff,
+			hdinsightClusterNodeTagsDiff,
+			hdinsightClusterRolesReplacementDiff,
+			hdinsightClusterStorageAccountsDiff,
+			hdinsightClusterStorageAccountFirewallDiff,
+			hdinsightClusterPrivateLinkDiff,
+			hdinsightClusterSecurityProfileNetworkDiff,
+			hdinsightClusterScriptActionUrisDiff,
+			hdinsightClusterScriptActionStorageAccountsDiff,
+			hdinsightClusterLocationDiff,
+			hdinsightClusterComponentVersionDiff,
+			hdinsightClusterScriptActionsPersistedDiff,
+			hdinsightClusterAutoscaleScheduleDiff,
+			hdinsightClusterAutoscaleQuotaDiff,
+			hdinsightClusterAutoscaleCapacityDiff,
+			hdinsightClusterWorkerTargetInstanceCountDiff,
+			hdinsightClusterYarnQueuesDiff,
+			hdinsightClusterSecurityProfileDiff("Hadoop"),
+			hdinsightClusterEdgeNodesDiff,
+		),
+
 		Schema: map[string]*pluginsdk.Schema{
 			"name": SchemaHDInsightName(),
 
@@ -84,6 +109,8 @@ func resourceHDInsightHadoopCluster() *pluginsdk.Resource {
 
 			"tls_min_version": SchemaHDInsightTls(),
 
+			"encryption_in_transit_enabled": SchemaHDInsightEncryptionInTransitEnabled(),
+
 			"component_version": {
 				Type:     pluginsdk.TypeList,
 				Required: true,
@@ -91,9 +118,10 @@ func resourceHDInsightHadoopCluster() *pluginsdk.Resource {
 				Elem: &pluginsdk.Resource{
 					Schema: map[string]*pluginsdk.Schema{
 						"hadoop": {
-							Type:     pluginsdk.TypeString,
-							Required: true,
-							ForceNew: true,
+							Type:         pluginsdk.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
 						},
 					},
 				},
@@ -101,12 +129,26 @@ func resourceHDInsightHadoopCluster() *pluginsdk.Resource {
 
 			"disk_encryption": SchemaHDInsightsDiskEncryptionProperties(),
 
+			"customer_managed_key_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Computed: true,
+			},
+
+			"cluster_state": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"unmanaged_persisted_script_actions": SchemaHDInsightUnmanagedPersistedScriptActions(),
+
 			"compute_isolation": SchemaHDInsightsComputeIsolation(),
 
 			"gateway": SchemaHDInsightsGateway(),
 
 			"metastores": SchemaHDInsightsExternalMetastores(),
 
+			"yarn_queue": SchemaHDInsightsYarnQueues(),
+
 			"network": SchemaHDInsightsNetwork(),
 
 			"security_profile": SchemaHDInsightsSecurityProfile(),
@@ -127,71 +169,74 @@ func resourceHDInsightHadoopCluster() *pluginsdk.Resource {
 
 						"zookeeper_node": SchemaHDInsightNodeDefinition("roles.0.zookeeper_node", hdInsightHadoopClusterZookeeperNodeDefinition, true),
 
-						"edge_node": {
-							Type:     pluginsdk.TypeList,
-							Optional: true,
-							MaxItems: 1,
-							Elem: &pluginsdk.Resource{
-								Schema: map[string]*pluginsdk.Schema{
-									"target_instance_count": {
-										Type:         pluginsdk.TypeInt,
-										Required:     true,
-										ValidateFunc: validation.IntBetween(1, 25),
-									},
-
-									"vm_size": {
-										Type:         pluginsdk.TypeString,
-										Required:     true,
-										ValidateFunc: validation.StringInSlice(validate.NodeDefinitionVMSize, false),
-									},
-
-									"install_script_action": {
-										Type:     pluginsdk.TypeList,
-										Required: true,
-										MinItems: 1,
-										Elem: &pluginsdk.Resource{
-											Schema: map[string]*pluginsdk.Schema{
-												"name": {
-													Type:         pluginsdk.TypeString,
-													Required:     true,
-													ValidateFunc: validation.StringIsNotEmpty,
-												},
-												"uri": {
-													Type:         pluginsdk.TypeString,
-													Required:     true,
-													ValidateFunc: validation.StringIsNotEmpty,
-												},
-												"parameters": {
-													Type:         pluginsdk.TypeString,
-													Optional:     true,
-													ValidateFunc: validation.StringIsNotEmpty,
-												},
-											},
-										},
-									},
-
-									"https_endpoints": SchemaHDInsightsHttpsEndpoints(),
-
-									"uninstall_script_actions": SchemaHDInsightsScriptActions(),
-								},
-							},
-						},
+						"edge_node": SchemaHDInsightEdgeNode("Hadoop"),
 					},
 				},
 			},
 
+			"script_action_reachability_check_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"configuration_export_on_destroy_path": SchemaHDInsightConfigurationExportOnDestroyPath(),
+
+			"default_storage_container_created_by_cluster": SchemaHDInsightDefaultStorageContainerCreatedByCluster(),
+
+			"identity": commonschema.UserAssignedIdentityOptionalForceNew(),
+
 			"tags": tags.Schema(),
 
+			"node_tags": tags.Schema(),
+
+			"gateway_authentication_mode": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
 			"https_endpoint": {
 				Type:     pluginsdk.TypeString,
 				Computed: true,
 			},
 
+			"https_url": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"endpoint_dns_label": SchemaHDInsightEndpointDNSLabel(),
+
+			"default_storage_endpoint": SchemaHDInsightDefaultStorageEndpoint(),
+
+			"application_endpoints": {
+				Type:     pluginsdk.TypeMap,
+				Computed: true,
+				Elem: &pluginsdk.Schema{
+					Type: pluginsdk.TypeString,
+				},
+			},
+
 			"ssh_endpoint": {
 				Type:     pluginsdk.TypeString,
 				Computed: true,
 			},
 
+			"private_https_endpoint": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"private_ssh_endpoint": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"current_worker_count": {
+				Type:     pluginsdk.TypeInt,
+				Computed: true,
+			},
+
 			"monitor": SchemaHDInsightsMonitor(),
 
 			"extension": SchemaHDInsightsExtension(),
@@ -211,14 +256,21 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 	id := parse.NewClusterID(subscriptionId, resourceGroup, name)
 	location := azure.NormalizeLocation(d.Get("location").(string))
 	clusterVersion := d.Get("cluster_version").(string)
-	t := d.Get("tags").(map[string]interface{})
-	tier := hdinsight.Tier(d.Get("tier").(string))
+	tier := hdinsight.Tier(normalizeHDInsightTier(d.Get("tier").(string)))
 	tls := d.Get("tls_min_version").(string)
 
 	componentVersionsRaw := d.Get("component_version").([]interface{})
-	componentVersions := expandHDInsightHadoopComponentVersion(componentVersionsRaw)
+	componentVersions, err := expandHDInsightHadoopComponentVersion(componentVersionsRaw)
+	if err != nil {
+		return fmt.Errorf("expanding `component_version`: %+v", err)
+	}
+
+	secretLookup := hdinsightKeyVaultSecretLookup(ctx, meta.(*clients.Client).KeyVault)
 
 	gatewayRaw := d.Get("gateway").([]interface{})
+	if err := expandHDInsightKeyVaultPasswords(gatewayRaw, secretLookup); err != nil {
+		return fmt.Errorf("expanding `gateway`: %+v", err)
+	}
 	configurations := ExpandHDInsightsConfigurations(gatewayRaw)
 
 	metastoresRaw := d.Get("metastores").([]interface{})
@@ -227,6 +279,11 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 		configurations[k] = v
 	}
 
+	yarnQueuesRaw := d.Get("yarn_queue").([]interface{})
+	for k, v := range ExpandHDInsightsYarnQueues(yarnQueuesRaw) {
+		configurations[k] = v
+	}
+
 	networkPropertiesRaw := d.Get("network").([]interface{})
 	networkProperties := ExpandHDInsightsNetwork(networkPropertiesRaw)
 
@@ -240,6 +297,9 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 	}
 
 	rolesRaw := d.Get("roles").([]interface{})
+	if err := expandHDInsightRolesKeyVaultPasswords(rolesRaw, secretLookup); err != nil {
+		return fmt.Errorf("expanding `roles`: %+v", err)
+	}
 	hadoopRoles := hdInsightRoleDefinition{
 		HeadNodeDef:      hdInsightHadoopClusterHeadNodeDefinition,
 		WorkerNodeDef:    hdInsightHadoopClusterWorkerNodeDefinition,
@@ -250,25 +310,24 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 		return fmt.Errorf("expanding `roles`: %+v", err)
 	}
 
-	existing, err := client.Get(ctx, resourceGroup, name)
+	exists, err := hdinsightClusterExists(ctx, client, id)
 	if err != nil {
-		if !utils.ResponseWasNotFound(existing.Response) {
-			return fmt.Errorf("checking for presence of existing HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
-		}
+		return fmt.Errorf("checking for presence of existing HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
 	}
 
-	if !utils.ResponseWasNotFound(existing.Response) {
+	if exists {
 		return tf.ImportAsExistsError("azurerm_hdinsight_hadoop_cluster", id.ID())
 	}
 
 	params := hdinsight.ClusterCreateParametersExtended{
 		Location: utils.String(location),
 		Properties: &hdinsight.ClusterCreateProperties{
-			Tier:                   tier,
-			OsType:                 hdinsight.OSTypeLinux,
-			ClusterVersion:         utils.String(clusterVersion),
-			MinSupportedTLSVersion: utils.String(tls),
-			NetworkProperties:      networkProperties,
+			Tier:                          tier,
+			OsType:                        hdinsight.OSTypeLinux,
+			ClusterVersion:                utils.String(clusterVersion),
+			MinSupportedTLSVersion:        utils.String(tls),
+			EncryptionInTransitProperties: ExpandHDInsightEncryptionInTransitProperties(d.Get("encryption_in_transit_enabled").(bool)),
+			NetworkProperties:             networkProperties,
 			ClusterDefinition: &hdinsight.ClusterDefinition{
 				Kind:             utils.String("Hadoop"),
 				ComponentVersion: componentVersions,
@@ -282,7 +341,7 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 			},
 			ComputeIsolationProperties: computeIsolationProperties,
 		},
-		Tags:     tags.Expand(t),
+		Tags:     expandHDInsightClusterTags(d),
 		Identity: identity,
 	}
 
@@ -292,28 +351,44 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 			return err
 		}
 		params.Properties.DiskEncryptionProperties = diskEncryptionProperties
+
+		if msiResourceId := params.Properties.DiskEncryptionProperties.MsiResourceID; msiResourceId != nil && *msiResourceId != "" {
+			params.Identity = addHDInsightClusterUserAssignedIdentity(params.Identity, *msiResourceId)
+		}
 	}
 
 	if v, ok := d.GetOk("security_profile"); ok {
 		params.Properties.SecurityProfile = ExpandHDInsightSecurityProfile(v.([]interface{}))
 
-		params.Identity = &hdinsight.ClusterIdentity{
-			Type:                   hdinsight.ResourceIdentityTypeUserAssigned,
-			UserAssignedIdentities: make(map[string]*hdinsight.ClusterIdentityUserAssignedIdentitiesValue),
-		}
-
 		if params.Properties.SecurityProfile != nil && params.Properties.SecurityProfile.MsiResourceID != nil {
-			params.Identity.UserAssignedIdentities[*params.Properties.SecurityProfile.MsiResourceID] = &hdinsight.ClusterIdentityUserAssignedIdentitiesValue{}
+			params.Identity = addHDInsightClusterUserAssignedIdentity(params.Identity, *params.Properties.SecurityProfile.MsiResourceID)
 		}
 	}
 
+	if params.Identity, err = expandHDInsightClusterIdentity(d.Get("identity").([]interface{}), params.Identity); err != nil {
+		return fmt.Errorf("expanding `identity`: %+v", err)
+	}
+
+	// checked before the cluster is created, since the cluster creates the container when it doesn't exist
+	defaultStorageContainerCreatedByCluster := hdinsightClusterDefaultStorageContainerCreatedByCluster(ctx, meta, storageAccountsRaw)
+
 	future, err := client.Create(ctx, resourceGroup, name, params)
 	if err != nil {
-		return fmt.Errorf("creating HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return fmt.Errorf("creating HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, hdinsightResourceProviderRegistrationError(id.SubscriptionId, err))
 	}
 
+	// the ID is set before waiting so that should Terraform be interrupted, the cluster (which continues to be provisioned)
+	// remains in the state - meaning the next apply replaces it rather than failing to create it again
+	d.SetId(id.ID())
+	d.Set("default_storage_container_created_by_cluster", defaultStorageContainerCreatedByCluster)
+
 	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
-		return fmt.Errorf("waiting for creation of HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		if hdinsightClusterCreationInterrupted(ctx) {
+			// an error is returned so that the cluster is tainted, since the configuration applied once it's been created wasn't
+			return fmt.Errorf("interrupted whilst waiting for creation of HDInsight Hadoop Cluster %q (Resource Group %q) - the cluster continues to be provisioned, and has been kept in the state to be replaced by the next apply", name, resourceGroup)
+		}
+
+		return fmt.Errorf("waiting for creation of HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, hdinsightClusterScriptActionCreateError(ctx, meta, resourceGroup, name, err))
 	}
 
 	read, err := client.Get(ctx, resourceGroup, name)
@@ -325,32 +400,17 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 		return fmt.Errorf("reading ID for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup)
 	}
 
-	d.SetId(id.ID())
-
-	// We can only add an edge node after creation
-	if v, ok := d.GetOk("roles.0.edge_node"); ok {
-		edgeNodeRaw := v.([]interface{})
-		applicationsClient := meta.(*clients.Client).HDInsight.ApplicationsClient
-		edgeNodeConfig := edgeNodeRaw[0].(map[string]interface{})
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	if err := removeHDInsightNonPersistedScriptActions(ctx, scriptActionsClient, resourceGroup, name, d.Get("roles").([]interface{})); err != nil {
+		return err
+	}
 
-		err := createHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name, edgeNodeConfig)
-		if err != nil {
+	// We can only add the edge nodes after creation
+	for _, v := range d.Get("roles.0.edge_node").([]interface{}) {
+		edgeNodeConfig := v.(map[string]interface{})
+		if err := addHDInsightClusterEdgeNode(ctx, meta, "Hadoop", resourceGroup, name, edgeNodeConfig, d.Timeout(pluginsdk.TimeoutCreate)); err != nil {
 			return err
 		}
-
-		// we can't rely on the use of the Future here due to the node being successfully completed but now the cluster is applying those changes.
-		log.Printf("[DEBUG] Waiting for Hadoop Cluster to %q (Resource Group %q) to finish applying edge node", name, resourceGroup)
-		stateConf := &pluginsdk.StateChangeConf{
-			Pending:    []string{"AzureVMConfiguration", "Accepted", "HdInsightConfiguration"},
-			Target:     []string{"Running"},
-			Refresh:    hdInsightWaitForReadyRefreshFunc(ctx, client, resourceGroup, name),
-			MinTimeout: 15 * time.Second,
-			Timeout:    d.Timeout(pluginsdk.TimeoutCreate),
-		}
-
-		if _, err := stateConf.WaitForStateContext(ctx); err != nil {
-			return fmt.Errorf("waiting for HDInsight Cluster %q (Resource Group %q) to be running: %s", name, resourceGroup, err)
-		}
 	}
 
 	// We can only enable monitoring after creation
@@ -373,8 +433,10 @@ func resourceHDInsightHadoopClusterCreate(d *pluginsdk.ResourceData, meta interf
 
 func resourceHDInsightHadoopClusterRead(d *pluginsdk.ResourceData, meta interface{}) error {
 	clustersClient := meta.(*clients.Client).HDInsight.ClustersClient
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
 	configurationsClient := meta.(*clients.Client).HDInsight.ConfigurationsClient
 	extensionsClient := meta.(*clients.Client).HDInsight.ExtensionsClient
+	virtualMachinesClient := meta.(*clients.Client).HDInsight.VirtualMachinesClient
 	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
 	defer cancel()
 
@@ -394,17 +456,30 @@ func resourceHDInsightHadoopClusterRead(d *pluginsdk.ResourceData, meta interfac
 			return nil
 		}
 
-		return fmt.Errorf("retrieving HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return hdinsightClusterReadError(ctx, fmt.Sprintf("HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
 	}
 
 	// Each call to configurationsClient methods is HTTP request. Getting all settings in one operation
 	configurations, err := configurationsClient.List(ctx, resourceGroup, name)
+	configurationsAvailable := true
 	if err != nil {
-		return fmt.Errorf("retrieving Configuration for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		switch {
+		case hdinsightClusterConfigurationsNotReady(configurations.Response):
+			// the configurations can't be retrieved whilst the cluster is provisioning, so the values in the state are kept until the next refresh
+			log.Printf("[DEBUG] Configuration for HDInsight Hadoop Cluster %q (Resource Group %q) isn't available since the cluster isn't ready - skipping `gateway` and `metastores`: %+v", name, resourceGroup, err)
+
+		case hdinsightClusterConfigurationsUnreachable(ctx, resp.Properties, configurations.Response):
+			// the values in the state are kept, so that the cluster can still be planned from outside of the private network
+			log.Printf("[WARN] Configuration for HDInsight Hadoop Cluster %q (Resource Group %q) couldn't be retrieved since the cluster is only reachable over Private Link - keeping the existing `gateway` and `metastores`, changes to these won't be detected until the cluster is refreshed from a network with private connectivity: %+v", name, resourceGroup, err)
+
+		default:
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("Configuration for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
+		}
+		configurationsAvailable = false
 	}
 
 	gateway, exists := configurations.Configurations["gateway"]
-	if !exists {
+	if configurationsAvailable && !exists {
 		return fmt.Errorf("retrieving gateway for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
 	}
 
@@ -416,27 +491,31 @@ func resourceHDInsightHadoopClusterRead(d *pluginsdk.ResourceData, meta interfac
 
 	// storage_account isn't returned so I guess we just leave it ¯\_(ツ)_/¯
 	if props := resp.Properties; props != nil {
-		tier := ""
-		// the Azure API is inconsistent here, so rewrite this into the casing we expect
-		for _, v := range hdinsight.PossibleTierValues() {
-			if strings.EqualFold(string(v), string(props.Tier)) {
-				tier = string(v)
-			}
-		}
-		d.Set("tier", tier)
+		d.Set("tier", normalizeHDInsightTier(string(props.Tier)))
 		d.Set("cluster_version", props.ClusterVersion)
 		d.Set("tls_min_version", props.MinSupportedTLSVersion)
+		d.Set("encryption_in_transit_enabled", FlattenHDInsightEncryptionInTransitProperties(props.EncryptionInTransitProperties))
+		d.Set("customer_managed_key_enabled", FlattenHDInsightCustomerManagedKeyEnabled(props.DiskEncryptionProperties))
+		d.Set("cluster_state", utils.NormalizeNilableString(props.ClusterState))
 
 		if def := props.ClusterDefinition; def != nil {
 			if err := d.Set("component_version", flattenHDInsightHadoopComponentVersion(def.ComponentVersion)); err != nil {
 				return fmt.Errorf("flattening `component_version`: %+v", err)
 			}
 
-			if err := d.Set("gateway", FlattenHDInsightsConfigurations(gateway, d)); err != nil {
-				return fmt.Errorf("flattening `gateway`: %+v", err)
-			}
+			if configurationsAvailable {
+				if err := d.Set("gateway", FlattenHDInsightsConfigurations(gateway, d)); err != nil {
+					return fmt.Errorf("flattening `gateway`: %+v", err)
+				}
 
-			flattenHDInsightsMetastores(d, configurations.Configurations)
+				flattenHDInsightsMetastores(d, configurations.Configurations)
+
+				if capacityScheduler, ok := configurations.Configurations["capacity-scheduler"]; ok {
+					if err := d.Set("yarn_queue", FlattenHDInsightsYarnQueues(capacityScheduler)); err != nil {
+						return fmt.Errorf("flattening `yarn_queue`: %+v", err)
+					}
+				}
+			}
 
 			if props.NetworkProperties != nil {
 				if err := d.Set("network", FlattenHDInsightsNetwork(props.NetworkProperties)); err != nil {
@@ -450,20 +529,27 @@ func resourceHDInsightHadoopClusterRead(d *pluginsdk.ResourceData, meta interfac
 			WorkerNodeDef:    hdInsightHadoopClusterWorkerNodeDefinition,
 			ZookeeperNodeDef: hdInsightHadoopClusterZookeeperNodeDefinition,
 		}
-		flattenedRoles := flattenHDInsightRoles(d, props.ComputeProfile, hadoopRoles)
 
-		applicationsClient := meta.(*clients.Client).HDInsight.ApplicationsClient
-
-		edgeNode, err := applicationsClient.Get(ctx, resourceGroup, name, name)
+		persistedScriptActions, scriptActionsAvailable, err := listHDInsightPersistedScriptActions(ctx, scriptActionsClient, resourceGroup, name)
 		if err != nil {
-			if !utils.ResponseWasNotFound(edgeNode.Response) {
-				return fmt.Errorf("reading edge node for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("persisted Script Actions for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
+		}
+
+		flattenedRoles := flattenHDInsightRoles(d, props.ComputeProfile, hadoopRoles)
+		if scriptActionsAvailable {
+			unmanaged := applyHDInsightPersistedScriptActions(flattenedRoles, persistedScriptActions)
+			if err := d.Set("unmanaged_persisted_script_actions", unmanaged); err != nil {
+				return fmt.Errorf("setting `unmanaged_persisted_script_actions`: %+v", err)
 			}
 		}
 
-		if edgeNodeProps := edgeNode.Properties; edgeNodeProps != nil {
-			flattenedRoles = flattenHDInsightEdgeNode(flattenedRoles, edgeNodeProps)
+		applicationsClient := meta.(*clients.Client).HDInsight.ApplicationsClient
+
+		edgeNodes, err := listHDInsightEdgeNodes(ctx, applicationsClient, resourceGroup, name)
+		if err != nil {
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("edge nodes for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
 		}
+		flattenedRoles = flattenHDInsightEdgeNodes(flattenedRoles, name, d.Get("roles.0.edge_node").([]interface{}), edgeNodes)
 
 		if props.DiskEncryptionProperties != nil {
 			diskEncryptionProps, err := FlattenHDInsightsDiskEncryptionProperties(*props.DiskEncryptionProperties)
@@ -487,44 +573,133 @@ func resourceHDInsightHadoopClusterRead(d *pluginsdk.ResourceData, meta interfac
 
 		httpEndpoint := FindHDInsightConnectivityEndpoint("HTTPS", props.ConnectivityEndpoints)
 		d.Set("https_endpoint", httpEndpoint)
+		d.Set("https_url", hdinsightClusterHttpsUrl(httpEndpoint))
+		d.Set("endpoint_dns_label", hdinsightClusterEndpointDNSLabel(name, httpEndpoint))
+		d.Set("default_storage_endpoint", hdinsightClusterDefaultStorageEndpoint(d.Get("storage_account").([]interface{}), d.Get("storage_account_gen2").([]interface{}), configurations.Configurations["core-site"]))
+		d.Set("application_endpoints", flattenHDInsightClusterApplicationEndpoints("hadoop", httpEndpoint))
 		sshEndpoint := FindHDInsightConnectivityEndpoint("SSH", props.ConnectivityEndpoints)
 		d.Set("ssh_endpoint", sshEndpoint)
+		privateHttpEndpoint := FindHDInsightConnectivityEndpoint("HTTPS-INTERNAL", props.ConnectivityEndpoints)
+		d.Set("private_https_endpoint", privateHttpEndpoint)
+		privateSshEndpoint := FindHDInsightConnectivityEndpoint("SSH-INTERNAL", props.ConnectivityEndpoints)
+		d.Set("private_ssh_endpoint", privateSshEndpoint)
+
+		currentWorkerCount, err := hdinsightClusterCurrentWorkerCount(ctx, virtualMachinesClient, resourceGroup, name, props.ComputeProfile)
+		if err != nil {
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("hosts for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
+		}
+		d.Set("current_worker_count", currentWorkerCount)
 
 		monitor, err := extensionsClient.GetMonitoringStatus(ctx, resourceGroup, name)
 		if err != nil {
-			return fmt.Errorf("reading monitor configuration for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("monitor configuration for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
 		}
 
 		d.Set("monitor", flattenHDInsightMonitoring(monitor))
 
 		extension, err := extensionsClient.GetAzureMonitorStatus(ctx, resourceGroup, name)
 		if err != nil {
-			return fmt.Errorf("reading extension configuration for HDInsight Hadoop Cluster %q (Resource Group %q) %+v", name, resourceGroup, err)
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("extension configuration for HDInsight Hadoop Cluster %q (Resource Group %q)", name, resourceGroup), err)
 		}
 
-		d.Set("extension", flattenHDInsightAzureMonitor(extension))
+		d.Set("extension", flattenHDInsightAzureMonitor(extension, d.Get("extension").([]interface{})))
 
 		if err := d.Set("security_profile", flattenHDInsightSecurityProfile(props.SecurityProfile, d)); err != nil {
 			return fmt.Errorf("setting `security_profile`: %+v", err)
 		}
+
+		d.Set("gateway_authentication_mode", hdinsightClusterGatewayAuthenticationMode(props.SecurityProfile, d.Get("gateway.0.enabled").(bool)))
+	}
+
+	// this is only used at plan time, so isn't returned from the API
+	d.Set("script_action_reachability_check_enabled", d.Get("script_action_reachability_check_enabled").(bool))
+	// only known when the cluster is created by Terraform
+	d.Set("default_storage_container_created_by_cluster", d.Get("default_storage_container_created_by_cluster").(bool))
+
+	flattenedIdentity, err := flattenHDInsightClusterIdentity(d, resp.Identity)
+	if err != nil {
+		return fmt.Errorf("flattening `identity`: %+v", err)
+	}
+	if err := d.Set("identity", flattenedIdentity); err != nil {
+		return fmt.Errorf("setting `identity`: %+v", err)
 	}
 
-	return tags.FlattenAndSet(d, resp.Tags)
+	return flattenHDInsightClusterTags(d, resp.Tags)
 }
 
-func flattenHDInsightEdgeNode(roles []interface{}, props *hdinsight.ApplicationProperties) []interface{} {
-	if len(roles) == 0 || props == nil {
+// flattenHDInsightEdgeNodes sets the `edge_node` blocks of the roles from the Applications deployed for the edge nodes,
+// in the order of the existing blocks so that the Applications being listed in a different order isn't drift
+func flattenHDInsightEdgeNodes(roles []interface{}, clusterName string, existing []interface{}, applications []hdinsight.Application) []interface{} {
+	if len(roles) == 0 {
 		return roles
 	}
 
+	existingNames := make(map[string]string)
+	existingPositions := make(map[string]int)
+	for i, raw := range existing {
+		if v, ok := raw.(map[string]interface{}); ok {
+			applicationName := hdinsightEdgeNodeApplicationName(clusterName, v)
+			existingNames[applicationName], _ = v["name"].(string)
+			existingPositions[applicationName] = i
+		}
+	}
+
+	applicationNames := make([]string, 0)
+	edgeNodes := make(map[string]map[string]interface{})
+	for _, application := range applications {
+		if application.Name == nil || application.Properties == nil {
+			continue
+		}
+
+		applicationName := *application.Name
+		// the name can be prefixed with the name of the cluster
+		if i := strings.LastIndex(applicationName, "/"); i != -1 {
+			applicationName = applicationName[i+1:]
+		}
+
+		edgeNode := flattenHDInsightEdgeNode(application.Properties)
+		if name, ok := existingNames[applicationName]; ok {
+			edgeNode["name"] = name
+		} else if applicationName != clusterName {
+			edgeNode["name"] = applicationName
+		} else {
+			edgeNode["name"] = ""
+		}
+
+		applicationNames = append(applicationNames, applicationName)
+		edgeNodes[applicationName] = edgeNode
+	}
+
+	sort.SliceStable(applicationNames, func(i, j int) bool {
+		first, firstExists := existingPositions[applicationNames[i]]
+		second, secondExists := existingPositions[applicationNames[j]]
+		if firstExists && secondExists {
+			return first < second
+		}
+		if firstExists != secondExists {
+			return firstExists
+		}
+		return applicationNames[i] < applicationNames[j]
+	})
+
+	result := make([]interface{}, 0)
+	for _, applicationName := range applicationNames {
+		result = append(result, edgeNodes[applicationName])
+	}
+
 	role := roles[0].(map[string]interface{})
+	role["edge_node"] = result
 
+	return []interface{}{role}
+}
+
+func flattenHDInsightEdgeNode(props *hdinsight.ApplicationProperties) map[string]interface{} {
 	edgeNode := make(map[string]interface{})
 	if computeProfile := props.ComputeProfile; computeProfile != nil {
 		if roles := computeProfile.Roles; roles != nil {
 			for _, role := range *roles {
 				if targetInstanceCount := role.TargetInstanceCount; targetInstanceCount != nil {
-					edgeNode["target_instance_count"] = targetInstanceCount
+					edgeNode["target_instance_count"] = int(*targetInstanceCount)
 				}
 				if hardwareProfile := role.HardwareProfile; hardwareProfile != nil && hardwareProfile.VMSize != nil {
 					vmSize := ""
@@ -540,49 +715,38 @@ func flattenHDInsightEdgeNode(roles []interface{}, props *hdinsight.ApplicationP
 		}
 	}
 
-	actions := make(map[string]interface{})
-	if installScriptActions := props.InstallScriptActions; installScriptActions != nil {
-		for _, action := range *installScriptActions {
-			actions["name"] = action.Name
-			actions["uri"] = action.URI
-			actions["parameters"] = action.Parameters
-		}
+	if uninstallScriptActions := flattenHDInsightApplicationEdgeNodeScriptActions(props.UninstallScriptActions); len(uninstallScriptActions) != 0 {
+		edgeNode["uninstall_script_actions"] = uninstallScriptActions
 	}
 
-	if uninstallScriptActions := props.UninstallScriptActions; uninstallScriptActions != nil && len(*uninstallScriptActions) != 0 {
-		uninstallActions := make(map[string]interface{})
-		for _, uninstallAction := range *uninstallScriptActions {
-			actions["name"] = uninstallAction.Name
-			actions["uri"] = uninstallAction.URI
-			actions["parameters"] = uninstallAction.Parameters
-		}
-		edgeNode["uninstall_script_actions"] = []interface{}{uninstallActions}
-	}
+	edgeNode["https_endpoints"] = flattenHDInsightApplicationEdgeNodeHttpsEndpoints(props.HTTPSEndpoints)
 
-	if HTTPSEndpoints := props.HTTPSEndpoints; HTTPSEndpoints != nil && len(*HTTPSEndpoints) != 0 {
-		httpsEndpoints := make(map[string]interface{})
-		for _, HTTPSEndpoint := range *HTTPSEndpoints {
-			httpsEndpoints["access_modes"] = HTTPSEndpoint.AccessModes
-			httpsEndpoints["destination_port"] = HTTPSEndpoint.DestinationPort
-			httpsEndpoints["disable_gateway_auth"] = HTTPSEndpoint.DisableGatewayAuth
-			httpsEndpoints["private_ip_address"] = HTTPSEndpoint.PrivateIPAddress
-			httpsEndpoints["sub_domain_suffix"] = HTTPSEndpoint.SubDomainSuffix
-		}
-		edgeNode["https_endpoints"] = []interface{}{httpsEndpoints}
-	}
+	edgeNode["install_script_action"] = flattenHDInsightApplicationEdgeNodeScriptActions(props.InstallScriptActions)
 
-	edgeNode["install_script_action"] = []interface{}{actions}
+	return edgeNode
+}
 
-	role["edge_node"] = []interface{}{edgeNode}
+// flattenHDInsightApplicationEdgeNodeScriptActions flattens the install or uninstall script actions of an edge node,
+// keeping them in the order they're run in
+func flattenHDInsightApplicationEdgeNodeScriptActions(input *[]hdinsight.RuntimeScriptAction) []interface{} {
+	actions := make([]interface{}, 0)
+	if input == nil {
+		return actions
+	}
 
-	return []interface{}{role}
+	for _, action := range *input {
+		actions = append(actions, map[string]interface{}{
+			"name":       utils.NormalizeNilableString(action.Name),
+			"uri":        utils.NormalizeNilableString(action.URI),
+			"parameters": utils.NormalizeNilableString(action.Parameters),
+		})
+	}
+
+	return actions
 }
 
-func expandHDInsightHadoopComponentVersion(input []interface{}) map[string]*string {
-	vs := input[0].(map[string]interface{})
-	return map[string]*string{
-		"Hadoop": utils.String(vs["hadoop"].(string)),
-	}
+func expandHDInsightHadoopComponentVersion(input []interface{}) (map[string]*string, error) {
+	return expandHDInsightComponentVersion(input, "Hadoop", "hadoop", "Hadoop")
 }
 
 func flattenHDInsightHadoopComponentVersion(input map[string]*string) []interface{} {
@@ -632,18 +796,19 @@ func expandHDInsightApplicationEdgeNodeHttpsEndpoints(input []interface{}) *[]hd
 	for _, v := range input {
 		val := v.(map[string]interface{})
 
-		accessModes := val["access_modes"].([]string)
-		destinationPort := val["destination_port"].(int32)
-		disableGatewayAuth := val["disable_gateway_auth"].(bool)
-		privateIpAddress := val["private_ip_address"].(string)
-		subDomainSuffix := val["sub_domain_suffix"].(string)
-
 		endPoint := hdinsight.ApplicationGetHTTPSEndpoint{
-			AccessModes:        &accessModes,
-			DestinationPort:    utils.Int32(destinationPort),
-			PrivateIPAddress:   utils.String(privateIpAddress),
-			SubDomainSuffix:    utils.String(subDomainSuffix),
-			DisableGatewayAuth: utils.Bool(disableGatewayAuth),
+			AccessModes:        utils.ExpandStringSlice(val["access_modes"].([]interface{})),
+			DestinationPort:    utils.Int32(int32(val["destination_port"].(int))),
+			DisableGatewayAuth: utils.Bool(val["disable_gateway_auth"].(bool)),
+		}
+
+		// the private IP address is assigned by HDInsight when this isn't specified
+		if privateIpAddress := val["private_ip_address"].(string); privateIpAddress != "" {
+			endPoint.PrivateIPAddress = utils.String(privateIpAddress)
+		}
+
+		if subDomainSuffix := val["sub_domain_suffix"].(string); subDomainSuffix != "" {
+			endPoint.SubDomainSuffix = utils.String(subDomainSuffix)
 		}
 
 		endpoints = append(endpoints, endPoint)
@@ -652,6 +817,36 @@ func expandHDInsightApplicationEdgeNodeHttpsEndpoints(input []interface{}) *[]hd
 	return &endpoints
 }
 
+func flattenHDInsightApplicationEdgeNodeHttpsEndpoints(input *[]hdinsight.ApplicationGetHTTPSEndpoint) []interface{} {
+	endpoints := make([]interface{}, 0)
+	if input == nil {
+		return endpoints
+	}
+
+	for _, endpoint := range *input {
+		destinationPort := 0
+		if endpoint.DestinationPort != nil {
+			destinationPort = int(*endpoint.DestinationPort)
+		}
+
+		disableGatewayAuth := false
+		if endpoint.DisableGatewayAuth != nil {
+			disableGatewayAuth = *endpoint.DisableGatewayAuth
+		}
+
+		// the `Location` and `PublicPort` of the endpoint are generated by HDInsight, so aren't part of the block
+		endpoints = append(endpoints, map[string]interface{}{
+			"access_modes":         utils.FlattenStringSlice(endpoint.AccessModes),
+			"destination_port":     destinationPort,
+			"disable_gateway_auth": disableGatewayAuth,
+			"private_ip_address":   utils.NormalizeNilableString(endpoint.PrivateIPAddress),
+			"sub_domain_suffix":    utils.NormalizeNilableString(endpoint.SubDomainSuffix),
+		})
+	}
+
+	return endpoints
+}
+
 func expandHDInsightApplicationEdgeNodeUninstallScriptActions(input []interface{}) *[]hdinsight.RuntimeScriptAction {
 	actions := make([]hdinsight.RuntimeScriptAction, 0)
 	if len(input) == 0 || input[0] == nil {
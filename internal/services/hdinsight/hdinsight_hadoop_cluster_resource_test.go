@@ -6,6 +6,7 @@ package hdinsight_test
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
@@ -28,6 +29,31 @@ func TestAccHDInsightHadoopCluster_basic(t *testing.T) {
 				check.That(data.ResourceName).ExistsInAzure(r),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("customer_managed_key_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("cluster_state").HasValue("Running"),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("basic"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHadoopCluster_encryptionInTransitEnabled(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.encryptionInTransitEnabled(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("true"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -54,7 +80,6 @@ func TestAccHDInsightHadoopCluster_roleScriptActions(t *testing.T) {
 		},
 		data.ImportStep("roles.0.head_node.0.password",
 			"roles.0.head_node.0.vm_size",
-			"roles.0.head_node.0.script_actions",
 			"roles.0.worker_node.0.password",
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
@@ -161,6 +186,34 @@ func TestAccHDInsightHadoopCluster_virtualNetwork(t *testing.T) {
 	})
 }
 
+func TestAccHDInsightHadoopCluster_virtualNetworkSubnetOnly(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.virtualNetworkSubnetOnly(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.head_node.0.virtual_network_id").Exists(),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.virtual_network_id").Exists(),
+				check.That(data.ResourceName).Key("roles.0.zookeeper_node.0.virtual_network_id").Exists(),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		{
+			// switching to specifying the Virtual Network explicitly shouldn't recreate the cluster
+			Config:   r.virtualNetwork(data),
+			PlanOnly: true,
+		},
+	})
+}
+
 func TestAccHDInsightHadoopCluster_complete(t *testing.T) {
 	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
 	r := HDInsightHadoopClusterResource{}
@@ -207,6 +260,229 @@ func TestAccHDInsightHadoopCluster_edgeNodeBasic(t *testing.T) {
 	})
 }
 
+func TestAccHDInsightHadoopCluster_edgeNodeResize(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.edgeNodeBasic(data, 1, "Standard_D3_V2"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.target_instance_count").HasValue("1"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.password",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.edgeNodeBasic(data, 2, "Standard_D3_V2"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.target_instance_count").HasValue("2"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.password",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.edgeNodeBasic(data, 1, "Standard_D3_V2"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.target_instance_count").HasValue("1"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.password",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHadoopCluster_multipleEdgeNodes(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.multipleEdgeNodes(data, true),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.#").HasValue("2"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"roles.0.edge_node.1.vm_size",
+			"storage_account"),
+		{
+			Config: r.multipleEdgeNodes(data, false),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.#").HasValue("1"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"roles.0.edge_node.1.vm_size",
+			"storage_account"),
+		{
+			Config: r.multipleEdgeNodes(data, true),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.#").HasValue("2"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"roles.0.edge_node.1.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHadoopCluster_edgeNodeHttpsEndpoints(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.edgeNodeHttpsEndpoints(data, 8888),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.https_endpoints.0.destination_port").HasValue("8888"),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.https_endpoints.0.sub_domain_suffix").HasValue("jup"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.edgeNodeHttpsEndpoints(data, 8889),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.https_endpoints.0.destination_port").HasValue("8889"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHadoopCluster_edgeNodeUninstallScriptActions(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.edgeNodeUninstallScriptActions(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.uninstall_script_actions.#").HasValue("2"),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.uninstall_script_actions.1.parameters").HasValue("--cleanup"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+		{
+			// removing the edge node deletes its Application, which runs the uninstall script actions
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.#").HasValue("0"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHadoopCluster_updateEdgeNodeInstallScriptAction(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.edgeNodeInstallScriptAction(data, "1"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.install_script_action.0.uri").HasValue("https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh?version=1"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.edgeNodeInstallScriptAction(data, "2"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.edge_node.0.install_script_action.0.uri").HasValue("https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh?version=2"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"roles.0.edge_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
 func TestAccHDInsightHadoopCluster_addEdgeNodeBasic(t *testing.T) {
 	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
 	r := HDInsightHadoopClusterResource{}
@@ -281,7 +557,8 @@ func TestAccHDInsightHadoopCluster_gen2storage(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"identity"),
 	})
 }
 
@@ -357,6 +634,7 @@ func TestAccHDInsightHadoopCluster_diskEncryption(t *testing.T) {
 			Config: r.diskEncryption(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("customer_managed_key_enabled").HasValue("false"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -513,7 +791,8 @@ func TestAccHDInsightHadoopCluster_azureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 	})
 }
 
@@ -535,7 +814,8 @@ func TestAccHDInsightHadoopCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.azureMonitor(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -550,7 +830,8 @@ func TestAccHDInsightHadoopCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			PreConfig: func() {
 				data.RandomString += "new"
@@ -568,7 +849,8 @@ func TestAccHDInsightHadoopCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.basic(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -583,7 +865,8 @@ func TestAccHDInsightHadoopCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 	})
 }
 
@@ -624,30 +907,79 @@ func TestAccHDInsightHadoopCluster_updateGateway(t *testing.T) {
 	})
 }
 
-func TestAccHDInsightHadoopCluster_updateMonitor(t *testing.T) {
+func TestAccHDInsightHadoopCluster_updateGatewayUsername(t *testing.T) {
 	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
 	r := HDInsightHadoopClusterResource{}
 	data.ResourceTest(t, r, []acceptance.TestStep{
-		// No monitor
 		{
 			Config: r.basic(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
-				check.That(data.ResourceName).Key("https_endpoint").Exists(),
-				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
 			),
 		},
-		data.ImportStep("roles.0.head_node.0.password",
-			"roles.0.head_node.0.vm_size",
-			"roles.0.worker_node.0.password",
-			"roles.0.worker_node.0.vm_size",
-			"roles.0.zookeeper_node.0.password",
-			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
-		// Add monitor
 		{
-			Config: r.monitor(data),
-			Check: acceptance.ComposeTestCheckFunc(
+			Config:      r.updateGatewayUsername(data),
+			ExpectError: regexp.MustCompile("`gateway.0.username` can't be changed"),
+		},
+	})
+}
+
+func TestAccHDInsightHadoopCluster_passwordsFromKeyVault(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.passwordsFromKeyVault(data, "gateway"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("gateway.0.password").IsEmpty(),
+				check.That(data.ResourceName).Key("roles.0.head_node.0.password").IsEmpty(),
+			),
+		},
+		data.ImportStep("gateway.0.password",
+			"gateway.0.password_key_vault_secret_id",
+			"roles.0.head_node.0.password_key_vault_secret_id",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password_key_vault_secret_id",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password_key_vault_secret_id",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		{
+			// rotating the gateway password is an update of the cluster
+			Config: r.passwordsFromKeyVault(data, "gateway_rotated"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("gateway.0.password").IsEmpty(),
+			),
+		},
+	})
+}
+
+func TestAccHDInsightHadoopCluster_updateMonitor(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		// No monitor
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("https_endpoint").Exists(),
+				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		// Add monitor
+		{
+			Config: r.monitor(data),
+			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
@@ -721,6 +1053,7 @@ func TestAccAzureRMHDInsightHadoopCluster_autoscaleWithSchedule(t *testing.T) {
 			Config: r.basic(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.#").HasValue("0"),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
 			),
@@ -735,6 +1068,43 @@ func TestAccAzureRMHDInsightHadoopCluster_autoscaleWithSchedule(t *testing.T) {
 	})
 }
 
+func TestAccHDInsightHadoopCluster_updateAutoscaleSchedule(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
+	r := HDInsightHadoopClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.autoscale_schedule(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		{
+			// changes the time of the first schedule, and replaces the second schedule with a new one
+			Config: r.autoscaleScheduleUpdated(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.0.recurrence.0.schedule.#").HasValue("2"),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.0.recurrence.0.schedule.0.time").HasValue("22:00"),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.0.recurrence.0.schedule.1.days.0").HasValue("Friday"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
 func TestAccAzureRMHDInsightHadoopCluster_autoscaleWithCapacity(t *testing.T) {
 	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hadoop_cluster", "test")
 	r := HDInsightHadoopClusterResource{}
@@ -781,6 +1151,7 @@ func testAccHDInsightHadoopCluster_securityProfile(t *testing.T) {
 			Config: r.securityProfile(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("aad"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -862,6 +1233,58 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
 `, r.template(data), data.RandomInteger)
 }
 
+func (r HDInsightHadoopClusterResource) encryptionInTransitEnabled(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  encryption_in_transit_enabled = true
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
 func (r HDInsightHadoopClusterResource) roleScriptActions(data acceptance.TestData) string {
 	return fmt.Sprintf(`
 %s
@@ -1101,31 +1524,362 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
     }
   }
 
-  tags = {
-    Hello = "World"
+  tags = {
+    Hello = "World"
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightHadoopClusterResource) virtualNetwork(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_virtual_network" "test" {
+  name                = "acctestvirtnet%d"
+  address_space       = ["10.0.0.0/16"]
+  location            = azurerm_resource_group.test.location
+  resource_group_name = azurerm_resource_group.test.name
+}
+
+resource "azurerm_subnet" "test" {
+  name                 = "acctestsubnet%d"
+  resource_group_name  = azurerm_resource_group.test.name
+  virtual_network_name = azurerm_virtual_network.test.name
+  address_prefixes     = ["10.0.2.0/24"]
+}
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size            = "Standard_D3_V2"
+      username           = "acctestusrvm"
+      password           = "AccTestvdSC4daf986!"
+      subnet_id          = azurerm_subnet.test.id
+      virtual_network_id = azurerm_virtual_network.test.id
+    }
+
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 3
+      subnet_id             = azurerm_subnet.test.id
+      virtual_network_id    = azurerm_virtual_network.test.id
+    }
+
+    zookeeper_node {
+      vm_size            = "Standard_D3_V2"
+      username           = "acctestusrvm"
+      password           = "AccTestvdSC4daf986!"
+      subnet_id          = azurerm_subnet.test.id
+      virtual_network_id = azurerm_virtual_network.test.id
+    }
+  }
+}
+`, r.template(data), data.RandomInteger, data.RandomInteger, data.RandomInteger)
+}
+
+func (r HDInsightHadoopClusterResource) virtualNetworkSubnetOnly(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_virtual_network" "test" {
+  name                = "acctestvirtnet%d"
+  address_space       = ["10.0.0.0/16"]
+  location            = azurerm_resource_group.test.location
+  resource_group_name = azurerm_resource_group.test.name
+}
+
+resource "azurerm_subnet" "test" {
+  name                 = "acctestsubnet%d"
+  resource_group_name  = azurerm_resource_group.test.name
+  virtual_network_name = azurerm_virtual_network.test.name
+  address_prefixes     = ["10.0.2.0/24"]
+}
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size   = "Standard_D3_V2"
+      username  = "acctestusrvm"
+      password  = "AccTestvdSC4daf986!"
+      subnet_id = azurerm_subnet.test.id
+    }
+
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 3
+      subnet_id             = azurerm_subnet.test.id
+    }
+
+    zookeeper_node {
+      vm_size   = "Standard_D3_V2"
+      username  = "acctestusrvm"
+      password  = "AccTestvdSC4daf986!"
+      subnet_id = azurerm_subnet.test.id
+    }
+  }
+}
+`, r.template(data), data.RandomInteger, data.RandomInteger, data.RandomInteger)
+}
+
+func (r HDInsightHadoopClusterResource) complete(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_virtual_network" "test" {
+  name                = "acctestvirtnet%d"
+  address_space       = ["10.0.0.0/16"]
+  location            = azurerm_resource_group.test.location
+  resource_group_name = azurerm_resource_group.test.name
+}
+
+resource "azurerm_subnet" "test" {
+  name                 = "acctestsubnet%d"
+  resource_group_name  = azurerm_resource_group.test.name
+  virtual_network_name = azurerm_virtual_network.test.name
+  address_prefixes     = ["10.0.2.0/24"]
+}
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_resource_id  = azurerm_storage_account.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  network {
+    connection_direction = "Outbound"
+  }
+
+  roles {
+    head_node {
+      vm_size            = "Standard_D3_V2"
+      username           = "acctestusrvm"
+      password           = "AccTestvdSC4daf986!"
+      subnet_id          = azurerm_subnet.test.id
+      virtual_network_id = azurerm_virtual_network.test.id
+    }
+
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 3
+      subnet_id             = azurerm_subnet.test.id
+      virtual_network_id    = azurerm_virtual_network.test.id
+    }
+
+    zookeeper_node {
+      vm_size            = "Standard_D3_V2"
+      username           = "acctestusrvm"
+      password           = "AccTestvdSC4daf986!"
+      subnet_id          = azurerm_subnet.test.id
+      virtual_network_id = azurerm_virtual_network.test.id
+    }
+  }
+
+  tags = {
+    Hello = "World"
+  }
+}
+`, r.template(data), data.RandomInteger, data.RandomInteger, data.RandomInteger)
+}
+
+func (r HDInsightHadoopClusterResource) edgeNodeBasic(data acceptance.TestData, numEdgeNodes int, instanceType string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    edge_node {
+      target_instance_count = %d
+      vm_size               = "%s"
+      install_script_action {
+        name = "script1"
+        uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+      }
+    }
+  }
+}
+`, r.template(data), data.RandomInteger, numEdgeNodes, instanceType)
+}
+
+func (r HDInsightHadoopClusterResource) edgeNodeUninstallScriptActions(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    edge_node {
+      target_instance_count = 1
+      vm_size               = "Standard_D3_V2"
+      install_script_action {
+        name = "script1"
+        uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+      }
+
+      uninstall_script_actions {
+        name = "deregister"
+        uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+      }
+
+      uninstall_script_actions {
+        name       = "cleanup"
+        uri        = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+        parameters = "--cleanup"
+      }
+    }
   }
 }
 `, r.template(data), data.RandomInteger)
 }
 
-func (r HDInsightHadoopClusterResource) virtualNetwork(data acceptance.TestData) string {
+func (r HDInsightHadoopClusterResource) edgeNodeInstallScriptAction(data acceptance.TestData, version string) string {
 	return fmt.Sprintf(`
 %s
 
-resource "azurerm_virtual_network" "test" {
-  name                = "acctestvirtnet%d"
-  address_space       = ["10.0.0.0/16"]
-  location            = azurerm_resource_group.test.location
-  resource_group_name = azurerm_resource_group.test.name
-}
-
-resource "azurerm_subnet" "test" {
-  name                 = "acctestsubnet%d"
-  resource_group_name  = azurerm_resource_group.test.name
-  virtual_network_name = azurerm_virtual_network.test.name
-  address_prefixes     = ["10.0.2.0/24"]
-}
-
 resource "azurerm_hdinsight_hadoop_cluster" "test" {
   name                = "acctesthdi-%d"
   resource_group_name = azurerm_resource_group.test.name
@@ -1150,52 +1904,41 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
 
   roles {
     head_node {
-      vm_size            = "Standard_D3_V2"
-      username           = "acctestusrvm"
-      password           = "AccTestvdSC4daf986!"
-      subnet_id          = azurerm_subnet.test.id
-      virtual_network_id = azurerm_virtual_network.test.id
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
     }
 
     worker_node {
       vm_size               = "Standard_D4_V2"
       username              = "acctestusrvm"
       password              = "AccTestvdSC4daf986!"
-      target_instance_count = 3
-      subnet_id             = azurerm_subnet.test.id
-      virtual_network_id    = azurerm_virtual_network.test.id
+      target_instance_count = 2
     }
 
     zookeeper_node {
-      vm_size            = "Standard_D3_V2"
-      username           = "acctestusrvm"
-      password           = "AccTestvdSC4daf986!"
-      subnet_id          = azurerm_subnet.test.id
-      virtual_network_id = azurerm_virtual_network.test.id
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    edge_node {
+      target_instance_count = 1
+      vm_size               = "Standard_D3_V2"
+      install_script_action {
+        name = "script1"
+        uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh?version=%s"
+      }
     }
   }
 }
-`, r.template(data), data.RandomInteger, data.RandomInteger, data.RandomInteger)
+`, r.template(data), data.RandomInteger, version)
 }
 
-func (r HDInsightHadoopClusterResource) complete(data acceptance.TestData) string {
+func (r HDInsightHadoopClusterResource) edgeNodeHttpsEndpoints(data acceptance.TestData, destinationPort int) string {
 	return fmt.Sprintf(`
 %s
 
-resource "azurerm_virtual_network" "test" {
-  name                = "acctestvirtnet%d"
-  address_space       = ["10.0.0.0/16"]
-  location            = azurerm_resource_group.test.location
-  resource_group_name = azurerm_resource_group.test.name
-}
-
-resource "azurerm_subnet" "test" {
-  name                 = "acctestsubnet%d"
-  resource_group_name  = azurerm_resource_group.test.name
-  virtual_network_name = azurerm_virtual_network.test.name
-  address_prefixes     = ["10.0.2.0/24"]
-}
-
 resource "azurerm_hdinsight_hadoop_cluster" "test" {
   name                = "acctesthdi-%d"
   resource_group_name = azurerm_resource_group.test.name
@@ -1214,50 +1957,51 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
 
   storage_account {
     storage_container_id = azurerm_storage_container.test.id
-    storage_resource_id  = azurerm_storage_account.test.id
     storage_account_key  = azurerm_storage_account.test.primary_access_key
     is_default           = true
   }
 
-  network {
-    connection_direction = "Outbound"
-  }
-
   roles {
     head_node {
-      vm_size            = "Standard_D3_V2"
-      username           = "acctestusrvm"
-      password           = "AccTestvdSC4daf986!"
-      subnet_id          = azurerm_subnet.test.id
-      virtual_network_id = azurerm_virtual_network.test.id
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
     }
 
     worker_node {
       vm_size               = "Standard_D4_V2"
       username              = "acctestusrvm"
       password              = "AccTestvdSC4daf986!"
-      target_instance_count = 3
-      subnet_id             = azurerm_subnet.test.id
-      virtual_network_id    = azurerm_virtual_network.test.id
+      target_instance_count = 2
     }
 
     zookeeper_node {
-      vm_size            = "Standard_D3_V2"
-      username           = "acctestusrvm"
-      password           = "AccTestvdSC4daf986!"
-      subnet_id          = azurerm_subnet.test.id
-      virtual_network_id = azurerm_virtual_network.test.id
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
     }
-  }
 
-  tags = {
-    Hello = "World"
+    edge_node {
+      target_instance_count = 1
+      vm_size               = "Standard_D3_V2"
+      install_script_action {
+        name = "script1"
+        uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+      }
+
+      https_endpoints {
+        access_modes         = ["WebPage"]
+        destination_port     = %d
+        disable_gateway_auth = false
+        sub_domain_suffix    = "jup"
+      }
+    }
   }
 }
-`, r.template(data), data.RandomInteger, data.RandomInteger, data.RandomInteger)
+`, r.template(data), data.RandomInteger, destinationPort)
 }
 
-func (r HDInsightHadoopClusterResource) edgeNodeBasic(data acceptance.TestData, numEdgeNodes int, instanceType string) string {
+func (r HDInsightHadoopClusterResource) multipleEdgeNodes(data acceptance.TestData, includeJobs bool) string {
 	return fmt.Sprintf(`
 %s
 
@@ -1304,16 +2048,30 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
     }
 
     edge_node {
-      target_instance_count = %d
-      vm_size               = "%s"
+      name                  = "analysts"
+      target_instance_count = 1
+      vm_size               = "Standard_D3_V2"
       install_script_action {
         name = "script1"
         uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
       }
     }
+
+    dynamic "edge_node" {
+      for_each = %t ? [1] : []
+      content {
+        name                  = "jobs"
+        target_instance_count = 2
+        vm_size               = "Standard_D4_V2"
+        install_script_action {
+          name = "script1"
+          uri  = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+        }
+      }
+    }
   }
 }
-`, r.template(data), data.RandomInteger, numEdgeNodes, instanceType)
+`, r.template(data), data.RandomInteger, includeJobs)
 }
 
 func (r HDInsightHadoopClusterResource) gen2storage(data acceptance.TestData) string {
@@ -1928,7 +2686,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -2033,7 +2791,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -2267,6 +3025,140 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
 `, r.template(data), data.RandomInteger)
 }
 
+func (r HDInsightHadoopClusterResource) updateGatewayUsername(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+  component_version {
+    hadoop = "3.1"
+  }
+  gateway {
+    username = "acctestusrgw2"
+    password = "TerrAform123!"
+  }
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+    }
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightHadoopClusterResource) passwordsFromKeyVault(data acceptance.TestData, gatewaySecret string) string {
+	return fmt.Sprintf(`
+%[1]s
+
+data "azurerm_client_config" "current" {}
+
+resource "azurerm_key_vault" "test" {
+  name                       = "acctestkv-%[3]s"
+  location                   = azurerm_resource_group.test.location
+  resource_group_name        = azurerm_resource_group.test.name
+  tenant_id                  = data.azurerm_client_config.current.tenant_id
+  sku_name                   = "standard"
+  soft_delete_retention_days = 7
+
+  access_policy {
+    tenant_id = data.azurerm_client_config.current.tenant_id
+    object_id = data.azurerm_client_config.current.object_id
+
+    secret_permissions = [
+      "Delete",
+      "Get",
+      "Purge",
+      "Set",
+    ]
+  }
+}
+
+resource "azurerm_key_vault_secret" "gateway" {
+  name         = "gateway"
+  value        = "TerrAform123!"
+  key_vault_id = azurerm_key_vault.test.id
+}
+
+resource "azurerm_key_vault_secret" "gateway_rotated" {
+  name         = "gateway-rotated"
+  value        = "TerrAformne3!"
+  key_vault_id = azurerm_key_vault.test.id
+}
+
+resource "azurerm_key_vault_secret" "nodes" {
+  name         = "nodes"
+  value        = "AccTestvdSC4daf986!"
+  key_vault_id = azurerm_key_vault.test.id
+}
+
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%[2]d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hadoop = "3.1"
+  }
+
+  gateway {
+    username                     = "acctestusrgw"
+    password_key_vault_secret_id = azurerm_key_vault_secret.%[4]s.id
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size                      = "Standard_D3_V2"
+      username                     = "acctestusrvm"
+      password_key_vault_secret_id = azurerm_key_vault_secret.nodes.id
+    }
+
+    worker_node {
+      vm_size                      = "Standard_D4_V2"
+      username                     = "acctestusrvm"
+      password_key_vault_secret_id = azurerm_key_vault_secret.nodes.id
+      target_instance_count        = 2
+    }
+
+    zookeeper_node {
+      vm_size                      = "Standard_D3_V2"
+      username                     = "acctestusrvm"
+      password_key_vault_secret_id = azurerm_key_vault_secret.nodes.id
+    }
+  }
+}
+`, r.template(data), data.RandomInteger, data.RandomString, gatewaySecret)
+}
+
 func (r HDInsightHadoopClusterResource) autoscale_capacity(data acceptance.TestData) string {
 	return fmt.Sprintf(`
 %s
@@ -2374,6 +3266,64 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
 `, r.template(data), data.RandomInteger)
 }
 
+func (r HDInsightHadoopClusterResource) autoscaleScheduleUpdated(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+resource "azurerm_hdinsight_hadoop_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+  component_version {
+    hadoop = "3.1"
+  }
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+    worker_node {
+      vm_size               = "Standard_D4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+      autoscale {
+        recurrence {
+          timezone = "Pacific Standard Time"
+          schedule {
+            days                  = ["Monday"]
+            time                  = "22:00"
+            target_instance_count = 5
+          }
+          schedule {
+            days                  = ["Friday"]
+            time                  = "19:00"
+            target_instance_count = 3
+          }
+        }
+      }
+    }
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
 func (r HDInsightHadoopClusterResource) securityProfile(data acceptance.TestData) string {
 	return fmt.Sprintf(`
 %[1]s
@@ -2390,7 +3340,7 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
   }
 
   gateway {
-    username = "sshuser"
+    username = "acctestusrgw"
     password = "TerrAform123!"
   }
 
@@ -2464,7 +3414,7 @@ resource "azurerm_hdinsight_hadoop_cluster" "test" {
   }
 
   gateway {
-    username = "sshuser"
+    username = "acctestusrgw"
     password = "TerrAform123!"
   }
 
@@ -6,6 +6,7 @@ package hdinsight_test
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
@@ -28,6 +29,29 @@ func TestAccHDInsightHBaseCluster_basic(t *testing.T) {
 				check.That(data.ResourceName).ExistsInAzure(r),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("basic"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHBaseCluster_encryptionInTransitEnabled(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hbase_cluster", "test")
+	r := HDInsightHBaseClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.encryptionInTransitEnabled(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("true"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -54,7 +78,6 @@ func TestAccHDInsightHBaseCluster_roleScriptActions(t *testing.T) {
 		},
 		data.ImportStep("roles.0.head_node.0.password",
 			"roles.0.head_node.0.vm_size",
-			"roles.0.head_node.0.script_actions",
 			"roles.0.worker_node.0.password",
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
@@ -81,7 +104,8 @@ func TestAccHDInsightHBaseCluster_gen2basic(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"identity"),
 	})
 }
 
@@ -159,6 +183,85 @@ func TestAccHDInsightHBaseCluster_update(t *testing.T) {
 	})
 }
 
+func TestAccHDInsightHBaseCluster_workerScaleDownStrictValidation(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hbase_cluster", "test")
+	r := HDInsightHBaseClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.workerScaleDown(data, 3, true),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		{
+			Config:      r.workerScaleDown(data, 2, true),
+			ExpectError: regexp.MustCompile("`strict_validation` is enabled"),
+		},
+		{
+			Config: r.workerScaleDown(data, 2, false),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.target_instance_count").HasValue("2"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightHBaseCluster_autoscaleWithSchedule(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hbase_cluster", "test")
+	r := HDInsightHBaseClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.autoscaleSchedule(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.0.recurrence.0.schedule.#").HasValue("2"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.#").HasValue("0"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
 func TestAccHDInsightHBaseCluster_sshKeys(t *testing.T) {
 	data := acceptance.BuildTestData(t, "azurerm_hdinsight_hbase_cluster", "test")
 	r := HDInsightHBaseClusterResource{}
@@ -255,6 +358,7 @@ func TestAccHDInsightHBaseCluster_diskEncryption(t *testing.T) {
 			Config: r.diskEncryption(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("customer_managed_key_enabled").HasValue("false"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -484,7 +588,8 @@ func TestAccHDInsightHBaseCluster_azureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 	})
 }
 
@@ -506,7 +611,8 @@ func TestAccHDInsightHBaseCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.azureMonitor(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -521,7 +627,8 @@ func TestAccHDInsightHBaseCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			PreConfig: func() {
 				data.RandomString += "new"
@@ -539,7 +646,8 @@ func TestAccHDInsightHBaseCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.basic(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -554,7 +662,8 @@ func TestAccHDInsightHBaseCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 	})
 }
 
@@ -567,6 +676,7 @@ func testAccHDInsightHBaseCluster_securityProfile(t *testing.T) {
 			Config: r.securityProfile(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("aad"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -648,6 +758,179 @@ resource "azurerm_hdinsight_hbase_cluster" "test" {
 `, r.template(data), data.RandomInteger)
 }
 
+func (r HDInsightHBaseClusterResource) autoscaleSchedule(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_hbase_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hbase = "2.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_D3_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+
+      autoscale {
+        recurrence {
+          timezone = "Pacific Standard Time"
+          schedule {
+            days                  = ["Monday", "Tuesday", "Wednesday", "Thursday", "Friday"]
+            time                  = "08:00"
+            target_instance_count = 3
+          }
+          schedule {
+            days                  = ["Saturday", "Sunday"]
+            time                  = "08:00"
+            target_instance_count = 2
+          }
+        }
+      }
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightHBaseClusterResource) encryptionInTransitEnabled(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_hbase_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  encryption_in_transit_enabled = true
+
+  component_version {
+    hbase = "2.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_D3_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightHBaseClusterResource) workerScaleDown(data acceptance.TestData, targetInstanceCount int, strictValidation bool) string {
+	template := r.template(data)
+	if strictValidation {
+		template = withStrictValidation(template)
+	}
+
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_hbase_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    hbase = "2.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_D3_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = %d
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, template, data.RandomInteger, targetInstanceCount)
+}
+
 func (r HDInsightHBaseClusterResource) roleScriptActions(data acceptance.TestData) string {
 	return fmt.Sprintf(`
 %s
@@ -1594,7 +1877,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -1699,7 +1982,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -1906,7 +2189,7 @@ resource "azurerm_hdinsight_hbase_cluster" "test" {
   }
 
   gateway {
-    username = "sshuser"
+    username = "acctestusrgw"
     password = "TerrAform123!"
   }
 
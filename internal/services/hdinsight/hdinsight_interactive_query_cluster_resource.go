@@ -6,7 +6,6 @@ package hdinsight
 import (
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
@@ -18,6 +17,7 @@ import (
 	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/tags"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/validation"
 	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
 	"github.com/hashicorp/terraform-provider-azurerm/utils"
 )
@@ -33,11 +33,13 @@ var hdInsightInteractiveQueryClusterHeadNodeDefinition = HDInsightNodeDefinition
 }
 
 var hdInsightInteractiveQueryClusterWorkerNodeDefinition = HDInsightNodeDefinition{
+	ClusterKind:                              "Interactive Query",
 	CanSpecifyInstanceCount:                  true,
 	MinInstanceCount:                         1,
 	CanSpecifyDisks:                          false,
 	CanAutoScaleByCapacityDeprecated4PointOh: true,
 	CanAutoScaleOnSchedule:                   true,
+	RecommendedMinInstanceCount:              2,
 }
 
 var hdInsightInteractiveQueryClusterZookeeperNodeDefinition = HDInsightNodeDefinition{
@@ -49,24 +51,50 @@ var hdInsightInteractiveQueryClusterZookeeperNodeDefinition = HDInsightNodeDefin
 }
 
 func resourceHDInsightInteractiveQueryCluster() *pluginsdk.Resource {
-	resource := &pluginsdk.Resource{
+	if features.FourPointOh() {
+		hdInsightInteractiveQueryClusterWorkerNodeDefinition.CanAutoScaleByCapacityDeprecated4PointOh = false
+	}
+
+	return &pluginsdk.Resource{
 		Create: resourceHDInsightInteractiveQueryClusterCreate,
 		Read:   resourceHDInsightInteractiveQueryClusterRead,
 		Update: hdinsightClusterUpdate("Interactive Query", resourceHDInsightInteractiveQueryClusterRead),
 		Delete: hdinsightClusterDelete("Interactive Query"),
 
-		Importer: pluginsdk.ImporterValidatingResourceId(func(id string) error {
-			_, err := parse.ClusterID(id)
+		Importer: pluginsdk.ImporterValidatingResourceIdThen(func(id string) error {
+			_, err := parseHDInsightClusterImportId(id, "")
 			return err
-		}),
+		}, importHDInsightCluster),
 
 		Timeouts: &pluginsdk.ResourceTimeout{
 			Create: pluginsdk.DefaultTimeout(60 * time.Minute),
-			Read:   pluginsdk.DefaultTimeout(5 * time.Minute),
+			Read:   pluginsdk.DefaultTimeout(10 * time.Minute),
 			Update: pluginsdk.DefaultTimeout(60 * time.Minute),
 			Delete: pluginsdk.DefaultTimeout(60 * time.Minute),
 		},
 
+		CustomizeDiff: pluginsdk.CustomDiffWithAll(
+			hdinsightClusterGatewayUsernameDiff,
+			hdinsightClusterPasswordReuseDiff,
+			hdinsightClusterNodeTagsDiff,
+			hdinsightClusterRolesReplacementDiff,
+			hdinsightClusterStorageAccountsDiff,
+			hdinsightClusterStorageAccountFirewallDiff,
+			hdinsightClusterPrivateLinkDiff,
+			hdinsightClusterSecurityProfileNetworkDiff,
+			hdinsightClusterScriptActionUrisDiff,
+			hdinsightClusterScriptActionStorageAccountsDiff,
+			hdinsightClusterLocationDiff,
+			hdinsightClusterComponentVersionDiff,
+			hdinsightClusterScriptActionsPersistedDiff,
+			hdinsightClusterAutoscaleScheduleDiff,
+			hdinsightClusterAutoscaleQuotaDiff,
+			hdinsightClusterAutoscaleCapacityDiff,
+			hdinsightClusterWorkerTargetInstanceCountDiff,
+			hdinsightClusterSecurityProfileDiff("Interactive Query"),
+			hdinsightClusterWorkerRecommendedMinimumDiff(hdInsightInteractiveQueryClusterWorkerNodeDefinition),
+		),
+
 		Schema: map[string]*pluginsdk.Schema{
 			"name": SchemaHDInsightName(),
 
@@ -80,14 +108,21 @@ func resourceHDInsightInteractiveQueryCluster() *pluginsdk.Resource {
 
 			"tls_min_version": SchemaHDInsightTls(),
 
-			"encryption_in_transit_enabled": {
+			"encryption_in_transit_enabled": SchemaHDInsightEncryptionInTransitEnabled(),
+
+			"disk_encryption": SchemaHDInsightsDiskEncryptionProperties(),
+
+			"customer_managed_key_enabled": {
 				Type:     pluginsdk.TypeBool,
-				Optional: true,
-				ForceNew: true,
 				Computed: true,
 			},
 
-			"disk_encryption": SchemaHDInsightsDiskEncryptionProperties(),
+			"cluster_state": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
+			"unmanaged_persisted_script_actions": SchemaHDInsightUnmanagedPersistedScriptActions(),
 
 			"component_version": {
 				Type:     pluginsdk.TypeList,
@@ -96,9 +131,10 @@ func resourceHDInsightInteractiveQueryCluster() *pluginsdk.Resource {
 				Elem: &pluginsdk.Resource{
 					Schema: map[string]*pluginsdk.Schema{
 						"interactive_hive": {
-							Type:     pluginsdk.TypeString,
-							Required: true,
-							ForceNew: true,
+							Type:         pluginsdk.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
 						},
 					},
 				},
@@ -118,58 +154,89 @@ func resourceHDInsightInteractiveQueryCluster() *pluginsdk.Resource {
 
 			"storage_account_gen2": SchemaHDInsightsGen2StorageAccounts(),
 
+			"roles": {
+				Type:     pluginsdk.TypeList,
+				Required: true,
+				MaxItems: 1,
+				Elem: &pluginsdk.Resource{
+					Schema: map[string]*pluginsdk.Schema{
+						"head_node": SchemaHDInsightNodeDefinition("roles.0.head_node", hdInsightInteractiveQueryClusterHeadNodeDefinition, true),
+
+						"worker_node": SchemaHDInsightNodeDefinition("roles.0.worker_node", hdInsightInteractiveQueryClusterWorkerNodeDefinition, true),
+
+						"zookeeper_node": SchemaHDInsightNodeDefinition("roles.0.zookeeper_node", hdInsightInteractiveQueryClusterZookeeperNodeDefinition, true),
+					},
+				},
+			},
+
+			"script_action_reachability_check_enabled": {
+				Type:     pluginsdk.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"configuration_export_on_destroy_path": SchemaHDInsightConfigurationExportOnDestroyPath(),
+
+			"default_storage_container_created_by_cluster": SchemaHDInsightDefaultStorageContainerCreatedByCluster(),
+
+			"identity": commonschema.UserAssignedIdentityOptionalForceNew(),
+
 			"tags": tags.Schema(),
 
+			"node_tags": tags.Schema(),
+
+			"gateway_authentication_mode": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
+
 			"https_endpoint": {
 				Type:     pluginsdk.TypeString,
 				Computed: true,
 			},
 
-			"ssh_endpoint": {
+			"https_url": {
 				Type:     pluginsdk.TypeString,
 				Computed: true,
 			},
 
-			"monitor": SchemaHDInsightsMonitor(),
+			"endpoint_dns_label": SchemaHDInsightEndpointDNSLabel(),
 
-			"extension": SchemaHDInsightsExtension(),
-		},
-	}
+			"default_storage_endpoint": SchemaHDInsightDefaultStorageEndpoint(),
 
-	if !features.FourPointOh() {
-		resource.Schema["roles"] = &pluginsdk.Schema{
-			Type:     pluginsdk.TypeList,
-			Required: true,
-			MaxItems: 1,
-			Elem: &pluginsdk.Resource{
-				Schema: map[string]*pluginsdk.Schema{
-					"head_node": SchemaHDInsightNodeDefinition("roles.0.head_node", hdInsightInteractiveQueryClusterHeadNodeDefinition, true),
+			"application_endpoints": {
+				Type:     pluginsdk.TypeMap,
+				Computed: true,
+				Elem: &pluginsdk.Schema{
+					Type: pluginsdk.TypeString,
+				},
+			},
 
-					"worker_node": SchemaHDInsightNodeDefinition("roles.0.worker_node", hdInsightInteractiveQueryClusterWorkerNodeDefinition, true),
+			"ssh_endpoint": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
 
-					"zookeeper_node": SchemaHDInsightNodeDefinition("roles.0.zookeeper_node", hdInsightInteractiveQueryClusterZookeeperNodeDefinition, true),
-				},
+			"private_https_endpoint": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
 			},
-		}
-	} else {
-		hdInsightInteractiveQueryClusterWorkerNodeDefinition.CanAutoScaleByCapacityDeprecated4PointOh = false
-		resource.Schema["roles"] = &pluginsdk.Schema{
-			Type:     pluginsdk.TypeList,
-			Required: true,
-			MaxItems: 1,
-			Elem: &pluginsdk.Resource{
-				Schema: map[string]*pluginsdk.Schema{
-					"head_node": SchemaHDInsightNodeDefinition("roles.0.head_node", hdInsightInteractiveQueryClusterHeadNodeDefinition, true),
 
-					"worker_node": SchemaHDInsightNodeDefinition("roles.0.worker_node", hdInsightInteractiveQueryClusterWorkerNodeDefinition, true),
+			"private_ssh_endpoint": {
+				Type:     pluginsdk.TypeString,
+				Computed: true,
+			},
 
-					"zookeeper_node": SchemaHDInsightNodeDefinition("roles.0.zookeeper_node", hdInsightInteractiveQueryClusterZookeeperNodeDefinition, true),
-				},
+			"current_worker_count": {
+				Type:     pluginsdk.TypeInt,
+				Computed: true,
 			},
-		}
-	}
 
-	return resource
+			"monitor": SchemaHDInsightsMonitor(),
+
+			"extension": SchemaHDInsightsExtension(),
+		},
+	}
 }
 
 func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, meta interface{}) error {
@@ -184,14 +251,21 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 	id := parse.NewClusterID(subscriptionId, resourceGroup, name)
 	location := azure.NormalizeLocation(d.Get("location").(string))
 	clusterVersion := d.Get("cluster_version").(string)
-	t := d.Get("tags").(map[string]interface{})
-	tier := hdinsight.Tier(d.Get("tier").(string))
+	tier := hdinsight.Tier(normalizeHDInsightTier(d.Get("tier").(string)))
 	tls := d.Get("tls_min_version").(string)
 
 	componentVersionsRaw := d.Get("component_version").([]interface{})
-	componentVersions := expandHDInsightInteractiveQueryComponentVersion(componentVersionsRaw)
+	componentVersions, err := expandHDInsightInteractiveQueryComponentVersion(componentVersionsRaw)
+	if err != nil {
+		return fmt.Errorf("expanding `component_version`: %+v", err)
+	}
+
+	secretLookup := hdinsightKeyVaultSecretLookup(ctx, meta.(*clients.Client).KeyVault)
 
 	gatewayRaw := d.Get("gateway").([]interface{})
+	if err := expandHDInsightKeyVaultPasswords(gatewayRaw, secretLookup); err != nil {
+		return fmt.Errorf("expanding `gateway`: %+v", err)
+	}
 	configurations := ExpandHDInsightsConfigurations(gatewayRaw)
 
 	metastoresRaw := d.Get("metastores").([]interface{})
@@ -216,6 +290,9 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 		ZookeeperNodeDef: hdInsightInteractiveQueryClusterZookeeperNodeDefinition,
 	}
 	rolesRaw := d.Get("roles").([]interface{})
+	if err := expandHDInsightRolesKeyVaultPasswords(rolesRaw, secretLookup); err != nil {
+		return fmt.Errorf("expanding `roles`: %+v", err)
+	}
 	roles, err := expandHDInsightRoles(rolesRaw, interactiveQueryRoles)
 	if err != nil {
 		return fmt.Errorf("expanding `roles`: %+v", err)
@@ -223,30 +300,24 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 
 	computeIsolationProperties := ExpandHDInsightComputeIsolationProperties(d.Get("compute_isolation").([]interface{}))
 
-	existing, err := client.Get(ctx, resourceGroup, name)
+	exists, err := hdinsightClusterExists(ctx, client, id)
 	if err != nil {
-		if !utils.ResponseWasNotFound(existing.Response) {
-			return fmt.Errorf("checking for presence of existing HDInsight InteractiveQuery Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
-		}
+		return fmt.Errorf("checking for presence of existing HDInsight InteractiveQuery Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
 	}
 
-	if !utils.ResponseWasNotFound(existing.Response) {
+	if exists {
 		return tf.ImportAsExistsError("azurerm_hdinsight_interactive_query_cluster", id.ID())
 	}
 
-	encryptionInTransit := d.Get("encryption_in_transit_enabled").(bool)
-
 	params := hdinsight.ClusterCreateParametersExtended{
 		Location: utils.String(location),
 		Properties: &hdinsight.ClusterCreateProperties{
-			Tier:                   tier,
-			OsType:                 hdinsight.OSTypeLinux,
-			ClusterVersion:         utils.String(clusterVersion),
-			MinSupportedTLSVersion: utils.String(tls),
-			NetworkProperties:      networkProperties,
-			EncryptionInTransitProperties: &hdinsight.EncryptionInTransitProperties{
-				IsEncryptionInTransitEnabled: &encryptionInTransit,
-			},
+			Tier:                          tier,
+			OsType:                        hdinsight.OSTypeLinux,
+			ClusterVersion:                utils.String(clusterVersion),
+			MinSupportedTLSVersion:        utils.String(tls),
+			NetworkProperties:             networkProperties,
+			EncryptionInTransitProperties: ExpandHDInsightEncryptionInTransitProperties(d.Get("encryption_in_transit_enabled").(bool)),
 			ClusterDefinition: &hdinsight.ClusterDefinition{
 				Kind:             utils.String("INTERACTIVEHIVE"),
 				ComponentVersion: componentVersions,
@@ -260,7 +331,7 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 			},
 			ComputeIsolationProperties: computeIsolationProperties,
 		},
-		Tags:     tags.Expand(t),
+		Tags:     expandHDInsightClusterTags(d),
 		Identity: identity,
 	}
 
@@ -269,28 +340,44 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 		if err != nil {
 			return err
 		}
+
+		if msiResourceId := params.Properties.DiskEncryptionProperties.MsiResourceID; msiResourceId != nil && *msiResourceId != "" {
+			params.Identity = addHDInsightClusterUserAssignedIdentity(params.Identity, *msiResourceId)
+		}
 	}
 
 	if v, ok := d.GetOk("security_profile"); ok {
 		params.Properties.SecurityProfile = ExpandHDInsightSecurityProfile(v.([]interface{}))
 
-		params.Identity = &hdinsight.ClusterIdentity{
-			Type:                   hdinsight.ResourceIdentityTypeUserAssigned,
-			UserAssignedIdentities: make(map[string]*hdinsight.ClusterIdentityUserAssignedIdentitiesValue),
-		}
-
 		if params.Properties.SecurityProfile != nil && params.Properties.SecurityProfile.MsiResourceID != nil {
-			params.Identity.UserAssignedIdentities[*params.Properties.SecurityProfile.MsiResourceID] = &hdinsight.ClusterIdentityUserAssignedIdentitiesValue{}
+			params.Identity = addHDInsightClusterUserAssignedIdentity(params.Identity, *params.Properties.SecurityProfile.MsiResourceID)
 		}
 	}
 
+	if params.Identity, err = expandHDInsightClusterIdentity(d.Get("identity").([]interface{}), params.Identity); err != nil {
+		return fmt.Errorf("expanding `identity`: %+v", err)
+	}
+
+	// checked before the cluster is created, since the cluster creates the container when it doesn't exist
+	defaultStorageContainerCreatedByCluster := hdinsightClusterDefaultStorageContainerCreatedByCluster(ctx, meta, storageAccountsRaw)
+
 	future, err := client.Create(ctx, resourceGroup, name, params)
 	if err != nil {
-		return fmt.Errorf("creating HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return fmt.Errorf("creating HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, hdinsightResourceProviderRegistrationError(id.SubscriptionId, err))
 	}
 
+	// the ID is set before waiting so that should Terraform be interrupted, the cluster (which continues to be provisioned)
+	// remains in the state - meaning the next apply replaces it rather than failing to create it again
+	d.SetId(id.ID())
+	d.Set("default_storage_container_created_by_cluster", defaultStorageContainerCreatedByCluster)
+
 	if err := future.WaitForCompletionRef(ctx, client.Client); err != nil {
-		return fmt.Errorf("waiting for creation of HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		if hdinsightClusterCreationInterrupted(ctx) {
+			// an error is returned so that the cluster is tainted, since the configuration applied once it's been created wasn't
+			return fmt.Errorf("interrupted whilst waiting for creation of HDInsight Interactive Query Cluster %q (Resource Group %q) - the cluster continues to be provisioned, and has been kept in the state to be replaced by the next apply", name, resourceGroup)
+		}
+
+		return fmt.Errorf("waiting for creation of HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, hdinsightClusterScriptActionCreateError(ctx, meta, resourceGroup, name, err))
 	}
 
 	read, err := client.Get(ctx, resourceGroup, name)
@@ -302,7 +389,10 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 		return fmt.Errorf("reading ID for HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup)
 	}
 
-	d.SetId(id.ID())
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	if err := removeHDInsightNonPersistedScriptActions(ctx, scriptActionsClient, resourceGroup, name, d.Get("roles").([]interface{})); err != nil {
+		return err
+	}
 
 	// We can only enable monitoring after creation
 	if v, ok := d.GetOk("monitor"); ok {
@@ -324,8 +414,10 @@ func resourceHDInsightInteractiveQueryClusterCreate(d *pluginsdk.ResourceData, m
 
 func resourceHDInsightInteractiveQueryClusterRead(d *pluginsdk.ResourceData, meta interface{}) error {
 	clustersClient := meta.(*clients.Client).HDInsight.ClustersClient
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
 	configurationsClient := meta.(*clients.Client).HDInsight.ConfigurationsClient
 	extensionsClient := meta.(*clients.Client).HDInsight.ExtensionsClient
+	virtualMachinesClient := meta.(*clients.Client).HDInsight.VirtualMachinesClient
 	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
 	defer cancel()
 
@@ -345,17 +437,30 @@ func resourceHDInsightInteractiveQueryClusterRead(d *pluginsdk.ResourceData, met
 			return nil
 		}
 
-		return fmt.Errorf("retrieving HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		return hdinsightClusterReadError(ctx, fmt.Sprintf("HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup), err)
 	}
 
 	// Each call to configurationsClient methods is HTTP request. Getting all settings in one operation
 	configurations, err := configurationsClient.List(ctx, resourceGroup, name)
+	configurationsAvailable := true
 	if err != nil {
-		return fmt.Errorf("retrieving Configuration for HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+		switch {
+		case hdinsightClusterConfigurationsNotReady(configurations.Response):
+			// the configurations can't be retrieved whilst the cluster is provisioning, so the values in the state are kept until the next refresh
+			log.Printf("[DEBUG] Configuration for HDInsight Interactive Query Cluster %q (Resource Group %q) isn't available since the cluster isn't ready - skipping `gateway` and `metastores`: %+v", name, resourceGroup, err)
+
+		case hdinsightClusterConfigurationsUnreachable(ctx, resp.Properties, configurations.Response):
+			// the values in the state are kept, so that the cluster can still be planned from outside of the private network
+			log.Printf("[WARN] Configuration for HDInsight Interactive Query Cluster %q (Resource Group %q) couldn't be retrieved since the cluster is only reachable over Private Link - keeping the existing `gateway` and `metastores`, changes to these won't be detected until the cluster is refreshed from a network with private connectivity: %+v", name, resourceGroup, err)
+
+		default:
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("Configuration for HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup), err)
+		}
+		configurationsAvailable = false
 	}
 
 	gateway, exists := configurations.Configurations["gateway"]
-	if !exists {
+	if configurationsAvailable && !exists {
 		return fmt.Errorf("retrieving gateway for HDInsight Interactive Query Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
 	}
 
@@ -367,30 +472,24 @@ func resourceHDInsightInteractiveQueryClusterRead(d *pluginsdk.ResourceData, met
 
 	// storage_account isn't returned so I guess we just leave it ¯\_(ツ)_/¯
 	if props := resp.Properties; props != nil {
-		tier := ""
-		// the Azure API is inconsistent here, so rewrite this into the casing we expect
-		for _, v := range hdinsight.PossibleTierValues() {
-			if strings.EqualFold(string(v), string(props.Tier)) {
-				tier = string(v)
-			}
-		}
-		d.Set("tier", tier)
+		d.Set("tier", normalizeHDInsightTier(string(props.Tier)))
 		d.Set("cluster_version", props.ClusterVersion)
 		d.Set("tls_min_version", props.MinSupportedTLSVersion)
+		d.Set("encryption_in_transit_enabled", FlattenHDInsightEncryptionInTransitProperties(props.EncryptionInTransitProperties))
+		d.Set("customer_managed_key_enabled", FlattenHDInsightCustomerManagedKeyEnabled(props.DiskEncryptionProperties))
+		d.Set("cluster_state", utils.NormalizeNilableString(props.ClusterState))
 
 		if def := props.ClusterDefinition; def != nil {
 			if err := d.Set("component_version", flattenHDInsightInteractiveQueryComponentVersion(def.ComponentVersion)); err != nil {
 				return fmt.Errorf("flattening `component_version`: %+v", err)
 			}
 
-			if err := d.Set("gateway", FlattenHDInsightsConfigurations(gateway, d)); err != nil {
-				return fmt.Errorf("flattening `gateway`: %+v", err)
-			}
-
-			flattenHDInsightsMetastores(d, configurations.Configurations)
+			if configurationsAvailable {
+				if err := d.Set("gateway", FlattenHDInsightsConfigurations(gateway, d)); err != nil {
+					return fmt.Errorf("flattening `gateway`: %+v", err)
+				}
 
-			if props.EncryptionInTransitProperties != nil {
-				d.Set("encryption_in_transit_enabled", props.EncryptionInTransitProperties.IsEncryptionInTransitEnabled)
+				flattenHDInsightsMetastores(d, configurations.Configurations)
 			}
 
 			if props.DiskEncryptionProperties != nil {
@@ -415,7 +514,19 @@ func resourceHDInsightInteractiveQueryClusterRead(d *pluginsdk.ResourceData, met
 			WorkerNodeDef:    hdInsightInteractiveQueryClusterWorkerNodeDefinition,
 			ZookeeperNodeDef: hdInsightInteractiveQueryClusterZookeeperNodeDefinition,
 		}
+
+		persistedScriptActions, scriptActionsAvailable, err := listHDInsightPersistedScriptActions(ctx, scriptActionsClient, resourceGroup, name)
+		if err != nil {
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("persisted Script Actions for HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup), err)
+		}
+
 		flattenedRoles := flattenHDInsightRoles(d, props.ComputeProfile, interactiveQueryRoles)
+		if scriptActionsAvailable {
+			unmanaged := applyHDInsightPersistedScriptActions(flattenedRoles, persistedScriptActions)
+			if err := d.Set("unmanaged_persisted_script_actions", unmanaged); err != nil {
+				return fmt.Errorf("setting `unmanaged_persisted_script_actions`: %+v", err)
+			}
+		}
 		if err := d.Set("roles", flattenedRoles); err != nil {
 			return fmt.Errorf("flattening `roles`: %+v", err)
 		}
@@ -428,36 +539,62 @@ func resourceHDInsightInteractiveQueryClusterRead(d *pluginsdk.ResourceData, met
 
 		httpEndpoint := FindHDInsightConnectivityEndpoint("HTTPS", props.ConnectivityEndpoints)
 		d.Set("https_endpoint", httpEndpoint)
+		d.Set("https_url", hdinsightClusterHttpsUrl(httpEndpoint))
+		d.Set("endpoint_dns_label", hdinsightClusterEndpointDNSLabel(name, httpEndpoint))
+		d.Set("default_storage_endpoint", hdinsightClusterDefaultStorageEndpoint(d.Get("storage_account").([]interface{}), d.Get("storage_account_gen2").([]interface{}), configurations.Configurations["core-site"]))
+		d.Set("application_endpoints", flattenHDInsightClusterApplicationEndpoints("interactivehive", httpEndpoint))
 		sshEndpoint := FindHDInsightConnectivityEndpoint("SSH", props.ConnectivityEndpoints)
 		d.Set("ssh_endpoint", sshEndpoint)
+		privateHttpEndpoint := FindHDInsightConnectivityEndpoint("HTTPS-INTERNAL", props.ConnectivityEndpoints)
+		d.Set("private_https_endpoint", privateHttpEndpoint)
+		privateSshEndpoint := FindHDInsightConnectivityEndpoint("SSH-INTERNAL", props.ConnectivityEndpoints)
+		d.Set("private_ssh_endpoint", privateSshEndpoint)
+
+		currentWorkerCount, err := hdinsightClusterCurrentWorkerCount(ctx, virtualMachinesClient, resourceGroup, name, props.ComputeProfile)
+		if err != nil {
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("hosts for HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup), err)
+		}
+		d.Set("current_worker_count", currentWorkerCount)
 
 		monitor, err := extensionsClient.GetMonitoringStatus(ctx, resourceGroup, name)
 		if err != nil {
-			return fmt.Errorf("reading monitor configuration for HDInsight Hadoop Cluster %q (Resource Group %q): %+v", name, resourceGroup, err)
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("monitor configuration for HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup), err)
 		}
 
 		d.Set("monitor", flattenHDInsightMonitoring(monitor))
 
 		extension, err := extensionsClient.GetAzureMonitorStatus(ctx, resourceGroup, name)
 		if err != nil {
-			return fmt.Errorf("reading extension configuration for HDInsight Hadoop Cluster %q (Resource Group %q) %+v", name, resourceGroup, err)
+			return hdinsightClusterReadError(ctx, fmt.Sprintf("extension configuration for HDInsight Interactive Query Cluster %q (Resource Group %q)", name, resourceGroup), err)
 		}
 
-		d.Set("extension", flattenHDInsightAzureMonitor(extension))
+		d.Set("extension", flattenHDInsightAzureMonitor(extension, d.Get("extension").([]interface{})))
 
 		if err := d.Set("security_profile", flattenHDInsightSecurityProfile(props.SecurityProfile, d)); err != nil {
 			return fmt.Errorf("setting `security_profile`: %+v", err)
 		}
+
+		d.Set("gateway_authentication_mode", hdinsightClusterGatewayAuthenticationMode(props.SecurityProfile, d.Get("gateway.0.enabled").(bool)))
 	}
 
-	return tags.FlattenAndSet(d, resp.Tags)
-}
+	// this is only used at plan time, so isn't returned from the API
+	d.Set("script_action_reachability_check_enabled", d.Get("script_action_reachability_check_enabled").(bool))
+	// only known when the cluster is created by Terraform
+	d.Set("default_storage_container_created_by_cluster", d.Get("default_storage_container_created_by_cluster").(bool))
 
-func expandHDInsightInteractiveQueryComponentVersion(input []interface{}) map[string]*string {
-	vs := input[0].(map[string]interface{})
-	return map[string]*string{
-		"InteractiveHive": utils.String(vs["interactive_hive"].(string)),
+	flattenedIdentity, err := flattenHDInsightClusterIdentity(d, resp.Identity)
+	if err != nil {
+		return fmt.Errorf("flattening `identity`: %+v", err)
+	}
+	if err := d.Set("identity", flattenedIdentity); err != nil {
+		return fmt.Errorf("setting `identity`: %+v", err)
 	}
+
+	return flattenHDInsightClusterTags(d, resp.Tags)
+}
+
+func expandHDInsightInteractiveQueryComponentVersion(input []interface{}) (map[string]*string, error) {
+	return expandHDInsightComponentVersion(input, "Interactive Query", "interactive_hive", "InteractiveHive")
 }
 
 func flattenHDInsightInteractiveQueryComponentVersion(input map[string]*string) []interface{} {
@@ -28,6 +28,8 @@ func TestAccHDInsightInteractiveQueryCluster_basic(t *testing.T) {
 				check.That(data.ResourceName).ExistsInAzure(r),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("basic"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -54,7 +56,6 @@ func TestAccHDInsightInteractiveQueryCluster_roleScriptActions(t *testing.T) {
 		},
 		data.ImportStep("roles.0.head_node.0.password",
 			"roles.0.head_node.0.vm_size",
-			"roles.0.head_node.0.script_actions",
 			"roles.0.worker_node.0.password",
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
@@ -81,7 +82,8 @@ func TestAccHDInsightInteractiveQueryCluster_gen2basic(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"identity"),
 	})
 }
 
@@ -278,6 +280,7 @@ func TestAccHDInsightInteractiveQueryCluster_diskEncryption(t *testing.T) {
 			Config: r.diskEncryption(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("customer_managed_key_enabled").HasValue("false"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -529,7 +532,8 @@ func TestAccHDInsightInteractiveQueryCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			PreConfig: func() {
 				data.RandomString += "new"
@@ -547,7 +551,8 @@ func TestAccHDInsightInteractiveQueryCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.basic(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -556,6 +561,43 @@ func TestAccHDInsightInteractiveQueryCluster_updateAzureMonitor(t *testing.T) {
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
 			),
 		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account",
+			"extension.0.primary_key"),
+	})
+}
+
+func TestAccHDInsightInteractiveQueryCluster_autoscaleCapacity(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_interactive_query_cluster", "test")
+	r := HDInsightInteractiveQueryClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.autoscaleCapacity(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.0.capacity.0.min_instance_count").HasValue("2"),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.0.capacity.0.max_instance_count").HasValue("3"),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("roles.0.worker_node.0.autoscale.#").HasValue("0"),
+			),
+		},
 		data.ImportStep("roles.0.head_node.0.password",
 			"roles.0.head_node.0.vm_size",
 			"roles.0.worker_node.0.password",
@@ -612,6 +654,7 @@ func testAccHDInsightInteractiveQueryCluster_securityProfile(t *testing.T) {
 			Config: r.securityProfile(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("aad"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -1692,7 +1735,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -1797,7 +1840,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -2046,6 +2089,55 @@ resource "azurerm_hdinsight_interactive_query_cluster" "test" {
 `, r.template(data), data.RandomInteger)
 }
 
+func (r HDInsightInteractiveQueryClusterResource) autoscaleCapacity(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+resource "azurerm_hdinsight_interactive_query_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+  component_version {
+    interactive_hive = "3.1"
+  }
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+  roles {
+    head_node {
+      vm_size  = "Standard_D13_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+    worker_node {
+      vm_size               = "Standard_D14_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 2
+      autoscale {
+        capacity {
+          min_instance_count = 2
+          max_instance_count = 3
+        }
+      }
+    }
+    zookeeper_node {
+      vm_size  = "Standard_A4_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
 func (r HDInsightInteractiveQueryClusterResource) securityProfile(data acceptance.TestData) string {
 	return fmt.Sprintf(`
 %[1]s
@@ -2062,7 +2154,7 @@ resource "azurerm_hdinsight_interactive_query_cluster" "test" {
   }
 
   gateway {
-    username = "sshuser"
+    username = "acctestusrgw"
     password = "TerrAform123!"
   }
 
@@ -6,6 +6,7 @@ package hdinsight_test
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
@@ -28,6 +29,8 @@ func TestAccHDInsightKafkaCluster_basic(t *testing.T) {
 				check.That(data.ResourceName).ExistsInAzure(r),
 				check.That(data.ResourceName).Key("https_endpoint").Exists(),
 				check.That(data.ResourceName).Key("ssh_endpoint").Exists(),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("false"),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("basic"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -58,7 +61,8 @@ func TestAccHDInsightKafkaCluster_gen2storage(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"identity"),
 	})
 }
 
@@ -404,7 +408,8 @@ func TestAccHDInsightKafkaCluster_azureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 	})
 }
 
@@ -426,7 +431,8 @@ func TestAccHDInsightKafkaCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.azureMonitor(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -441,7 +447,8 @@ func TestAccHDInsightKafkaCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			PreConfig: func() {
 				data.RandomString += "new"
@@ -459,7 +466,8 @@ func TestAccHDInsightKafkaCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 		{
 			Config: r.basic(data),
 			Check: acceptance.ComposeTestCheckFunc(
@@ -474,7 +482,8 @@ func TestAccHDInsightKafkaCluster_updateAzureMonitor(t *testing.T) {
 			"roles.0.worker_node.0.vm_size",
 			"roles.0.zookeeper_node.0.password",
 			"roles.0.zookeeper_node.0.vm_size",
-			"storage_account"),
+			"storage_account",
+			"extension.0.primary_key"),
 	})
 }
 
@@ -487,6 +496,9 @@ func TestAccHDInsightKafkaCluster_restProxy(t *testing.T) {
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
 				check.That(data.ResourceName).Key("kafka_rest_proxy_endpoint").Exists(),
+				check.That(data.ResourceName).Key("rest_proxy.#").HasValue("1"),
+				check.That(data.ResourceName).Key("rest_proxy.0.security_group_id").Exists(),
+				check.That(data.ResourceName).Key("rest_proxy.0.security_group_name").Exists(),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -501,6 +513,60 @@ func TestAccHDInsightKafkaCluster_restProxy(t *testing.T) {
 	})
 }
 
+func TestAccHDInsightKafkaCluster_restProxyRemoved(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_kafka_cluster", "test")
+	r := HDInsightKafkaClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.restProxy(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("kafka_rest_proxy_endpoint").Exists(),
+			),
+		},
+		{
+			// removing the block replaces the cluster, since the REST proxy can't be disabled in-place
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("rest_proxy.#").HasValue("0"),
+				check.That(data.ResourceName).Key("kafka_rest_proxy_endpoint").HasValue(""),
+			),
+		},
+		data.ImportStep("roles.0.head_node.0.password",
+			"roles.0.head_node.0.vm_size",
+			"roles.0.worker_node.0.password",
+			"roles.0.worker_node.0.vm_size",
+			"roles.0.zookeeper_node.0.password",
+			"roles.0.zookeeper_node.0.vm_size",
+			"storage_account"),
+	})
+}
+
+func TestAccHDInsightKafkaCluster_restProxyNameTooLong(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_kafka_cluster", "test")
+	r := HDInsightKafkaClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config:      r.restProxyNameTooLong(data),
+			PlanOnly:    true,
+			ExpectError: regexp.MustCompile("`name` must be 53 characters or less when `rest_proxy` is specified"),
+		},
+	})
+}
+
+func TestAccHDInsightKafkaCluster_kafkaManagementNodeWithoutRestProxy(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_kafka_cluster", "test")
+	r := HDInsightKafkaClusterResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config:      withStrictValidation(r.kafkaManagementNodeWithoutRestProxy(data)),
+			PlanOnly:    true,
+			ExpectError: regexp.MustCompile("`roles.0.kafka_management_node` is specified without `rest_proxy`"),
+		},
+	})
+}
+
 func TestAccHDInsightKafkaCluster_diskEncryption(t *testing.T) {
 	data := acceptance.BuildTestData(t, "azurerm_hdinsight_kafka_cluster", "test")
 	r := HDInsightKafkaClusterResource{}
@@ -509,6 +575,7 @@ func TestAccHDInsightKafkaCluster_diskEncryption(t *testing.T) {
 			Config: r.diskEncryption(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("customer_managed_key_enabled").HasValue("false"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -531,6 +598,7 @@ func TestAccHDInsightKafkaCluster_encryptionInTransitEnabled(t *testing.T) {
 			Config: r.encryptionInTransitEnabled(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("encryption_in_transit_enabled").HasValue("true"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -554,6 +622,7 @@ func testAccHDInsightKafkaCluster_securityProfile(t *testing.T) {
 			Config: r.securityProfile(data),
 			Check: acceptance.ComposeTestCheckFunc(
 				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("gateway_authentication_mode").HasValue("aad"),
 			),
 		},
 		data.ImportStep("roles.0.head_node.0.password",
@@ -1172,7 +1241,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -1278,7 +1347,7 @@ resource "azurerm_sql_server" "test" {
   resource_group_name          = azurerm_resource_group.test.name
   location                     = azurerm_resource_group.test.location
   administrator_login          = "sql_admin"
-  administrator_login_password = "TerrAform123!"
+  administrator_login_password = "AccTestSQL789!"
   version                      = "12.0"
 }
 resource "azurerm_sql_database" "hive" {
@@ -1541,6 +1610,132 @@ resource "azurerm_hdinsight_kafka_cluster" "test" {
 `, r.template(data), data.RandomInteger, data.RandomInteger)
 }
 
+func (r HDInsightKafkaClusterResource) kafkaManagementNodeWithoutRestProxy(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_kafka_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    kafka = "2.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size                  = "Standard_D3_V2"
+      username                 = "acctestusrvm"
+      password                 = "AccTestvdSC4daf986!"
+      target_instance_count    = 3
+      number_of_disks_per_node = 2
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    kafka_management_node {
+      vm_size  = "Standard_D4_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightKafkaClusterResource) restProxyNameTooLong(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+provider "azuread" {}
+
+resource "azuread_group" "test" {
+  display_name     = "acctesthdi-%d"
+  security_enabled = true
+}
+
+resource "azurerm_hdinsight_kafka_cluster" "test" {
+  name                = "acctesthdi-%d-long-kafka-rest-proxy-name"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    kafka = "2.1"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size                  = "Standard_D3_V2"
+      username                 = "acctestusrvm"
+      password                 = "AccTestvdSC4daf986!"
+      target_instance_count    = 3
+      number_of_disks_per_node = 2
+    }
+
+    zookeeper_node {
+      vm_size  = "Standard_D3_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    kafka_management_node {
+      vm_size  = "Standard_D4_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+
+  rest_proxy {
+    security_group_id   = azuread_group.test.id
+    security_group_name = azuread_group.test.display_name
+  }
+}
+`, r.template(data), data.RandomInteger, data.RandomInteger)
+}
+
 func (r HDInsightKafkaClusterResource) diskEncryption(data acceptance.TestData) string {
 	return fmt.Sprintf(`
 %s
@@ -1720,7 +1915,7 @@ resource "azurerm_hdinsight_kafka_cluster" "test" {
   }
 
   gateway {
-    username = "sshuser"
+    username = "acctestusrgw"
     password = "TerrAform123!"
   }
 
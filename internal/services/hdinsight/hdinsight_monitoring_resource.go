@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/hashicorp/terraform-provider-azurerm/helpers/tf"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/validation"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+const (
+	// hdInsightMonitoringModeClassic is the Log Analytics integration, configured using the `monitor` block of the clusters
+	hdInsightMonitoringModeClassic = "classic"
+	// hdInsightMonitoringModeAzureMonitor is the Azure Monitor integration, configured using the `extension` block of the clusters
+	hdInsightMonitoringModeAzureMonitor = "azure_monitor"
+)
+
+func resourceHDInsightMonitoring() *pluginsdk.Resource {
+	return &pluginsdk.Resource{
+		Create: resourceHDInsightMonitoringCreate,
+		Read:   resourceHDInsightMonitoringRead,
+		Update: resourceHDInsightMonitoringUpdate,
+		Delete: resourceHDInsightMonitoringDelete,
+
+		Importer: pluginsdk.ImporterValidatingResourceIdThen(func(id string) error {
+			_, err := parse.ClusterID(id)
+			return err
+		}, importHDInsightMonitoring),
+
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Create: pluginsdk.DefaultTimeout(60 * time.Minute),
+			Read:   pluginsdk.DefaultTimeout(5 * time.Minute),
+			Update: pluginsdk.DefaultTimeout(60 * time.Minute),
+			Delete: pluginsdk.DefaultTimeout(60 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"cluster_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validate.ClusterID,
+			},
+
+			"mode": {
+				Type:     pluginsdk.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					hdInsightMonitoringModeClassic,
+					hdInsightMonitoringModeAzureMonitor,
+				}, false),
+			},
+
+			"log_analytics_workspace_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ValidateFunc: validation.IsUUID,
+			},
+
+			// the API doesn't return the key, so the value from the config is used
+			"primary_key": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				Sensitive:    true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+		},
+	}
+}
+
+func resourceHDInsightMonitoringCreate(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ExtensionsClient
+	ctx, cancel := timeouts.ForCreate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Get("cluster_id").(string))
+	if err != nil {
+		return err
+	}
+	mode := d.Get("mode").(string)
+
+	// the monitoring can also be configured using the `monitor` or `extension` blocks of the clusters, which would conflict
+	existing, err := getHDInsightMonitoringStatus(ctx, client, *id, mode)
+	if err != nil {
+		return fmt.Errorf("checking for the existing %s Monitoring for %s: %+v", mode, id, err)
+	}
+	if existing.enabled {
+		return tf.ImportAsExistsError("azurerm_hdinsight_monitoring", id.ID())
+	}
+
+	if err := enableHDInsightMonitoringMode(ctx, client, *id, mode, d); err != nil {
+		return err
+	}
+
+	d.SetId(id.ID())
+
+	return resourceHDInsightMonitoringRead(d, meta)
+}
+
+func resourceHDInsightMonitoringRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ExtensionsClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Id())
+	if err != nil {
+		return err
+	}
+	mode := d.Get("mode").(string)
+
+	status, err := getHDInsightMonitoringStatus(ctx, client, *id, mode)
+	if err != nil {
+		if utils.ResponseWasNotFound(status.response) {
+			log.Printf("[DEBUG] %s was not found - removing from state", id)
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("retrieving %s Monitoring for %s: %+v", mode, id, err)
+	}
+
+	if !status.enabled {
+		log.Printf("[DEBUG] %s Monitoring for %s is disabled - removing from state", mode, id)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("cluster_id", id.ID())
+	d.Set("mode", mode)
+	d.Set("log_analytics_workspace_id", status.workspaceId)
+
+	return nil
+}
+
+func resourceHDInsightMonitoringUpdate(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ExtensionsClient
+	ctx, cancel := timeouts.ForUpdate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	if d.HasChanges("log_analytics_workspace_id", "primary_key") {
+		// the API supports re-enabling the monitoring with a different workspace or a rotated key
+		if err := enableHDInsightMonitoringMode(ctx, client, *id, d.Get("mode").(string), d); err != nil {
+			return err
+		}
+	}
+
+	return resourceHDInsightMonitoringRead(d, meta)
+}
+
+func resourceHDInsightMonitoringDelete(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ExtensionsClient
+	ctx, cancel := timeouts.ForDelete(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ClusterID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	switch d.Get("mode").(string) {
+	case hdInsightMonitoringModeAzureMonitor:
+		return disableHDInsightAzureMonitor(ctx, client, id.ResourceGroup, id.Name)
+	default:
+		return disableHDInsightMonitoring(ctx, client, id.ResourceGroup, id.Name)
+	}
+}
+
+// importHDInsightMonitoring determines the `mode` from whichever integration is enabled on the cluster being imported
+func importHDInsightMonitoring(ctx context.Context, d *pluginsdk.ResourceData, meta interface{}) ([]*pluginsdk.ResourceData, error) {
+	client := meta.(*clients.Client).HDInsight.ExtensionsClient
+
+	id, err := parse.ClusterID(d.Id())
+	if err != nil {
+		return []*pluginsdk.ResourceData{}, err
+	}
+
+	enabledModes := make([]string, 0)
+	for _, mode := range []string{hdInsightMonitoringModeClassic, hdInsightMonitoringModeAzureMonitor} {
+		status, err := getHDInsightMonitoringStatus(ctx, client, *id, mode)
+		if err != nil {
+			return []*pluginsdk.ResourceData{}, fmt.Errorf("retrieving %s Monitoring for %s: %+v", mode, id, err)
+		}
+
+		if status.enabled {
+			enabledModes = append(enabledModes, mode)
+		}
+	}
+
+	switch len(enabledModes) {
+	case 0:
+		return []*pluginsdk.ResourceData{}, fmt.Errorf("neither %s nor %s Monitoring is enabled for %s", hdInsightMonitoringModeClassic, hdInsightMonitoringModeAzureMonitor, id)
+	case 1:
+		d.Set("mode", enabledModes[0])
+	default:
+		return []*pluginsdk.ResourceData{}, fmt.Errorf("both %s and %s Monitoring are enabled for %s - the `mode` to import can't be determined, disable one of them before importing the other", hdInsightMonitoringModeClassic, hdInsightMonitoringModeAzureMonitor, id)
+	}
+
+	return []*pluginsdk.ResourceData{d}, nil
+}
+
+type hdInsightMonitoringStatus struct {
+	response    autorest.Response
+	enabled     bool
+	workspaceId string
+}
+
+func getHDInsightMonitoringStatus(ctx context.Context, client *hdinsight.ExtensionsClient, id parse.ClusterId, mode string) (hdInsightMonitoringStatus, error) {
+	var enabled *bool
+	var workspaceId *string
+	var response autorest.Response
+
+	switch mode {
+	case hdInsightMonitoringModeAzureMonitor:
+		resp, err := client.GetAzureMonitorStatus(ctx, id.ResourceGroup, id.Name)
+		if err != nil {
+			return hdInsightMonitoringStatus{response: resp.Response}, err
+		}
+		enabled, workspaceId, response = resp.ClusterMonitoringEnabled, resp.WorkspaceID, resp.Response
+	default:
+		resp, err := client.GetMonitoringStatus(ctx, id.ResourceGroup, id.Name)
+		if err != nil {
+			return hdInsightMonitoringStatus{response: resp.Response}, err
+		}
+		enabled, workspaceId, response = resp.ClusterMonitoringEnabled, resp.WorkspaceID, resp.Response
+	}
+
+	status := hdInsightMonitoringStatus{
+		response: response,
+		enabled:  enabled != nil && *enabled,
+	}
+	if workspaceId != nil {
+		status.workspaceId = *workspaceId
+	}
+
+	return status, nil
+}
+
+func enableHDInsightMonitoringMode(ctx context.Context, client *hdinsight.ExtensionsClient, id parse.ClusterId, mode string, d *pluginsdk.ResourceData) error {
+	input := []interface{}{
+		map[string]interface{}{
+			"log_analytics_workspace_id": d.Get("log_analytics_workspace_id").(string),
+			"primary_key":                d.Get("primary_key").(string),
+		},
+	}
+
+	switch mode {
+	case hdInsightMonitoringModeAzureMonitor:
+		if err := enableHDInsightAzureMonitor(ctx, client, id.ResourceGroup, id.Name, input); err != nil {
+			return fmt.Errorf("enabling Azure Monitor for %s: %+v", id, err)
+		}
+	default:
+		if err := enableHDInsightMonitoring(ctx, client, id.ResourceGroup, id.Name, input); err != nil {
+			return fmt.Errorf("enabling Monitoring for %s: %+v", id, err)
+		}
+	}
+
+	return nil
+}
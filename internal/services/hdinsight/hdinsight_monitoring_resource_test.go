@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+type HDInsightMonitoringResource struct{}
+
+func TestAccHDInsightMonitoring_classic(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_monitoring", "test")
+	r := HDInsightMonitoringResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data, "classic"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("mode").HasValue("classic"),
+			),
+		},
+		data.ImportStep("primary_key"),
+	})
+}
+
+func TestAccHDInsightMonitoring_azureMonitor(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_monitoring", "test")
+	r := HDInsightMonitoringResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data, "azure_monitor"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("mode").HasValue("azure_monitor"),
+			),
+		},
+		data.ImportStep("primary_key"),
+	})
+}
+
+func TestAccHDInsightMonitoring_update(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_monitoring", "test")
+	r := HDInsightMonitoringResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data, "azure_monitor"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.ImportStep("primary_key"),
+		{
+			Config: r.updated(data, "azure_monitor"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.ImportStep("primary_key"),
+	})
+}
+
+func TestAccHDInsightMonitoring_requiresImport(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_monitoring", "test")
+	r := HDInsightMonitoringResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data, "classic"),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.RequiresImportErrorStep(r.requiresImport),
+	})
+}
+
+func (HDInsightMonitoringResource) Exists(ctx context.Context, clients *clients.Client, state *pluginsdk.InstanceState) (*bool, error) {
+	id, err := parse.ClusterID(state.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Attributes["mode"] == "azure_monitor" {
+		resp, err := clients.HDInsight.ExtensionsClient.GetAzureMonitorStatus(ctx, id.ResourceGroup, id.Name)
+		if err != nil {
+			return nil, fmt.Errorf("retrieving Azure Monitor Status for %s: %+v", id, err)
+		}
+
+		return utils.Bool(resp.ClusterMonitoringEnabled != nil && *resp.ClusterMonitoringEnabled), nil
+	}
+
+	resp, err := clients.HDInsight.ExtensionsClient.GetMonitoringStatus(ctx, id.ResourceGroup, id.Name)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving Monitoring Status for %s: %+v", id, err)
+	}
+
+	return utils.Bool(resp.ClusterMonitoringEnabled != nil && *resp.ClusterMonitoringEnabled), nil
+}
+
+func (r HDInsightMonitoringResource) basic(data acceptance.TestData, mode string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_monitoring" "test" {
+  cluster_id                 = azurerm_hdinsight_spark_cluster.test.id
+  mode                       = "%s"
+  log_analytics_workspace_id = azurerm_log_analytics_workspace.test.workspace_id
+  primary_key                = azurerm_log_analytics_workspace.test.primary_shared_key
+}
+`, r.template(data), mode)
+}
+
+func (r HDInsightMonitoringResource) updated(data acceptance.TestData, mode string) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_log_analytics_workspace" "other" {
+  name                = "acctestLAW2-%s-%d"
+  location            = azurerm_resource_group.test.location
+  resource_group_name = azurerm_resource_group.test.name
+  sku                 = "PerGB2018"
+}
+
+resource "azurerm_hdinsight_monitoring" "test" {
+  cluster_id                 = azurerm_hdinsight_spark_cluster.test.id
+  mode                       = "%s"
+  log_analytics_workspace_id = azurerm_log_analytics_workspace.other.workspace_id
+  primary_key                = azurerm_log_analytics_workspace.other.primary_shared_key
+}
+`, r.template(data), data.RandomString, data.RandomInteger, mode)
+}
+
+func (r HDInsightMonitoringResource) requiresImport(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_monitoring" "import" {
+  cluster_id                 = azurerm_hdinsight_monitoring.test.cluster_id
+  mode                       = azurerm_hdinsight_monitoring.test.mode
+  log_analytics_workspace_id = azurerm_hdinsight_monitoring.test.log_analytics_workspace_id
+  primary_key                = azurerm_hdinsight_monitoring.test.primary_key
+}
+`, r.basic(data, "classic"))
+}
+
+func (HDInsightMonitoringResource) template(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_log_analytics_workspace" "test" {
+  name                = "acctestLAW-%s-%d"
+  location            = azurerm_resource_group.test.location
+  resource_group_name = azurerm_resource_group.test.name
+  sku                 = "PerGB2018"
+}
+
+resource "azurerm_hdinsight_spark_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    spark = "2.4"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_A4_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_A4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 3
+    }
+
+    zookeeper_node {
+      vm_size  = "Medium"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+
+  lifecycle {
+    ignore_changes = [monitor, extension]
+  }
+}
+`, HDInsightSparkClusterResource{}.template(data), data.RandomString, data.RandomInteger, data.RandomInteger)
+}
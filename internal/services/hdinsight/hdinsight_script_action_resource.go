@@ -0,0 +1,498 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/hashicorp/terraform-provider-azurerm/helpers/tf"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/validate"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/validation"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/timeouts"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+// hdInsightScriptActionResourceRoles maps the `roles` of the script action to the names of the roles used by the API.
+// Unlike the `script_actions` within the `roles` block of the clusters, these can also be run on the edge nodes.
+var hdInsightScriptActionResourceRoles = map[string]string{
+	"head_node":             "headnode",
+	"worker_node":           "workernode",
+	"zookeeper_node":        "zookeepernode",
+	"edge_node":             "edgenode",
+	"kafka_management_node": "kafkamanagementnode",
+}
+
+func resourceHDInsightScriptAction() *pluginsdk.Resource {
+	return &pluginsdk.Resource{
+		Create: resourceHDInsightScriptActionCreate,
+		Read:   resourceHDInsightScriptActionRead,
+		Update: resourceHDInsightScriptActionUpdate,
+		Delete: resourceHDInsightScriptActionDelete,
+
+		Importer: pluginsdk.ImporterValidatingResourceIdThen(func(id string) error {
+			_, err := parse.ScriptActionID(id)
+			return err
+		}, importHDInsightScriptAction),
+
+		Timeouts: &pluginsdk.ResourceTimeout{
+			Create: pluginsdk.DefaultTimeout(60 * time.Minute),
+			Read:   pluginsdk.DefaultTimeout(5 * time.Minute),
+			Update: pluginsdk.DefaultTimeout(60 * time.Minute),
+			Delete: pluginsdk.DefaultTimeout(30 * time.Minute),
+		},
+
+		Schema: map[string]*pluginsdk.Schema{
+			"name": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"cluster_id": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validate.ClusterID,
+			},
+
+			"uri": {
+				Type:         pluginsdk.TypeString,
+				Required:     true,
+				ValidateFunc: validate.ScriptActionURI,
+			},
+
+			"roles": {
+				Type:     pluginsdk.TypeSet,
+				Required: true,
+				ForceNew: true,
+				MinItems: 1,
+				Elem: &pluginsdk.Schema{
+					Type:         pluginsdk.TypeString,
+					ValidateFunc: validation.StringInSlice(hdinsightScriptActionRoleNames(), false),
+				},
+			},
+
+			"parameters": {
+				Type:         pluginsdk.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsNotEmpty,
+			},
+
+			"persist_on_success": {
+				Type:     pluginsdk.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"script_execution_id": {
+				Type:         pluginsdk.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[0-9]+$`), "`script_execution_id` must be the numeric ID of a script execution"),
+			},
+		},
+	}
+}
+
+func resourceHDInsightScriptActionCreate(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	ctx, cancel := timeouts.ForCreate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	clusterId, err := parse.ClusterID(d.Get("cluster_id").(string))
+	if err != nil {
+		return err
+	}
+	id := parse.NewScriptActionID(clusterId.SubscriptionId, clusterId.ResourceGroup, clusterId.Name, d.Get("name").(string))
+
+	if d.Get("persist_on_success").(bool) {
+		existing, err := findHDInsightPersistedScriptAction(ctx, client, id)
+		if err != nil {
+			return fmt.Errorf("checking for the existing %s: %+v", id, err)
+		}
+		if existing != nil {
+			return tf.ImportAsExistsError("azurerm_hdinsight_script_action", id.ID())
+		}
+	}
+
+	// a script action which has already been run (e.g. manually) is promoted to a persisted script action, rather than
+	// running it again
+	if executionId := d.Get("script_execution_id").(string); executionId != "" {
+		if !d.Get("persist_on_success").(bool) {
+			return fmt.Errorf("`persist_on_success` must be `true` when `script_execution_id` is specified, since the execution is promoted to a persisted script action")
+		}
+
+		if err := promoteHDInsightScriptActionExecution(ctx, meta, id, executionId); err != nil {
+			return err
+		}
+	} else if err := executeHDInsightScriptAction(ctx, meta, id, d); err != nil {
+		return err
+	}
+
+	d.SetId(id.ID())
+
+	return resourceHDInsightScriptActionRead(d, meta)
+}
+
+func resourceHDInsightScriptActionRead(d *pluginsdk.ResourceData, meta interface{}) error {
+	clustersClient := meta.(*clients.Client).HDInsight.ClustersClient
+	client := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	ctx, cancel := timeouts.ForRead(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ScriptActionID(d.Id())
+	if err != nil {
+		return err
+	}
+	clusterId := parse.NewClusterID(id.SubscriptionId, id.ResourceGroup, id.ClusterName)
+
+	cluster, err := clustersClient.Get(ctx, id.ResourceGroup, id.ClusterName)
+	if err != nil {
+		if utils.ResponseWasNotFound(cluster.Response) {
+			log.Printf("[DEBUG] %s was not found - removing %s from state", clusterId, id)
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("retrieving %s: %+v", clusterId, err)
+	}
+
+	d.Set("name", id.Name)
+	d.Set("cluster_id", clusterId.ID())
+
+	// script actions which aren't persisted are only recorded in the execution history, so the values from the config
+	// are used - these are run again when the `uri` or `parameters` change
+	persistOnSuccess := d.Get("persist_on_success").(bool)
+	d.Set("persist_on_success", persistOnSuccess)
+	if !persistOnSuccess {
+		return nil
+	}
+
+	scriptAction, err := findHDInsightPersistedScriptAction(ctx, client, *id)
+	if err != nil {
+		return fmt.Errorf("retrieving %s: %+v", id, err)
+	}
+	if scriptAction == nil {
+		log.Printf("[DEBUG] %s was not found - removing from state", id)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("uri", scriptAction.URI)
+	d.Set("parameters", scriptAction.Parameters)
+	if err := d.Set("roles", flattenHDInsightScriptActionRoles(scriptAction.Roles)); err != nil {
+		return fmt.Errorf("setting `roles`: %+v", err)
+	}
+
+	return nil
+}
+
+func resourceHDInsightScriptActionUpdate(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	ctx, cancel := timeouts.ForUpdate(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ScriptActionID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	oldPersistOnSuccess, _ := d.GetChange("persist_on_success")
+	wasPersisted := oldPersistOnSuccess.(bool)
+	persistOnSuccess := d.Get("persist_on_success").(bool)
+
+	switch {
+	case d.HasChanges("uri", "parameters"):
+		// a script action can't be persisted with the name of an existing persisted script action, so the previous
+		// version is removed before the script action is run again
+		if wasPersisted {
+			if _, err := client.Delete(ctx, id.ResourceGroup, id.ClusterName, id.Name); err != nil {
+				return fmt.Errorf("removing the previous version of %s: %+v", id, err)
+			}
+		}
+
+		if err := executeHDInsightScriptAction(ctx, meta, *id, d); err != nil {
+			return err
+		}
+
+	case d.HasChange("persist_on_success") && persistOnSuccess:
+		// the most recent execution is promoted, rather than running the script action again
+		execution, err := latestHDInsightScriptActionExecution(ctx, meta, *id)
+		if err != nil {
+			return fmt.Errorf("retrieving the execution history of %s: %+v", id, err)
+		}
+		if execution == nil || execution.ScriptExecutionID == nil {
+			return fmt.Errorf("promoting %s: no execution of the script action was found in the execution history of the cluster", id)
+		}
+
+		if err := promoteHDInsightScriptActionExecution(ctx, meta, *id, strconv.FormatInt(*execution.ScriptExecutionID, 10)); err != nil {
+			return err
+		}
+
+	case d.HasChange("persist_on_success"):
+		if _, err := client.Delete(ctx, id.ResourceGroup, id.ClusterName, id.Name); err != nil {
+			return fmt.Errorf("removing the persisted %s: %+v", id, err)
+		}
+	}
+
+	return resourceHDInsightScriptActionRead(d, meta)
+}
+
+func resourceHDInsightScriptActionDelete(d *pluginsdk.ResourceData, meta interface{}) error {
+	client := meta.(*clients.Client).HDInsight.ScriptActionsClient
+	ctx, cancel := timeouts.ForDelete(meta.(*clients.Client).StopContext, d)
+	defer cancel()
+
+	id, err := parse.ScriptActionID(d.Id())
+	if err != nil {
+		return err
+	}
+
+	// the changes made by a script action can't be reverted, deleting it only stops it being run on new nodes
+	if !d.Get("persist_on_success").(bool) {
+		return nil
+	}
+
+	if resp, err := client.Delete(ctx, id.ResourceGroup, id.ClusterName, id.Name); err != nil {
+		if !utils.ResponseWasNotFound(resp) {
+			return fmt.Errorf("deleting %s: %+v", id, err)
+		}
+	}
+
+	return nil
+}
+
+// importHDInsightScriptAction sets `persist_on_success` since only persisted script actions can be imported
+func importHDInsightScriptAction(ctx context.Context, d *pluginsdk.ResourceData, meta interface{}) ([]*pluginsdk.ResourceData, error) {
+	client := meta.(*clients.Client).HDInsight.ScriptActionsClient
+
+	id, err := parse.ScriptActionID(d.Id())
+	if err != nil {
+		return []*pluginsdk.ResourceData{}, err
+	}
+
+	scriptAction, err := findHDInsightPersistedScriptAction(ctx, client, *id)
+	if err != nil {
+		return []*pluginsdk.ResourceData{}, fmt.Errorf("retrieving %s: %+v", id, err)
+	}
+	if scriptAction == nil {
+		return []*pluginsdk.ResourceData{}, fmt.Errorf("%s was not found - only persisted script actions can be imported", id)
+	}
+
+	d.Set("persist_on_success", true)
+
+	return []*pluginsdk.ResourceData{d}, nil
+}
+
+// executeHDInsightScriptAction runs the script action on the cluster and waits for it to complete, returning the debug
+// information of the execution when the script fails
+func executeHDInsightScriptAction(ctx context.Context, meta interface{}, id parse.ScriptActionId, d *pluginsdk.ResourceData) error {
+	client := meta.(*clients.Client).HDInsight.ClustersClient
+
+	scriptAction := hdinsight.RuntimeScriptAction{
+		Name:  utils.String(id.Name),
+		URI:   utils.String(d.Get("uri").(string)),
+		Roles: expandHDInsightScriptActionRoles(d.Get("roles").(*pluginsdk.Set).List()),
+	}
+	if v := d.Get("parameters").(string); v != "" {
+		scriptAction.Parameters = utils.String(v)
+	}
+
+	params := hdinsight.ExecuteScriptActionParameters{
+		ScriptActions:    &[]hdinsight.RuntimeScriptAction{scriptAction},
+		PersistOnSuccess: utils.Bool(d.Get("persist_on_success").(bool)),
+	}
+
+	future, err := client.ExecuteScriptActions(ctx, id.ResourceGroup, id.ClusterName, params)
+	if err == nil {
+		err = future.WaitForCompletionRef(ctx, client.Client)
+	}
+
+	execution, historyErr := latestHDInsightScriptActionExecution(ctx, meta, id)
+	if historyErr != nil {
+		log.Printf("[DEBUG] Unable to retrieve the execution history of %s: %+v", id, historyErr)
+	}
+
+	if err != nil {
+		if execution != nil && hdinsightScriptActionExecutionFailed(*execution) {
+			return fmt.Errorf("executing %s: %+v\n\n%s", id, err, formatHDInsightScriptActionFailure(*execution))
+		}
+		return fmt.Errorf("executing %s: %+v", id, err)
+	}
+
+	// the operation can complete successfully even though the script exited with a non-zero exit code on some nodes
+	if execution != nil && hdinsightScriptActionExecutionFailed(*execution) {
+		return fmt.Errorf("executing %s:\n\n%s", id, formatHDInsightScriptActionFailure(*execution))
+	}
+
+	return nil
+}
+
+// promoteHDInsightScriptActionExecution promotes a previous execution of the script action to a persisted script action,
+// so that it's also run on nodes added when scaling the cluster
+func promoteHDInsightScriptActionExecution(ctx context.Context, meta interface{}, id parse.ScriptActionId, executionId string) error {
+	historyClient := meta.(*clients.Client).HDInsight.ScriptExecutionHistoryClient
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
+
+	execution, err := scriptActionsClient.GetExecutionDetail(ctx, id.ResourceGroup, id.ClusterName, executionId)
+	if err != nil {
+		return fmt.Errorf("retrieving the Script Action execution %s of the HDInsight Cluster %q (Resource Group %q): %+v", executionId, id.ClusterName, id.ResourceGroup, err)
+	}
+
+	if err := validateHDInsightScriptActionPromotion(execution, id.Name); err != nil {
+		return fmt.Errorf("promoting the Script Action execution %s to %s: %+v", executionId, id, err)
+	}
+
+	if _, err := historyClient.Promote(ctx, id.ResourceGroup, id.ClusterName, executionId); err != nil {
+		return fmt.Errorf("promoting the Script Action execution %s to %s: %+v", executionId, id, err)
+	}
+
+	return nil
+}
+
+// validateHDInsightScriptActionPromotion checks the execution being promoted is of the script action with this name,
+// and that it succeeded - since the API otherwise persists a script action which fails on the new nodes
+func validateHDInsightScriptActionPromotion(execution hdinsight.RuntimeScriptActionDetail, name string) error {
+	if execution.Name == nil || !strings.EqualFold(*execution.Name, name) {
+		executionName := ""
+		if execution.Name != nil {
+			executionName = *execution.Name
+		}
+		return fmt.Errorf("the execution is of the script action %q rather than %q", executionName, name)
+	}
+
+	if execution.Status == nil || !strings.EqualFold(*execution.Status, "Succeeded") {
+		return fmt.Errorf("only successful executions can be promoted:\n\n%s", formatHDInsightScriptActionFailure(execution))
+	}
+
+	return nil
+}
+
+// findHDInsightPersistedScriptAction returns the persisted script action with the name of the ID, or nil when it
+// isn't persisted on the cluster
+func findHDInsightPersistedScriptAction(ctx context.Context, client *hdinsight.ScriptActionsClient, id parse.ScriptActionId) (*hdinsight.RuntimeScriptActionDetail, error) {
+	iterator, err := client.ListByClusterComplete(ctx, id.ResourceGroup, id.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	for iterator.NotDone() {
+		scriptAction := iterator.Value()
+		if scriptAction.Name != nil && strings.EqualFold(*scriptAction.Name, id.Name) {
+			return &scriptAction, nil
+		}
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// latestHDInsightScriptActionExecution returns the most recent execution of the script action from the execution
+// history of the cluster, including the debug information which is only returned for the individual execution
+func latestHDInsightScriptActionExecution(ctx context.Context, meta interface{}, id parse.ScriptActionId) (*hdinsight.RuntimeScriptActionDetail, error) {
+	historyClient := meta.(*clients.Client).HDInsight.ScriptExecutionHistoryClient
+	scriptActionsClient := meta.(*clients.Client).HDInsight.ScriptActionsClient
+
+	iterator, err := historyClient.ListByClusterComplete(ctx, id.ResourceGroup, id.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]hdinsight.RuntimeScriptActionDetail, 0)
+	for iterator.NotDone() {
+		history = append(history, iterator.Value())
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	execution := findLatestHDInsightScriptActionExecution(history, id.Name)
+	if execution == nil || execution.ScriptExecutionID == nil {
+		return execution, nil
+	}
+
+	detail, err := scriptActionsClient.GetExecutionDetail(ctx, id.ResourceGroup, id.ClusterName, strconv.FormatInt(*execution.ScriptExecutionID, 10))
+	if err != nil {
+		log.Printf("[DEBUG] Unable to retrieve the Script Action execution %d of %s: %+v", *execution.ScriptExecutionID, id, err)
+		return execution, nil
+	}
+
+	return &detail, nil
+}
+
+// findLatestHDInsightScriptActionExecution returns the execution of the named script action with the highest execution
+// ID, since the history isn't returned in a stable order
+func findLatestHDInsightScriptActionExecution(history []hdinsight.RuntimeScriptActionDetail, name string) *hdinsight.RuntimeScriptActionDetail {
+	var latest *hdinsight.RuntimeScriptActionDetail
+	for i := range history {
+		execution := history[i]
+		if execution.Name == nil || !strings.EqualFold(*execution.Name, name) || execution.ScriptExecutionID == nil {
+			continue
+		}
+
+		if latest == nil || *execution.ScriptExecutionID > *latest.ScriptExecutionID {
+			latest = &execution
+		}
+	}
+
+	return latest
+}
+
+func hdinsightScriptActionExecutionFailed(input hdinsight.RuntimeScriptActionDetail) bool {
+	return input.Status != nil && strings.Contains(strings.ToLower(*input.Status), "fail")
+}
+
+func hdinsightScriptActionRoleNames() []string {
+	names := make([]string, 0, len(hdInsightScriptActionResourceRoles))
+	for name := range hdInsightScriptActionResourceRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func expandHDInsightScriptActionRoles(input []interface{}) *[]string {
+	roles := make([]string, 0)
+	for _, v := range input {
+		roles = append(roles, hdInsightScriptActionResourceRoles[v.(string)])
+	}
+	sort.Strings(roles)
+
+	return &roles
+}
+
+func flattenHDInsightScriptActionRoles(input *[]string) []interface{} {
+	output := make([]interface{}, 0)
+	if input == nil {
+		return output
+	}
+
+	for _, apiRole := range *input {
+		for role, v := range hdInsightScriptActionResourceRoles {
+			if strings.EqualFold(apiRole, v) {
+				output = append(output, role)
+				break
+			}
+		}
+	}
+
+	return output
+}
@@ -0,0 +1,252 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package hdinsight_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/acceptance/check"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/clients"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/services/hdinsight/parse"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
+
+type HDInsightScriptActionResource struct{}
+
+func TestAccHDInsightScriptAction_basic(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_script_action", "test")
+	r := HDInsightScriptActionResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.ImportStep(),
+	})
+}
+
+func TestAccHDInsightScriptAction_update(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_script_action", "test")
+	r := HDInsightScriptActionResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.ImportStep(),
+		{
+			Config: r.parameters(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("parameters").HasValue("--verbose"),
+			),
+		},
+		data.ImportStep(),
+	})
+}
+
+func TestAccHDInsightScriptAction_notPersisted(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_script_action", "test")
+	r := HDInsightScriptActionResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.notPersisted(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("persist_on_success").HasValue("false"),
+			),
+		},
+	})
+}
+
+func TestAccHDInsightScriptAction_promote(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_script_action", "test")
+	r := HDInsightScriptActionResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.notPersisted(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).Key("persist_on_success").HasValue("false"),
+			),
+		},
+		{
+			Config: r.promoted(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+				check.That(data.ResourceName).Key("persist_on_success").HasValue("true"),
+			),
+		},
+		data.ImportStep(),
+		{
+			Config: r.notPersisted(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).DoesNotExistInAzure(r),
+			),
+		},
+	})
+}
+
+func TestAccHDInsightScriptAction_requiresImport(t *testing.T) {
+	data := acceptance.BuildTestData(t, "azurerm_hdinsight_script_action", "test")
+	r := HDInsightScriptActionResource{}
+	data.ResourceTest(t, r, []acceptance.TestStep{
+		{
+			Config: r.basic(data),
+			Check: acceptance.ComposeTestCheckFunc(
+				check.That(data.ResourceName).ExistsInAzure(r),
+			),
+		},
+		data.RequiresImportErrorStep(r.requiresImport),
+	})
+}
+
+func (HDInsightScriptActionResource) Exists(ctx context.Context, clients *clients.Client, state *pluginsdk.InstanceState) (*bool, error) {
+	id, err := parse.ScriptActionID(state.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := clients.HDInsight.ScriptActionsClient.ListByClusterComplete(ctx, id.ResourceGroup, id.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("listing the persisted Script Actions for %s: %+v", id, err)
+	}
+
+	for iterator.NotDone() {
+		if v := iterator.Value(); v.Name != nil && strings.EqualFold(*v.Name, id.Name) {
+			return utils.Bool(true), nil
+		}
+
+		if err := iterator.NextWithContext(ctx); err != nil {
+			return nil, fmt.Errorf("listing the persisted Script Actions for %s: %+v", id, err)
+		}
+	}
+
+	return utils.Bool(false), nil
+}
+
+func (r HDInsightScriptActionResource) basic(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_script_action" "test" {
+  name       = "acctestsa-%d"
+  cluster_id = azurerm_hdinsight_spark_cluster.test.id
+  uri        = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  roles      = ["head_node", "worker_node"]
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightScriptActionResource) parameters(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_script_action" "test" {
+  name       = "acctestsa-%d"
+  cluster_id = azurerm_hdinsight_spark_cluster.test.id
+  uri        = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  parameters = "--verbose"
+  roles      = ["head_node", "worker_node"]
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightScriptActionResource) notPersisted(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_script_action" "test" {
+  name               = "acctestsa-%d"
+  cluster_id         = azurerm_hdinsight_spark_cluster.test.id
+  uri                = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  roles              = ["worker_node"]
+  persist_on_success = false
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightScriptActionResource) promoted(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_script_action" "test" {
+  name               = "acctestsa-%d"
+  cluster_id         = azurerm_hdinsight_spark_cluster.test.id
+  uri                = "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh"
+  roles              = ["worker_node"]
+  persist_on_success = true
+}
+`, r.template(data), data.RandomInteger)
+}
+
+func (r HDInsightScriptActionResource) requiresImport(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_script_action" "import" {
+  name       = azurerm_hdinsight_script_action.test.name
+  cluster_id = azurerm_hdinsight_script_action.test.cluster_id
+  uri        = azurerm_hdinsight_script_action.test.uri
+  roles      = azurerm_hdinsight_script_action.test.roles
+}
+`, r.basic(data))
+}
+
+func (HDInsightScriptActionResource) template(data acceptance.TestData) string {
+	return fmt.Sprintf(`
+%s
+
+resource "azurerm_hdinsight_spark_cluster" "test" {
+  name                = "acctesthdi-%d"
+  resource_group_name = azurerm_resource_group.test.name
+  location            = azurerm_resource_group.test.location
+  cluster_version     = "4.0"
+  tier                = "Standard"
+
+  component_version {
+    spark = "2.4"
+  }
+
+  gateway {
+    username = "acctestusrgw"
+    password = "TerrAform123!"
+  }
+
+  storage_account {
+    storage_container_id = azurerm_storage_container.test.id
+    storage_account_key  = azurerm_storage_account.test.primary_access_key
+    is_default           = true
+  }
+
+  roles {
+    head_node {
+      vm_size  = "Standard_A4_V2"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+
+    worker_node {
+      vm_size               = "Standard_A4_V2"
+      username              = "acctestusrvm"
+      password              = "AccTestvdSC4daf986!"
+      target_instance_count = 3
+    }
+
+    zookeeper_node {
+      vm_size  = "Medium"
+      username = "acctestusrvm"
+      password = "AccTestvdSC4daf986!"
+    }
+  }
+}
+`, HDInsightSparkClusterResource{}.template(data), data.RandomInteger)
+}
@@ -31,17 +31,24 @@ func (r Registration) WebsiteCategories() []string {
 // SupportedDataSources returns the supported Data Sources supported by this Service
 func (r Registration) SupportedDataSources() map[string]*pluginsdk.Resource {
 	return map[string]*pluginsdk.Resource{
-		"azurerm_hdinsight_cluster": dataSourceHDInsightSparkCluster(),
+		"azurerm_hdinsight_cluster":                          dataSourceHDInsightSparkCluster(),
+		"azurerm_hdinsight_cluster_applications":             dataSourceHDInsightClusterApplications(),
+		"azurerm_hdinsight_cluster_cost_estimate":            dataSourceHDInsightClusterCostEstimate(),
+		"azurerm_hdinsight_cluster_monitoring_status":        dataSourceHDInsightClusterMonitoringStatus(),
+		"azurerm_hdinsight_cluster_persisted_script_actions": dataSourceHDInsightClusterPersistedScriptActions(),
 	}
 }
 
 // SupportedResources returns the supported Resources supported by this Service
 func (r Registration) SupportedResources() map[string]*pluginsdk.Resource {
 	return map[string]*pluginsdk.Resource{
+		"azurerm_hdinsight_application":               resourceHDInsightApplication(),
 		"azurerm_hdinsight_hadoop_cluster":            resourceHDInsightHadoopCluster(),
 		"azurerm_hdinsight_hbase_cluster":             resourceHDInsightHBaseCluster(),
 		"azurerm_hdinsight_interactive_query_cluster": resourceHDInsightInteractiveQueryCluster(),
 		"azurerm_hdinsight_kafka_cluster":             resourceHDInsightKafkaCluster(),
+		"azurerm_hdinsight_monitoring":                resourceHDInsightMonitoring(),
+		"azurerm_hdinsight_script_action":             resourceHDInsightScriptAction(),
 		"azurerm_hdinsight_spark_cluster":             resourceHDInsightSparkCluster(),
 	}
 }
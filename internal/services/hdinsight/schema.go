@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
@@ -28,6 +30,24 @@ func SchemaHDInsightName() *pluginsdk.Schema {
 		Required:     true,
 		ForceNew:     true,
 		ValidateFunc: validate.HDInsightName,
+		// names differing only by case refer to the same cluster (and endpoints), so shouldn't replace the cluster
+		DiffSuppressFunc: func(_, old, new string, _ *pluginsdk.ResourceData) bool {
+			return validate.HDInsightEndpointDNSLabel(old) == validate.HDInsightEndpointDNSLabel(new)
+		},
+	}
+}
+
+func SchemaHDInsightEndpointDNSLabel() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeString,
+		Computed: true,
+	}
+}
+
+func SchemaHDInsightDefaultStorageEndpoint() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeString,
+		Computed: true,
 	}
 }
 
@@ -39,6 +59,75 @@ func SchemaHDInsightDataSourceName() *pluginsdk.Schema {
 	}
 }
 
+// SchemaHDInsightDataSourceAutoscale returns the computed schema for the autoscale configuration of the Worker Nodes,
+// mirroring the `autoscale` block of the clusters
+func SchemaHDInsightDataSourceAutoscale() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeList,
+		Computed: true,
+		Elem: &pluginsdk.Resource{
+			Schema: map[string]*pluginsdk.Schema{
+				"capacity": {
+					Type:     pluginsdk.TypeList,
+					Computed: true,
+					Elem: &pluginsdk.Resource{
+						Schema: map[string]*pluginsdk.Schema{
+							"min_instance_count": {
+								Type:     pluginsdk.TypeInt,
+								Computed: true,
+							},
+
+							"max_instance_count": {
+								Type:     pluginsdk.TypeInt,
+								Computed: true,
+							},
+						},
+					},
+				},
+
+				"recurrence": {
+					Type:     pluginsdk.TypeList,
+					Computed: true,
+					Elem: &pluginsdk.Resource{
+						Schema: map[string]*pluginsdk.Schema{
+							"timezone": {
+								Type:     pluginsdk.TypeString,
+								Computed: true,
+							},
+
+							"schedule": {
+								Type:     pluginsdk.TypeList,
+								Computed: true,
+								Elem: &pluginsdk.Resource{
+									Schema: map[string]*pluginsdk.Schema{
+										"days": {
+											Type:     pluginsdk.TypeList,
+											Computed: true,
+											Elem: &pluginsdk.Schema{
+												Type: pluginsdk.TypeString,
+											},
+										},
+
+										"time": {
+											Type:     pluginsdk.TypeString,
+											Computed: true,
+										},
+
+										"target_instance_count": {
+											Type:     pluginsdk.TypeInt,
+											Computed: true,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func SchemaHDInsightTier() *pluginsdk.Schema {
 	return &pluginsdk.Schema{
 		Type:     pluginsdk.TypeString,
@@ -47,7 +136,49 @@ func SchemaHDInsightTier() *pluginsdk.Schema {
 		ValidateFunc: validation.StringInSlice([]string{
 			string(hdinsight.TierStandard),
 			string(hdinsight.TierPremium),
-		}, false),
+		}, true),
+		DiffSuppressFunc: hdinsightTierDiffSuppressFunc,
+	}
+}
+
+func hdinsightTierDiffSuppressFunc(_, old, new string, _ *pluginsdk.ResourceData) bool {
+	return normalizeHDInsightTier(old) == normalizeHDInsightTier(new)
+}
+
+// normalizeHDInsightTier rewrites the tier into the casing we expect, since the Azure API is inconsistent here
+func normalizeHDInsightTier(input string) string {
+	for _, v := range hdinsight.PossibleTierValues() {
+		if strings.EqualFold(string(v), input) {
+			return string(v)
+		}
+	}
+
+	return input
+}
+
+func SchemaHDInsightEncryptionInTransitEnabled() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeBool,
+		Optional: true,
+		ForceNew: true,
+		Computed: true,
+	}
+}
+
+func SchemaHDInsightConfigurationExportOnDestroyPath() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:         pluginsdk.TypeString,
+		Optional:     true,
+		ValidateFunc: validation.StringIsNotEmpty,
+	}
+}
+
+// SchemaHDInsightDefaultStorageContainerCreatedByCluster is determined when the cluster is created, as once the cluster
+// exists the container can't be distinguished from one which existed beforehand
+func SchemaHDInsightDefaultStorageContainerCreatedByCluster() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeBool,
+		Computed: true,
 	}
 }
 
@@ -99,19 +230,36 @@ func SchemaHDInsightsGateway() *pluginsdk.Schema {
 		Elem: &pluginsdk.Resource{
 			Schema: map[string]*pluginsdk.Schema{
 				// NOTE: these are Required since if these aren't present you get a `500 bad request`
+				// the username can't be changed once the cluster exists, which is surfaced as an error
+				// during the plan (see `hdinsightClusterGatewayUsernameDiff`) rather than a replacement
 				"username": {
 					Type:     pluginsdk.TypeString,
 					Required: true,
-					ForceNew: true,
 				},
 				"password": {
 					Type:      pluginsdk.TypeString,
-					Required:  true,
+					Optional:  true,
 					Sensitive: true,
 					// Azure returns the key as *****. We'll suppress that here.
 					DiffSuppressFunc: func(k, old, new string, d *pluginsdk.ResourceData) bool {
 						return (new == d.Get(k).(string)) && (old == "*****")
 					},
+					ExactlyOneOf: []string{"gateway.0.password", "gateway.0.password_key_vault_secret_id"},
+				},
+
+				// the password is retrieved from the secret at apply time, so only the ID of the secret is stored in the state
+				// - since the secret isn't read during the plan a versioned ID should be used, so rotating the password is a change to the ID
+				"password_key_vault_secret_id": {
+					Type:         pluginsdk.TypeString,
+					Optional:     true,
+					ValidateFunc: keyVault.NestedItemIdWithOptionalVersion,
+					ExactlyOneOf: []string{"gateway.0.password", "gateway.0.password_key_vault_secret_id"},
+				},
+
+				"enabled": {
+					Type:     pluginsdk.TypeBool,
+					Optional: true,
+					Default:  true,
 				},
 			},
 		},
@@ -195,6 +343,45 @@ func SchemaHDInsightsExternalMetastores() *pluginsdk.Schema {
 	}
 }
 
+func SchemaHDInsightsYarnQueues() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type: pluginsdk.TypeList,
+		// the `capacity-scheduler` configuration can only be specified when creating the cluster, and the cluster's
+		// default queues are returned when it isn't specified
+		Optional: true,
+		Computed: true,
+		ForceNew: true,
+		Elem: &pluginsdk.Resource{
+			Schema: map[string]*pluginsdk.Schema{
+				"name": {
+					Type:     pluginsdk.TypeString,
+					Required: true,
+					ForceNew: true,
+					ValidateFunc: validation.StringMatch(
+						regexp.MustCompile(`^[a-zA-Z0-9_-]+$`),
+						"The name of the queue can only contain letters, numbers, underscores and hyphens",
+					),
+				},
+
+				"capacity": {
+					Type:         pluginsdk.TypeFloat,
+					Required:     true,
+					ForceNew:     true,
+					ValidateFunc: validation.FloatBetween(0, 100),
+				},
+
+				"max_capacity": {
+					Type:         pluginsdk.TypeFloat,
+					Optional:     true,
+					ForceNew:     true,
+					Default:      100,
+					ValidateFunc: validation.FloatBetween(0, 100),
+				},
+			},
+		},
+	}
+}
+
 func SchemaHDInsightsMonitor() *pluginsdk.Schema {
 	return &pluginsdk.Schema{
 		Type:     pluginsdk.TypeList,
@@ -234,14 +421,12 @@ func SchemaHDInsightsExtension() *pluginsdk.Schema {
 					Required:     true,
 					ValidateFunc: validation.IsUUID,
 				},
+				// Azure doesn't return the key, so the value from the config is kept so that rotating it can be detected
 				"primary_key": {
 					Type:         pluginsdk.TypeString,
 					Required:     true,
 					Sensitive:    true,
 					ValidateFunc: validation.StringIsNotEmpty,
-					DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
-						return (new == d.Get(k).(string)) && (old == "*****")
-					},
 				},
 			},
 		},
@@ -319,9 +504,11 @@ func SchemaHDInsightsSecurityProfile() *pluginsdk.Schema {
 					Required: true,
 					ForceNew: true,
 					Elem: &pluginsdk.Schema{
-						Type:         pluginsdk.TypeString,
-						ValidateFunc: validate.HDInsightClusterLdapsUrls,
+						Type:             pluginsdk.TypeString,
+						ValidateFunc:     validate.HDInsightClusterLdapsUrls,
+						DiffSuppressFunc: hdinsightNormalizedDiffSuppressFunc(normalizeHDInsightLdapsUrl),
 					},
+					Set: hdinsightLdapsUrlHash,
 				},
 
 				"msi_resource_id": {
@@ -336,15 +523,73 @@ func SchemaHDInsightsSecurityProfile() *pluginsdk.Schema {
 					Optional: true,
 					ForceNew: true,
 					Elem: &pluginsdk.Schema{
-						Type:         pluginsdk.TypeString,
-						ValidateFunc: validation.StringIsNotEmpty,
+						Type:             pluginsdk.TypeString,
+						ValidateFunc:     validation.StringIsNotEmpty,
+						DiffSuppressFunc: hdinsightNormalizedDiffSuppressFunc(normalizeHDInsightDistinguishedName),
 					},
+					Set: hdinsightDistinguishedNameHash,
 				},
 			},
 		},
 	}
 }
 
+// hdinsightSSHKeyHash hashes an SSH Public Key ignoring differences in whitespace, such as the trailing newline present
+// when the key is read using `file()`
+func hdinsightSSHKeyHash(v interface{}) int {
+	return pluginsdk.HashString(normalizeHDInsightSSHKey(v.(string)))
+}
+
+func normalizeHDInsightSSHKey(input string) string {
+	return strings.Join(strings.Fields(input), " ")
+}
+
+// hdinsightLdapsUrlHash hashes an LDAPS URL ignoring differences in casing and any trailing slash, which don't change
+// the domain controller being referenced
+func hdinsightLdapsUrlHash(v interface{}) int {
+	return pluginsdk.HashString(normalizeHDInsightLdapsUrl(v.(string)))
+}
+
+func normalizeHDInsightLdapsUrl(input string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(input)), "/")
+}
+
+// hdinsightDistinguishedNameHash hashes the Distinguished Name of a group ignoring differences in casing and in the
+// whitespace around the separators, since Active Directory treats these as the same group
+func hdinsightDistinguishedNameHash(v interface{}) int {
+	return pluginsdk.HashString(normalizeHDInsightDistinguishedName(v.(string)))
+}
+
+func normalizeHDInsightDistinguishedName(input string) string {
+	components := strings.Split(input, ",")
+	for i, component := range components {
+		if attribute, value, ok := strings.Cut(component, "="); ok {
+			component = strings.TrimSpace(attribute) + "=" + strings.TrimSpace(value)
+		}
+		components[i] = strings.ToLower(strings.TrimSpace(component))
+	}
+
+	return strings.Join(components, ",")
+}
+
+// hdinsightNormalizedDiffSuppressFunc is used for the elements of the sets hashed using the normalized values - since
+// elements with the same hash are otherwise diffed using their original values
+func hdinsightNormalizedDiffSuppressFunc(normalize func(string) string) pluginsdk.SchemaDiffSuppressFunc {
+	return func(_, old, new string, _ *pluginsdk.ResourceData) bool {
+		return normalize(old) == normalize(new)
+	}
+}
+
+// sortedHDInsightStrings returns the normalized values from a set in a sorted order
+func sortedHDInsightStrings(input []interface{}, normalize func(string) string) []string {
+	output := make([]string, 0, len(input))
+	for _, v := range input {
+		output = append(output, normalize(v.(string)))
+	}
+	sort.Strings(output)
+	return output
+}
+
 func SchemaHDInsightsScriptActions() *pluginsdk.Schema {
 	return &pluginsdk.Schema{
 		Type:     pluginsdk.TypeList,
@@ -362,7 +607,7 @@ func SchemaHDInsightsScriptActions() *pluginsdk.Schema {
 				"uri": {
 					Type:         pluginsdk.TypeString,
 					Required:     true,
-					ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+					ValidateFunc: validate.ScriptActionURI,
 				},
 
 				"parameters": {
@@ -375,6 +620,123 @@ func SchemaHDInsightsScriptActions() *pluginsdk.Schema {
 	}
 }
 
+// SchemaHDInsightsRolesScriptActions returns the schema for the script actions run on the nodes of a role, which can
+// optionally only be run when the cluster is provisioned rather than also on the nodes added when scaling out
+func SchemaHDInsightsRolesScriptActions() *pluginsdk.Schema {
+	s := SchemaHDInsightsScriptActions()
+	s.Elem.(*pluginsdk.Resource).Schema["persisted"] = &pluginsdk.Schema{
+		Type:     pluginsdk.TypeBool,
+		Optional: true,
+		Default:  true,
+	}
+
+	return s
+}
+
+// SchemaHDInsightUnmanagedPersistedScriptActions returns the schema for the script actions persisted on the cluster which
+// aren't specified in the `script_actions` of the roles - such as those persisted using the portal or the CLI, or using
+// the `azurerm_hdinsight_script_action` resource. These are exposed separately since the `script_actions` of the roles
+// can only be specified when creating the cluster.
+func SchemaHDInsightUnmanagedPersistedScriptActions() *pluginsdk.Schema {
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeList,
+		Computed: true,
+		Elem: &pluginsdk.Resource{
+			Schema: map[string]*pluginsdk.Schema{
+				"name": {
+					Type:     pluginsdk.TypeString,
+					Computed: true,
+				},
+
+				"uri": {
+					Type:     pluginsdk.TypeString,
+					Computed: true,
+				},
+
+				"parameters": {
+					Type:     pluginsdk.TypeString,
+					Computed: true,
+				},
+
+				"roles": {
+					Type:     pluginsdk.TypeList,
+					Computed: true,
+					Elem: &pluginsdk.Schema{
+						Type: pluginsdk.TypeString,
+					},
+				},
+			},
+		},
+	}
+}
+
+// SchemaHDInsightEdgeNode returns the schema for the edge nodes of the cluster, which (unlike the other roles) are
+// deployed as an HDInsight Application once the cluster exists - one Application per `edge_node` block, so that each
+// of them can be added, changed or removed without touching the cluster or the other edge nodes
+func SchemaHDInsightEdgeNode(clusterKind string) *pluginsdk.Schema {
+	// the uninstall script actions are run when the Application is deleted, so unlike those of the cluster these can
+	// be changed by recreating the edge node
+	uninstallScriptActions := SchemaHDInsightsScriptActions()
+	uninstallScriptActions.ForceNew = false
+
+	return &pluginsdk.Schema{
+		Type:     pluginsdk.TypeList,
+		Optional: true,
+		Elem: &pluginsdk.Resource{
+			Schema: map[string]*pluginsdk.Schema{
+				// edge nodes without a name are deployed as an Application named after the cluster, which is how the
+				// single edge node supported previously was deployed
+				"name": {
+					Type:         pluginsdk.TypeString,
+					Optional:     true,
+					ValidateFunc: validate.HDInsightName,
+				},
+
+				"target_instance_count": {
+					Type:         pluginsdk.TypeInt,
+					Required:     true,
+					ValidateFunc: validate.NodeDefinitionInstanceCount(clusterKind, "edge_node", 1, 25),
+				},
+
+				"vm_size": {
+					Type:         pluginsdk.TypeString,
+					Required:     true,
+					ValidateFunc: validate.NodeDefinitionVMSizeIn(validate.NodeDefinitionVMSize, "edge_node"),
+				},
+
+				"install_script_action": {
+					Type:     pluginsdk.TypeList,
+					Required: true,
+					MinItems: 1,
+					Elem: &pluginsdk.Resource{
+						Schema: map[string]*pluginsdk.Schema{
+							"name": {
+								Type:         pluginsdk.TypeString,
+								Required:     true,
+								ValidateFunc: validation.StringIsNotEmpty,
+							},
+							"uri": {
+								Type:         pluginsdk.TypeString,
+								Required:     true,
+								ValidateFunc: validate.ScriptActionURI,
+							},
+							"parameters": {
+								Type:         pluginsdk.TypeString,
+								Optional:     true,
+								ValidateFunc: validation.StringIsNotEmpty,
+							},
+						},
+					},
+				},
+
+				"https_endpoints": SchemaHDInsightsHttpsEndpoints(),
+
+				"uninstall_script_actions": uninstallScriptActions,
+			},
+		},
+	}
+}
+
 func SchemaHDInsightsHttpsEndpoints() *pluginsdk.Schema {
 	return &pluginsdk.Schema{
 		Type:     pluginsdk.TypeList,
@@ -388,6 +750,13 @@ func SchemaHDInsightsHttpsEndpoints() *pluginsdk.Schema {
 						Type:         pluginsdk.TypeString,
 						ValidateFunc: validation.StringIsNotEmpty,
 					},
+					// HDInsight populates the access modes of the endpoint when these aren't specified
+					DiffSuppressFunc: func(k, _, new string, _ *pluginsdk.ResourceData) bool {
+						if strings.HasSuffix(k, ".#") {
+							return new == "0"
+						}
+						return new == ""
+					},
 				},
 
 				"destination_port": {
@@ -405,6 +774,10 @@ func SchemaHDInsightsHttpsEndpoints() *pluginsdk.Schema {
 					Type:         pluginsdk.TypeString,
 					Optional:     true,
 					ValidateFunc: validation.IsIPAddress,
+					// HDInsight assigns a private IP address to the endpoint when this isn't specified
+					DiffSuppressFunc: func(_, _, new string, _ *pluginsdk.ResourceData) bool {
+						return new == ""
+					},
 				},
 
 				"sub_domain_suffix": {
@@ -465,11 +838,20 @@ func ExpandHDInsightComputeIsolationProperties(input []interface{}) *hdinsight.C
 	}
 }
 
+func ExpandHDInsightEncryptionInTransitProperties(enabled bool) *hdinsight.EncryptionInTransitProperties {
+	return &hdinsight.EncryptionInTransitProperties{
+		IsEncryptionInTransitEnabled: utils.Bool(enabled),
+	}
+}
+
 func ExpandHDInsightsConfigurations(input []interface{}) map[string]interface{} {
 	vs := input[0].(map[string]interface{})
 
 	// NOTE: Admin username must be different from SSH Username
 	enabled := true
+	if v, ok := vs["enabled"]; ok {
+		enabled = v.(bool)
+	}
 	username := vs["username"].(string)
 	password := vs["password"].(string)
 
@@ -482,6 +864,72 @@ func ExpandHDInsightsConfigurations(input []interface{}) map[string]interface{}
 	}
 }
 
+// ExpandHDInsightsYarnQueues returns the `capacity-scheduler` configuration defining the queues beneath the root queue
+func ExpandHDInsightsYarnQueues(input []interface{}) map[string]interface{} {
+	if len(input) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0)
+	capacityScheduler := make(map[string]interface{})
+	for _, raw := range input {
+		if raw == nil {
+			continue
+		}
+		v := raw.(map[string]interface{})
+
+		name := v["name"].(string)
+		names = append(names, name)
+		capacityScheduler[fmt.Sprintf("yarn.scheduler.capacity.root.%s.capacity", name)] = strconv.FormatFloat(v["capacity"].(float64), 'f', -1, 64)
+		capacityScheduler[fmt.Sprintf("yarn.scheduler.capacity.root.%s.maximum-capacity", name)] = strconv.FormatFloat(v["max_capacity"].(float64), 'f', -1, 64)
+	}
+	capacityScheduler["yarn.scheduler.capacity.root.queues"] = strings.Join(names, ",")
+
+	return map[string]interface{}{
+		"capacity-scheduler": capacityScheduler,
+	}
+}
+
+// FlattenHDInsightsYarnQueues returns the queues beneath the root queue from the `capacity-scheduler` configuration, in
+// the order they're listed in `yarn.scheduler.capacity.root.queues`
+func FlattenHDInsightsYarnQueues(capacityScheduler map[string]*string) []interface{} {
+	queues := capacityScheduler["yarn.scheduler.capacity.root.queues"]
+	if queues == nil || strings.TrimSpace(*queues) == "" {
+		return nil
+	}
+
+	output := make([]interface{}, 0)
+	for _, name := range strings.Split(*queues, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		capacity := 0.0
+		if v := capacityScheduler[fmt.Sprintf("yarn.scheduler.capacity.root.%s.capacity", name)]; v != nil {
+			if parsed, err := strconv.ParseFloat(*v, 64); err == nil {
+				capacity = parsed
+			}
+		}
+
+		// YARN defaults the maximum capacity of a queue to 100% when it isn't specified
+		maxCapacity := 100.0
+		if v := capacityScheduler[fmt.Sprintf("yarn.scheduler.capacity.root.%s.maximum-capacity", name)]; v != nil {
+			if parsed, err := strconv.ParseFloat(*v, 64); err == nil && parsed >= 0 {
+				maxCapacity = parsed
+			}
+		}
+
+		output = append(output, map[string]interface{}{
+			"name":         name,
+			"capacity":     capacity,
+			"max_capacity": maxCapacity,
+		})
+	}
+
+	return output
+}
+
 func ExpandHDInsightsHiveMetastore(input []interface{}) map[string]interface{} {
 	if len(input) == 0 {
 		return nil
@@ -598,6 +1046,23 @@ func ExpandHDInsightsNetwork(input []interface{}) *hdinsight.NetworkProperties {
 	}
 }
 
+// FlattenHDInsightEncryptionInTransitProperties returns whether encryption in transit is enabled - the API omits these
+// properties for clusters where it was never enabled, so this defaults to false to ensure drift is detected
+func FlattenHDInsightEncryptionInTransitProperties(input *hdinsight.EncryptionInTransitProperties) bool {
+	if input == nil || input.IsEncryptionInTransitEnabled == nil {
+		return false
+	}
+
+	return *input.IsEncryptionInTransitEnabled
+}
+
+// FlattenHDInsightCustomerManagedKeyEnabled returns whether the disks of the cluster are encrypted using a customer-managed
+// key from a Key Vault rather than platform-managed keys - the disk encryption properties are also returned when only
+// encryption at host is enabled, in which case there's no key
+func FlattenHDInsightCustomerManagedKeyEnabled(input *hdinsight.DiskEncryptionProperties) bool {
+	return input != nil && input.KeyName != nil && *input.KeyName != ""
+}
+
 func FlattenHDInsightComputeIsolationProperties(input hdinsight.ComputeIsolationProperties) []interface{} {
 	var hostSku string
 	var enableComputeIsolation bool
@@ -657,11 +1122,30 @@ func FlattenHDInsightsConfigurations(input map[string]*string, d *pluginsdk.Reso
 		password = d.Get("gateway.0.password").(string)
 	}
 
+	enabled := true
+	if v, exists := input["restAuthCredential.isEnabled"]; exists && v != nil {
+		if val, err := strconv.ParseBool(*v); err == nil {
+			enabled = val
+		}
+	}
+
 	out := map[string]interface{}{
+		"enabled":  enabled,
 		"username": username,
 		"password": password,
 	}
 
+	// when the password comes from Key Vault only the ID of the secret is tracked, rather than the password itself - the
+	// Data Source doesn't expose `password_key_vault_secret_id` so this is only set for the Resources
+	if d != nil {
+		if secretId, ok := d.Get("gateway.0.password_key_vault_secret_id").(string); ok {
+			out["password_key_vault_secret_id"] = secretId
+			if secretId != "" {
+				out["password"] = ""
+			}
+		}
+	}
+
 	return []interface{}{out}
 }
 
@@ -776,10 +1260,11 @@ func SchemaHDInsightsStorageAccounts() *pluginsdk.Schema {
 		Optional: true,
 		Elem: &pluginsdk.Resource{
 			Schema: map[string]*pluginsdk.Schema{
+				// the storage profile of the cluster can't be updated, however replacing the cluster when the key is
+				// rotated isn't necessary - so changes to the key are only recorded in the state
 				"storage_account_key": {
 					Type:         pluginsdk.TypeString,
 					Required:     true,
-					ForceNew:     true,
 					Sensitive:    true,
 					ValidateFunc: validation.StringIsNotEmpty,
 				},
@@ -787,7 +1272,7 @@ func SchemaHDInsightsStorageAccounts() *pluginsdk.Schema {
 					Type:         pluginsdk.TypeString,
 					Required:     true,
 					ForceNew:     true,
-					ValidateFunc: validation.StringIsNotEmpty,
+					ValidateFunc: validate.HDInsightStorageContainerID,
 				},
 				// TODO: this should become `storage_account_id` in 4.0
 				"storage_resource_id": {
@@ -825,7 +1310,7 @@ func SchemaHDInsightsGen2StorageAccounts() *pluginsdk.Schema {
 					Type:         pluginsdk.TypeString,
 					Required:     true,
 					ForceNew:     true,
-					ValidateFunc: validation.StringIsNotEmpty,
+					ValidateFunc: validate.HDInsightStorageFilesystemID,
 				},
 				// TODO: this should become `user_assigned_identity_id` in 4.0
 				"managed_identity_resource_id": {
@@ -925,7 +1410,9 @@ func FlattenHDInsightsDiskEncryptionProperties(input hdinsight.DiskEncryptionPro
 	if input.KeyVersion != nil {
 		keyVersion = *input.KeyVersion
 	}
-	msiResourceId = *input.MsiResourceID
+	if input.MsiResourceID != nil {
+		msiResourceId = *input.MsiResourceID
+	}
 
 	if keyName != "" || keyVersion != "" {
 		keyVaultKeyIdRaw, err := parse.NewNestedItemID(*input.VaultURI, parse.NestedItemTypeKey, keyName, keyVersion)
@@ -947,12 +1434,19 @@ func FlattenHDInsightsDiskEncryptionProperties(input hdinsight.DiskEncryptionPro
 
 // ExpandHDInsightsStorageAccounts returns an array of StorageAccount structs, as well as a ClusterIdentity
 // populated with any managed identities required for accessing Data Lake Gen2 storage.
+//
+// Every configured account is returned, with the default account first regardless of whether it's a Blob
+// Storage (WASB) or Data Lake Gen2 account, so that additional accounts are always configured alongside it.
 func ExpandHDInsightsStorageAccounts(storageAccounts []interface{}, gen2storageAccounts []interface{}) (*[]hdinsight.StorageAccount, *hdinsight.ClusterIdentity, error) {
-	results := make([]hdinsight.StorageAccount, 0)
+	defaults := make([]hdinsight.StorageAccount, 0)
+	additional := make([]hdinsight.StorageAccount, 0)
 
 	var clusterIndentity *hdinsight.ClusterIdentity
 
 	for _, vs := range storageAccounts {
+		if vs == nil {
+			continue
+		}
 		v := vs.(map[string]interface{})
 
 		storageAccountKey := v["storage_account_key"].(string)
@@ -966,16 +1460,27 @@ func ExpandHDInsightsStorageAccounts(storageAccounts []interface{}, gen2storageA
 		}
 
 		result := hdinsight.StorageAccount{
-			Name:       utils.String(uri.Host),
-			ResourceID: utils.String(storageResourceID),
-			Container:  utils.String(strings.TrimPrefix(uri.Path, "/")),
-			Key:        utils.String(storageAccountKey),
-			IsDefault:  utils.Bool(isDefault),
+			Name:      utils.String(uri.Host),
+			Container: utils.String(strings.TrimPrefix(uri.Path, "/")),
+			Key:       utils.String(storageAccountKey),
+			IsDefault: utils.Bool(isDefault),
+		}
+		// an empty Resource ID causes the API to ignore the account when it isn't the default
+		if storageResourceID != "" {
+			result.ResourceID = utils.String(storageResourceID)
+		}
+
+		if isDefault {
+			defaults = append(defaults, result)
+		} else {
+			additional = append(additional, result)
 		}
-		results = append(results, result)
 	}
 
 	for _, vs := range gen2storageAccounts {
+		if vs == nil {
+			continue
+		}
 		v := vs.(map[string]interface{})
 
 		fileSystemID := v["filesystem_id"].(string)
@@ -1002,17 +1507,25 @@ func ExpandHDInsightsStorageAccounts(storageAccounts []interface{}, gen2storageA
 		result := hdinsight.StorageAccount{
 			Name:          utils.String(uri.Host), // https://storageaccountname.dfs.core.windows.net/filesystemname -> storageaccountname.dfs.core.windows.net
 			ResourceID:    utils.String(storageResourceID),
-			FileSystem:    utils.String(uri.Path[1:]), // https://storageaccountname.dfs.core.windows.net/filesystemname -> filesystemname
+			FileSystem:    utils.String(strings.TrimPrefix(uri.Path, "/")), // https://storageaccountname.dfs.core.windows.net/filesystemname -> filesystemname
 			MsiResourceID: utils.String(managedIdentityResourceID),
 			IsDefault:     utils.Bool(isDefault),
 		}
-		results = append(results, result)
+
+		if isDefault {
+			defaults = append(defaults, result)
+		} else {
+			additional = append(additional, result)
+		}
 	}
 
+	results := append(defaults, additional...)
 	return &results, clusterIndentity, nil
 }
 
 type HDInsightNodeDefinition struct {
+	// ClusterKind is the kind of cluster this role belongs to, which is used to name the per-kind limits in errors
+	ClusterKind              string
 	CanSpecifyInstanceCount  bool
 	MinInstanceCount         int
 	MaxInstanceCount         *int
@@ -1022,17 +1535,27 @@ type HDInsightNodeDefinition struct {
 	FixedTargetInstanceCount *int32
 	CanAutoScaleByCapacity   bool
 	CanAutoScaleOnSchedule   bool
+	// VMSizes optionally limits the `vm_size` to a subset of the VM SKU's supported by HDInsight
+	VMSizes []string
+	// RecommendedMinInstanceCount is the number of nodes HDInsight recommends running at least for this role - fewer
+	// nodes are accepted (down to MinInstanceCount) but are only warned about when planning
+	RecommendedMinInstanceCount int
 	// todo remove in 4.0
 	CanAutoScaleByCapacityDeprecated4PointOh bool
 }
 
 func SchemaHDInsightNodeDefinition(schemaLocation string, definition HDInsightNodeDefinition, required bool) *pluginsdk.Schema {
+	vmSizes := validate.NodeDefinitionVMSize
+	if len(definition.VMSizes) > 0 {
+		vmSizes = definition.VMSizes
+	}
+
 	result := map[string]*pluginsdk.Schema{
 		"vm_size": {
 			Type:         pluginsdk.TypeString,
 			Required:     true,
 			ForceNew:     true,
-			ValidateFunc: validation.StringInSlice(validate.NodeDefinitionVMSize, false),
+			ValidateFunc: validate.NodeDefinitionVMSizeIn(vmSizes, strings.TrimPrefix(schemaLocation, "roles.0.")),
 		},
 		"username": {
 			Type:     pluginsdk.TypeString,
@@ -1045,53 +1568,80 @@ func SchemaHDInsightNodeDefinition(schemaLocation string, definition HDInsightNo
 			ForceNew:  true,
 			Sensitive: true,
 		},
+		"password_key_vault_secret_id": {
+			Type:         pluginsdk.TypeString,
+			Optional:     true,
+			ForceNew:     true,
+			ValidateFunc: keyVault.NestedItemIdWithOptionalVersion,
+			ConflictsWith: []string{
+				fmt.Sprintf("%s.0.password", schemaLocation),
+			},
+		},
 		"ssh_keys": {
 			Type:     pluginsdk.TypeSet,
 			Optional: true,
 			ForceNew: true,
 			Elem: &pluginsdk.Schema{
-				Type:         pluginsdk.TypeString,
-				ValidateFunc: validation.StringIsNotEmpty,
+				Type:             pluginsdk.TypeString,
+				ValidateFunc:     validation.StringIsNotEmpty,
+				DiffSuppressFunc: hdinsightNormalizedDiffSuppressFunc(normalizeHDInsightSSHKey),
 			},
-			Set: pluginsdk.HashString,
+			Set: hdinsightSSHKeyHash,
 			ConflictsWith: []string{
 				fmt.Sprintf("%s.0.password", schemaLocation),
+				fmt.Sprintf("%s.0.password_key_vault_secret_id", schemaLocation),
 			},
 		},
 
 		"subnet_id": {
-			Type:         pluginsdk.TypeString,
-			Optional:     true,
-			ForceNew:     true,
-			ValidateFunc: commonids.ValidateSubnetID,
+			Type:             pluginsdk.TypeString,
+			Optional:         true,
+			ForceNew:         true,
+			ValidateFunc:     commonids.ValidateSubnetID,
+			DiffSuppressFunc: hdinsightResourceIdDiffSuppressFunc,
 		},
 
+		// the Virtual Network can be derived from the `subnet_id`, in which case the derived value is tracked in the state
 		"virtual_network_id": {
-			Type:         pluginsdk.TypeString,
-			Optional:     true,
-			ForceNew:     true,
-			ValidateFunc: commonids.ValidateVirtualNetworkID,
+			Type:             pluginsdk.TypeString,
+			Optional:         true,
+			Computed:         true,
+			ForceNew:         true,
+			ValidateFunc:     commonids.ValidateVirtualNetworkID,
+			DiffSuppressFunc: hdinsightResourceIdDiffSuppressFunc,
+			RequiredWith: []string{
+				fmt.Sprintf("%s.0.subnet_id", schemaLocation),
+			},
 		},
 
-		"script_actions": SchemaHDInsightsScriptActions(),
+		"script_actions": SchemaHDInsightsRolesScriptActions(),
 	}
 
 	if definition.CanSpecifyInstanceCount {
-		countValidation := validation.IntAtLeast(definition.MinInstanceCount)
+		maxInstanceCount := 0
 		if definition.MaxInstanceCount != nil {
-			countValidation = validation.IntBetween(definition.MinInstanceCount, *definition.MaxInstanceCount)
+			maxInstanceCount = *definition.MaxInstanceCount
 		}
+		countValidation := validate.NodeDefinitionInstanceCount(definition.ClusterKind, strings.TrimPrefix(schemaLocation, "roles.0."), definition.MinInstanceCount, maxInstanceCount)
 
 		result["target_instance_count"] = &pluginsdk.Schema{
 			Type:         pluginsdk.TypeInt,
 			Required:     true,
 			ValidateFunc: countValidation,
 		}
+		if definition.CanAutoScaleByCapacity || definition.CanAutoScaleOnSchedule {
+			// once autoscale is enabled the number of nodes is managed by Azure, so this can be omitted - which is
+			// required when autoscale isn't configured (see `hdinsightClusterWorkerTargetInstanceCountDiff`)
+			result["target_instance_count"].Required = false
+			result["target_instance_count"].Optional = true
+			result["target_instance_count"].Computed = true
+			result["target_instance_count"].DiffSuppressFunc = hdinsightAutoscaleTargetInstanceCountDiffSuppressFunc(schemaLocation)
+		}
 
 		if definition.CanAutoScaleByCapacity || definition.CanAutoScaleOnSchedule {
 			autoScales := map[string]*pluginsdk.Schema{}
 
-			if definition.CanAutoScaleByCapacity {
+			if definition.CanAutoScaleByCapacity || definition.CanAutoScaleByCapacityDeprecated4PointOh {
 				autoScales["capacity"] = &pluginsdk.Schema{
 					Type:     pluginsdk.TypeList,
 					Optional: true,
@@ -1119,36 +1669,9 @@ func SchemaHDInsightNodeDefinition(schemaLocation string, definition HDInsightNo
 						fmt.Sprintf("%s.0.autoscale.0.recurrence", schemaLocation),
 					}
 				}
-			}
-			// managing `azurerm_hdinsight_interactive_query_cluster` autoscaling through `capacity` doesn't work so we'll deprecate this portion of the schema for 4.0
-			if definition.CanAutoScaleByCapacityDeprecated4PointOh {
-				autoScales["capacity"] = &pluginsdk.Schema{
-					Type:     pluginsdk.TypeList,
-					Optional: true,
-					MaxItems: 1,
-					ConflictsWith: []string{
-						fmt.Sprintf("%s.0.autoscale.0.recurrence", schemaLocation),
-					},
-					Deprecated: "HDInsight interactive query clusters can no longer be configured through `autoscale.0.capacity`. Use `autoscale.0.recurrence` instead.",
-					Elem: &pluginsdk.Resource{
-						Schema: map[string]*pluginsdk.Schema{
-							"min_instance_count": {
-								Type:         pluginsdk.TypeInt,
-								Required:     true,
-								ValidateFunc: countValidation,
-							},
-							"max_instance_count": {
-								Type:         pluginsdk.TypeInt,
-								Required:     true,
-								ValidateFunc: countValidation,
-							},
-						},
-					},
-				}
-				if definition.CanAutoScaleOnSchedule {
-					autoScales["capacity"].ConflictsWith = []string{
-						fmt.Sprintf("%s.0.autoscale.0.recurrence", schemaLocation),
-					}
+				// managing `azurerm_hdinsight_interactive_query_cluster` autoscaling through `capacity` doesn't work so we'll deprecate this portion of the schema for 4.0
+				if definition.CanAutoScaleByCapacityDeprecated4PointOh {
+					autoScales["capacity"].Deprecated = "HDInsight interactive query clusters can no longer be configured through `autoscale.0.capacity`. Use `autoscale.0.recurrence` instead."
 				}
 			}
 			if definition.CanAutoScaleOnSchedule {
@@ -1159,8 +1682,13 @@ func SchemaHDInsightNodeDefinition(schemaLocation string, definition HDInsightNo
 					Elem: &pluginsdk.Resource{
 						Schema: map[string]*pluginsdk.Schema{
 							"timezone": {
-								Type:     pluginsdk.TypeString,
-								Required: true,
+								Type:         pluginsdk.TypeString,
+								Required:     true,
+								ValidateFunc: validate.AutoscaleRecurrenceTimeZone,
+								// the Time Zone is sent to the API using the canonical casing
+								DiffSuppressFunc: func(_, old, new string, _ *pluginsdk.ResourceData) bool {
+									return strings.EqualFold(old, new)
+								},
 							},
 							"schedule": {
 								Type:     pluginsdk.TypeList,
@@ -1244,6 +1772,31 @@ func SchemaHDInsightNodeDefinition(schemaLocation string, definition HDInsightNo
 	return s
 }
 
+// hdinsightAutoscaleTargetInstanceCountDiffSuppressFunc suppresses changes to the `target_instance_count` of an
+// existing cluster whilst either load-based or schedule-based autoscale is enabled, since the number of nodes is then
+// managed by the autoscaler - the number of nodes currently running is exposed as `current_worker_count` instead
+func hdinsightAutoscaleTargetInstanceCountDiffSuppressFunc(schemaLocation string) pluginsdk.SchemaDiffSuppressFunc {
+	return func(_, old, new string, d *pluginsdk.ResourceData) bool {
+		if old == "" || old == new {
+			return old == new
+		}
+
+		for _, mode := range []string{"capacity", "recurrence"} {
+			if v, _ := d.Get(fmt.Sprintf("%s.0.autoscale.0.%s", schemaLocation, mode)).([]interface{}); len(v) > 0 {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// hdinsightResourceIdDiffSuppressFunc suppresses differences in the casing of the Resource IDs referenced by the roles,
+// which the API can return with a different casing - since otherwise these would replace the cluster
+func hdinsightResourceIdDiffSuppressFunc(_, old, new string, _ *pluginsdk.ResourceData) bool {
+	return strings.EqualFold(old, new)
+}
+
 func ExpandHDInsightNodeDefinition(name string, input []interface{}, definition HDInsightNodeDefinition) (*hdinsight.Role, error) {
 	v := input[0].(map[string]interface{})
 	vmSize := v["vm_size"].(string)
@@ -1266,30 +1819,25 @@ func ExpandHDInsightNodeDefinition(name string, input []interface{}, definition
 		ScriptActions: ExpandHDInsightsRolesScriptActions(scriptActions),
 	}
 
-	virtualNetworkSpecified := virtualNetworkId != ""
-	subnetSpecified := subnetId != ""
-	if virtualNetworkSpecified && subnetSpecified {
-		role.VirtualNetworkProfile = &hdinsight.VirtualNetworkProfile{
-			ID:     utils.String(virtualNetworkId),
-			Subnet: utils.String(subnetId),
-		}
-	} else if (virtualNetworkSpecified && !subnetSpecified) || (subnetSpecified && !virtualNetworkSpecified) {
-		return nil, fmt.Errorf("`virtual_network_id` and `subnet_id` must both either be set or empty")
+	virtualNetworkProfile, err := expandHDInsightVirtualNetworkProfile(virtualNetworkId, subnetId)
+	if err != nil {
+		return nil, err
 	}
+	role.VirtualNetworkProfile = virtualNetworkProfile
 
 	if password != "" {
 		role.OsProfile.LinuxOperatingSystemProfile.Password = utils.String(password)
 	} else {
 		sshKeysRaw := v["ssh_keys"].(*pluginsdk.Set).List()
 		sshKeys := make([]hdinsight.SSHPublicKey, 0)
-		for _, v := range sshKeysRaw {
+		for _, v := range sortedHDInsightStrings(sshKeysRaw, normalizeHDInsightSSHKey) {
 			sshKeys = append(sshKeys, hdinsight.SSHPublicKey{
-				CertificateData: utils.String(v.(string)),
+				CertificateData: utils.String(v),
 			})
 		}
 
 		if len(sshKeys) == 0 {
-			return nil, fmt.Errorf("either a `password` or `ssh_key` must be specified")
+			return nil, fmt.Errorf("either a `password`, `password_key_vault_secret_id` or `ssh_key` must be specified")
 		}
 
 		role.OsProfile.LinuxOperatingSystemProfile.SSHProfile = &hdinsight.SSHProfile{
@@ -1299,15 +1847,21 @@ func ExpandHDInsightNodeDefinition(name string, input []interface{}, definition
 
 	if definition.CanSpecifyInstanceCount {
 		targetInstanceCount := v["target_instance_count"].(int)
-		role.TargetInstanceCount = utils.Int32(int32(targetInstanceCount))
 
 		if definition.CanAutoScaleByCapacity || definition.CanAutoScaleOnSchedule {
 			autoscaleRaw := v["autoscale"].([]interface{})
 			autoscale := ExpandHDInsightNodeAutoScaleDefinition(autoscaleRaw)
 			if autoscale != nil {
 				role.AutoscaleConfiguration = autoscale
+
+				// the `target_instance_count` can be omitted when autoscale is configured
+				if targetInstanceCount == 0 {
+					targetInstanceCount = hdinsightAutoscaleInitialInstanceCount(autoscale)
+				}
 			}
 		}
+
+		role.TargetInstanceCount = utils.Int32(int32(targetInstanceCount))
 	} else {
 		role.MinInstanceCount = definition.FixedMinInstanceCount
 		role.TargetInstanceCount = definition.FixedTargetInstanceCount
@@ -1358,6 +1912,33 @@ func ExpandHDInsightNodeAutoScaleDefinition(input []interface{}) *hdinsight.Auto
 	return nil
 }
 
+// hdinsightAutoscaleInitialInstanceCount returns the number of nodes to provision when the `target_instance_count` is
+// omitted - which is the minimum number of nodes for capacity autoscale, or the smallest number of nodes across the
+// schedules for recurrence autoscale (since autoscale then scales the cluster as required)
+func hdinsightAutoscaleInitialInstanceCount(input *hdinsight.Autoscale) int {
+	if input == nil {
+		return 0
+	}
+
+	if input.Capacity != nil && input.Capacity.MinInstanceCount != nil {
+		return int(*input.Capacity.MinInstanceCount)
+	}
+
+	count := 0
+	if input.Recurrence != nil && input.Recurrence.Schedule != nil {
+		for _, schedule := range *input.Recurrence.Schedule {
+			if schedule.TimeAndCapacity == nil || schedule.TimeAndCapacity.MinInstanceCount == nil {
+				continue
+			}
+			if v := int(*schedule.TimeAndCapacity.MinInstanceCount); count == 0 || v < count {
+				count = v
+			}
+		}
+	}
+
+	return count
+}
+
 func ExpandHDInsightAutoscaleCapacityDefinition(input []interface{}) *hdinsight.AutoscaleCapacity {
 	if len(input) == 0 || input[0] == nil {
 		return nil
@@ -1401,7 +1982,7 @@ func ExpandHDInsightAutoscaleRecurrenceDefinition(input []interface{}) *hdinsigh
 	}
 
 	result := &hdinsight.AutoscaleRecurrence{
-		TimeZone: utils.String(vs["timezone"].(string)),
+		TimeZone: utils.String(normalizeHDInsightAutoscaleTimeZone(vs["timezone"].(string))),
 		Schedule: &schedules,
 	}
 
@@ -1415,10 +1996,13 @@ func ExpandHDInsightSecurityProfile(input []interface{}) *hdinsight.SecurityProf
 
 	v := input[0].(map[string]interface{})
 
+	// the values are sorted so that the request doesn't depend on the order of the sets
+	ldapsUrls := sortedHDInsightStrings(v["ldaps_urls"].(*pluginsdk.Set).List(), strings.TrimSpace)
+
 	result := hdinsight.SecurityProfile{
 		DirectoryType:      hdinsight.DirectoryTypeActiveDirectory,
 		Domain:             utils.String(v["domain_name"].(string)),
-		LdapsUrls:          utils.ExpandStringSlice(v["ldaps_urls"].(*pluginsdk.Set).List()),
+		LdapsUrls:          &ldapsUrls,
 		DomainUsername:     utils.String(v["domain_username"].(string)),
 		DomainUserPassword: utils.String(v["domain_user_password"].(string)),
 		AaddsResourceID:    utils.String(v["aadds_resource_id"].(string)),
@@ -1426,25 +2010,62 @@ func ExpandHDInsightSecurityProfile(input []interface{}) *hdinsight.SecurityProf
 	}
 
 	if clusterUsersGroupDNS := v["cluster_users_group_dns"].(*pluginsdk.Set).List(); len(clusterUsersGroupDNS) != 0 {
-		result.ClusterUsersGroupDNS = utils.ExpandStringSlice(clusterUsersGroupDNS)
+		groupDNs := sortedHDInsightStrings(clusterUsersGroupDNS, strings.TrimSpace)
+		result.ClusterUsersGroupDNS = &groupDNs
 	}
 
 	return &result
 }
 
+// expandHDInsightVirtualNetworkProfile derives the Virtual Network from the Subnet when `virtual_network_id` isn't
+// specified, otherwise ensuring the Subnet is within the specified Virtual Network
+func expandHDInsightVirtualNetworkProfile(virtualNetworkId, subnetId string) (*hdinsight.VirtualNetworkProfile, error) {
+	if subnetId == "" {
+		if virtualNetworkId != "" {
+			return nil, fmt.Errorf("`subnet_id` must be set when `virtual_network_id` is set")
+		}
+		return nil, nil
+	}
+
+	subnet, err := commonids.ParseSubnetIDInsensitively(subnetId)
+	if err != nil {
+		return nil, err
+	}
+	subnetVirtualNetworkId := commonids.NewVirtualNetworkID(subnet.SubscriptionId, subnet.ResourceGroupName, subnet.VirtualNetworkName)
+
+	if virtualNetworkId == "" {
+		virtualNetworkId = subnetVirtualNetworkId.ID()
+	} else {
+		virtualNetwork, err := commonids.ParseVirtualNetworkIDInsensitively(virtualNetworkId)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.EqualFold(virtualNetwork.ID(), subnetVirtualNetworkId.ID()) {
+			return nil, fmt.Errorf("the `subnet_id` %q must be within the `virtual_network_id` %q - either correct the `virtual_network_id` or omit it so that it's derived from the `subnet_id`", subnetId, virtualNetworkId)
+		}
+	}
+
+	return &hdinsight.VirtualNetworkProfile{
+		ID:     utils.String(virtualNetworkId),
+		Subnet: utils.String(subnetId),
+	}, nil
+}
+
 func FlattenHDInsightNodeDefinition(input *hdinsight.Role, existing []interface{}, definition HDInsightNodeDefinition) []interface{} {
 	if input == nil {
 		return []interface{}{}
 	}
 
 	output := map[string]interface{}{
-		"vm_size":            "",
-		"username":           "",
-		"password":           "",
-		"ssh_keys":           pluginsdk.NewSet(pluginsdk.HashString, []interface{}{}),
-		"subnet_id":          "",
-		"virtual_network_id": "",
-		"script_actions":     make([]interface{}, 0),
+		"vm_size":                      "",
+		"username":                     "",
+		"password":                     "",
+		"password_key_vault_secret_id": "",
+		"ssh_keys":                     pluginsdk.NewSet(hdinsightSSHKeyHash, []interface{}{}),
+		"subnet_id":                    "",
+		"virtual_network_id":           "",
+		"script_actions":               make([]interface{}, 0),
 	}
 
 	if profile := input.OsProfile; profile != nil {
@@ -1459,9 +2080,18 @@ func FlattenHDInsightNodeDefinition(input *hdinsight.Role, existing []interface{
 	if len(existing) > 0 {
 		existingV := existing[0].(map[string]interface{})
 		output["password"] = existingV["password"].(string)
+		if v, ok := existingV["password_key_vault_secret_id"].(string); ok {
+			output["password_key_vault_secret_id"] = v
+		}
+
+		// the username is returned in the `osProfile` (and so is available on import), however should the API
+		// omit it we fall back to the existing value rather than forcing the cluster to be recreated
+		if output["username"] == "" {
+			output["username"] = existingV["username"].(string)
+		}
 
 		sshKeys := existingV["ssh_keys"].(*pluginsdk.Set).List()
-		output["ssh_keys"] = pluginsdk.NewSet(pluginsdk.HashString, sshKeys)
+		output["ssh_keys"] = pluginsdk.NewSet(hdinsightSSHKeyHash, sshKeys)
 
 		// whilst the VMSize can be returned from `input.HardwareProfile.VMSize` - it can be malformed
 		// for example, `small`, `medium`, `large` and `extralarge` can be returned inside of actual VM Size
@@ -1494,6 +2124,12 @@ func FlattenHDInsightNodeDefinition(input *hdinsight.Role, existing []interface{
 		if definition.CanAutoScaleByCapacity || definition.CanAutoScaleOnSchedule {
 			autoscale := FlattenHDInsightNodeAutoscaleDefinition(input.AutoscaleConfiguration)
 			if autoscale != nil {
+				var existingAutoscale []interface{}
+				if len(existing) > 0 {
+					existingAutoscale, _ = existing[0].(map[string]interface{})["autoscale"].([]interface{})
+				}
+				removeHDInsightAutoscaleConflictingMode(autoscale, existingAutoscale)
+				orderHDInsightAutoscaleRecurrenceSchedules(autoscale, existingAutoscale)
 				output["autoscale"] = autoscale
 			}
 		}
@@ -1571,14 +2207,32 @@ func FlattenHDInsightNodeAutoscaleDefinition(input *hdinsight.Autoscale) []inter
 }
 
 func FlattenHDInsightAutoscaleCapacityDefinition(input *hdinsight.AutoscaleCapacity) []interface{} {
+	minInstanceCount := 0
+	if input.MinInstanceCount != nil {
+		minInstanceCount = int(*input.MinInstanceCount)
+	}
+
+	maxInstanceCount := 0
+	if input.MaxInstanceCount != nil {
+		maxInstanceCount = int(*input.MaxInstanceCount)
+	}
+
 	return []interface{}{
 		map[string]interface{}{
-			"min_instance_count": input.MinInstanceCount,
-			"max_instance_count": input.MaxInstanceCount,
+			"min_instance_count": minInstanceCount,
+			"max_instance_count": maxInstanceCount,
 		},
 	}
 }
 
+func normalizeHDInsightAutoscaleTimeZone(input string) string {
+	if timeZone := validate.NormalizeAutoscaleRecurrenceTimeZone(input); timeZone != "" {
+		return timeZone
+	}
+
+	return input
+}
+
 func FlattenHDInsightAutoscaleRecurrenceDefinition(input *hdinsight.AutoscaleRecurrence) []interface{} {
 	if input.Schedule == nil {
 		return []interface{}{}
@@ -1587,9 +2241,11 @@ func FlattenHDInsightAutoscaleRecurrenceDefinition(input *hdinsight.AutoscaleRec
 	schedules := make([]interface{}, 0)
 
 	for _, schedule := range *input.Schedule {
-		days := make([]hdinsight.DaysOfWeek, 0)
+		days := make([]interface{}, 0)
 		if schedule.Days != nil {
-			days = *schedule.Days
+			for _, day := range *schedule.Days {
+				days = append(days, string(day))
+			}
 		}
 
 		targetInstanceCount := 0
@@ -1599,7 +2255,7 @@ func FlattenHDInsightAutoscaleRecurrenceDefinition(input *hdinsight.AutoscaleRec
 				// note: min / max are the same
 				targetInstanceCount = int(*schedule.TimeAndCapacity.MinInstanceCount)
 			}
-			if *schedule.TimeAndCapacity.Time != "" {
+			if schedule.TimeAndCapacity.Time != nil {
 				time = *schedule.TimeAndCapacity.Time
 			}
 		}
@@ -1610,14 +2266,131 @@ func FlattenHDInsightAutoscaleRecurrenceDefinition(input *hdinsight.AutoscaleRec
 		})
 	}
 
+	timezone := ""
+	if input.TimeZone != nil {
+		timezone = *input.TimeZone
+	}
+
 	return []interface{}{
 		map[string]interface{}{
-			"timezone": input.TimeZone,
-			"schedule": &schedules,
+			"timezone": timezone,
+			"schedule": schedules,
 		},
 	}
 }
 
+// removeHDInsightAutoscaleConflictingMode ensures only one of `capacity` and `recurrence` is set, since these conflict -
+// should the API return both (e.g. when switching between them) the one in the existing state is kept, or `capacity`
+// when there's no existing state
+func removeHDInsightAutoscaleConflictingMode(autoscale []interface{}, existingAutoscale []interface{}) {
+	if len(autoscale) == 0 || autoscale[0] == nil {
+		return
+	}
+	result := autoscale[0].(map[string]interface{})
+	if result["capacity"] == nil || result["recurrence"] == nil {
+		return
+	}
+
+	if len(existingAutoscale) > 0 && existingAutoscale[0] != nil {
+		if v, ok := existingAutoscale[0].(map[string]interface{})["recurrence"].([]interface{}); ok && len(v) > 0 {
+			delete(result, "capacity")
+			return
+		}
+	}
+
+	delete(result, "recurrence")
+}
+
+// orderHDInsightAutoscaleRecurrenceSchedules orders the flattened autoscale schedules to match those in the existing
+// state, since the API returns the schedules (and the days within them) in an arbitrary order - any schedules which
+// aren't in the existing state (e.g. when importing) are ordered by their days and then their time
+func orderHDInsightAutoscaleRecurrenceSchedules(autoscale []interface{}, existingAutoscale []interface{}) {
+	recurrence := hdinsightAutoscaleRecurrence(autoscale)
+	if recurrence == nil {
+		return
+	}
+	schedules, _ := recurrence["schedule"].([]interface{})
+
+	var existingSchedules []interface{}
+	if existingRecurrence := hdinsightAutoscaleRecurrence(existingAutoscale); existingRecurrence != nil {
+		existingSchedules, _ = existingRecurrence["schedule"].([]interface{})
+	}
+
+	ordered := make([]interface{}, 0, len(schedules))
+	matched := make([]bool, len(schedules))
+	for _, raw := range existingSchedules {
+		existingSchedule, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for i, schedule := range schedules {
+			if matched[i] || hdinsightAutoscaleScheduleKey(schedule.(map[string]interface{})) != hdinsightAutoscaleScheduleKey(existingSchedule) {
+				continue
+			}
+
+			// the days are the same, however the existing order is kept
+			schedule.(map[string]interface{})["days"] = existingSchedule["days"]
+			ordered = append(ordered, schedule)
+			matched[i] = true
+			break
+		}
+	}
+
+	remaining := make([]interface{}, 0)
+	for i, schedule := range schedules {
+		if !matched[i] {
+			remaining = append(remaining, schedule)
+		}
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return hdinsightAutoscaleScheduleKey(remaining[i].(map[string]interface{})) < hdinsightAutoscaleScheduleKey(remaining[j].(map[string]interface{}))
+	})
+
+	recurrence["schedule"] = append(ordered, remaining...)
+}
+
+func hdinsightAutoscaleRecurrence(autoscale []interface{}) map[string]interface{} {
+	if len(autoscale) == 0 || autoscale[0] == nil {
+		return nil
+	}
+	recurrences, _ := autoscale[0].(map[string]interface{})["recurrence"].([]interface{})
+	if len(recurrences) == 0 || recurrences[0] == nil {
+		return nil
+	}
+	return recurrences[0].(map[string]interface{})
+}
+
+// hdinsightAutoscaleScheduleKey returns a key identifying an autoscale schedule which doesn't depend on the order of
+// the days - these are sorted by their position in the week so that the key can also be used to order the schedules
+func hdinsightAutoscaleScheduleKey(schedule map[string]interface{}) string {
+	weekDays := map[string]int{
+		string(hdinsight.DaysOfWeekMonday):    0,
+		string(hdinsight.DaysOfWeekTuesday):   1,
+		string(hdinsight.DaysOfWeekWednesday): 2,
+		string(hdinsight.DaysOfWeekThursday):  3,
+		string(hdinsight.DaysOfWeekFriday):    4,
+		string(hdinsight.DaysOfWeekSaturday):  5,
+		string(hdinsight.DaysOfWeekSunday):    6,
+	}
+
+	days := make([]string, 0)
+	if v, ok := schedule["days"].([]interface{}); ok {
+		for _, day := range v {
+			days = append(days, day.(string))
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		return weekDays[days[i]] < weekDays[days[j]]
+	})
+
+	positions := make([]string, 0, len(days))
+	for _, day := range days {
+		positions = append(positions, strconv.Itoa(weekDays[day]))
+	}
+
+	return fmt.Sprintf("%s/%v/%v", strings.Join(positions, ","), schedule["time"], schedule["target_instance_count"])
+}
+
 func flattenHDInsightSecurityProfile(input *hdinsight.SecurityProfile, d *pluginsdk.ResourceData) []interface{} {
 	if input == nil {
 		return make([]interface{}, 0)
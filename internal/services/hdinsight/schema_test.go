@@ -3,7 +3,19 @@
 
 package hdinsight
 
-import "testing"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/hdinsight/mgmt/2018-06-01/hdinsight" // nolint: staticcheck
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-provider-azurerm/internal/tf/pluginsdk"
+	"github.com/hashicorp/terraform-provider-azurerm/utils"
+)
 
 func TestHDInsightClusterVersionDiffSuppress(t *testing.T) {
 	tests := []struct {
@@ -52,3 +64,1144 @@ func TestHDInsightClusterVersionDiffSuppress(t *testing.T) {
 		})
 	}
 }
+
+func TestFlattenHDInsightNodeDefinitionUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *hdinsight.Role
+		existing []interface{}
+		expected string
+	}{
+		{
+			name: "imported with username returned from the API",
+			input: &hdinsight.Role{
+				OsProfile: &hdinsight.OsProfile{
+					LinuxOperatingSystemProfile: &hdinsight.LinuxOperatingSystemProfile{
+						Username: utils.String("sshuser"),
+					},
+				},
+			},
+			existing: []interface{}{},
+			expected: "sshuser",
+		},
+		{
+			name: "username returned from the API takes precedence",
+			input: &hdinsight.Role{
+				OsProfile: &hdinsight.OsProfile{
+					LinuxOperatingSystemProfile: &hdinsight.LinuxOperatingSystemProfile{
+						Username: utils.String("sshuser"),
+					},
+				},
+			},
+			existing: []interface{}{
+				map[string]interface{}{
+					"username":       "olduser",
+					"password":       "",
+					"vm_size":        "Standard_A4_V2",
+					"ssh_keys":       pluginsdk.NewSet(pluginsdk.HashString, []interface{}{}),
+					"script_actions": []interface{}{},
+				},
+			},
+			expected: "sshuser",
+		},
+		{
+			name:  "username missing from the API falls back to the existing value",
+			input: &hdinsight.Role{},
+			existing: []interface{}{
+				map[string]interface{}{
+					"username":       "sshuser",
+					"password":       "",
+					"vm_size":        "Standard_A4_V2",
+					"ssh_keys":       pluginsdk.NewSet(pluginsdk.HashString, []interface{}{}),
+					"script_actions": []interface{}{},
+				},
+			},
+			expected: "sshuser",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := FlattenHDInsightNodeDefinition(tt.input, tt.existing, HDInsightNodeDefinition{})
+			actual := output[0].(map[string]interface{})["username"].(string)
+			if actual != tt.expected {
+				t.Errorf("Expected %q to be %q but got %q", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenHDInsightNodeAutoscaleDefinition(t *testing.T) {
+	capacity := FlattenHDInsightNodeAutoscaleDefinition(&hdinsight.Autoscale{
+		Capacity: &hdinsight.AutoscaleCapacity{
+			MinInstanceCount: utils.Int32(3),
+			MaxInstanceCount: utils.Int32(10),
+		},
+	})
+	expectedCapacity := []interface{}{
+		map[string]interface{}{
+			"capacity": []interface{}{
+				map[string]interface{}{
+					"min_instance_count": 3,
+					"max_instance_count": 10,
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(capacity, expectedCapacity) {
+		t.Fatalf("Expected capacity autoscale to be %+v but got %+v", expectedCapacity, capacity)
+	}
+
+	recurrence := FlattenHDInsightNodeAutoscaleDefinition(&hdinsight.Autoscale{
+		Recurrence: &hdinsight.AutoscaleRecurrence{
+			TimeZone: utils.String("Pacific Standard Time"),
+			Schedule: &[]hdinsight.AutoscaleSchedule{
+				{
+					Days: &[]hdinsight.DaysOfWeek{hdinsight.DaysOfWeekMonday, hdinsight.DaysOfWeekTuesday},
+					TimeAndCapacity: &hdinsight.AutoscaleTimeAndCapacity{
+						Time:             utils.String("08:00"),
+						MinInstanceCount: utils.Int32(5),
+						MaxInstanceCount: utils.Int32(5),
+					},
+				},
+			},
+		},
+	})
+	expectedRecurrence := []interface{}{
+		map[string]interface{}{
+			"recurrence": []interface{}{
+				map[string]interface{}{
+					"timezone": "Pacific Standard Time",
+					"schedule": []interface{}{
+						map[string]interface{}{
+							"days":                  []interface{}{"Monday", "Tuesday"},
+							"target_instance_count": 5,
+							"time":                  "08:00",
+						},
+					},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(recurrence, expectedRecurrence) {
+		t.Fatalf("Expected recurrence autoscale to be %+v but got %+v", expectedRecurrence, recurrence)
+	}
+
+	if v := FlattenHDInsightNodeAutoscaleDefinition(nil); v != nil {
+		t.Fatalf("Expected no autoscale to flatten to nil but got %+v", v)
+	}
+}
+
+func TestSchemaHDInsightNodeDefinitionAutoscaleOnScheduleOnly(t *testing.T) {
+	nodeSchema := SchemaHDInsightNodeDefinition("roles.0.worker_node", hdInsightHBaseClusterWorkerNodeDefinition, true)
+	autoscale, ok := nodeSchema.Elem.(*pluginsdk.Resource).Schema["autoscale"]
+	if !ok {
+		t.Fatalf("Expected the HBase Worker Node to support `autoscale`")
+	}
+
+	autoscaleSchema := autoscale.Elem.(*pluginsdk.Resource).Schema
+	if _, ok := autoscaleSchema["recurrence"]; !ok {
+		t.Fatalf("Expected the HBase Worker Node to support `autoscale.0.recurrence`")
+	}
+	if _, ok := autoscaleSchema["capacity"]; ok {
+		t.Fatalf("Expected the HBase Worker Node not to support `autoscale.0.capacity`")
+	}
+
+	workerNode := map[string]interface{}{
+		"autoscale": []interface{}{
+			map[string]interface{}{
+				"recurrence": []interface{}{
+					map[string]interface{}{
+						"timezone": "UTC",
+						"schedule": []interface{}{
+							map[string]interface{}{
+								"days":                  []interface{}{"Saturday", "Sunday"},
+								"time":                  "08:00",
+								"target_instance_count": 2,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	expanded := ExpandHDInsightNodeAutoScaleDefinition(workerNode["autoscale"].([]interface{}))
+	if expanded == nil || expanded.Capacity != nil || expanded.Recurrence == nil {
+		t.Fatalf("Expected a recurrence autoscale definition but got %+v", expanded)
+	}
+}
+
+func TestSchemaHDInsightNodeDefinitionInteractiveQueryAutoscaleByCapacity(t *testing.T) {
+	nodeSchema := SchemaHDInsightNodeDefinition("roles.0.worker_node", hdInsightInteractiveQueryClusterWorkerNodeDefinition, true)
+	autoscaleSchema := nodeSchema.Elem.(*pluginsdk.Resource).Schema["autoscale"].Elem.(*pluginsdk.Resource).Schema
+	capacity, ok := autoscaleSchema["capacity"]
+	if !ok {
+		t.Fatalf("Expected the Interactive Query Worker Node to support `autoscale.0.capacity`")
+	}
+	if capacity.Deprecated == "" {
+		t.Fatalf("Expected `autoscale.0.capacity` to be deprecated for the Interactive Query Worker Node")
+	}
+	if !reflect.DeepEqual(capacity.ConflictsWith, []string{"roles.0.worker_node.0.autoscale.0.recurrence"}) {
+		t.Fatalf("Expected `autoscale.0.capacity` to conflict with `autoscale.0.recurrence` but got %+v", capacity.ConflictsWith)
+	}
+
+	// the API can return the autoscale configuration using a different casing
+	var role hdinsight.Role
+	if err := json.Unmarshal([]byte(`{"name":"WorkerNode","targetInstanceCount":3,"AutoScale":{"Capacity":{"MinInstanceCount":1,"maxInstanceCount":4}}}`), &role); err != nil {
+		t.Fatalf("unmarshalling the role: %+v", err)
+	}
+	workerNode := FindHDInsightRole(&[]hdinsight.Role{role}, "workernode")
+	if workerNode == nil {
+		t.Fatalf("Expected the Worker Node role to be found")
+	}
+
+	output := FlattenHDInsightNodeDefinition(workerNode, []interface{}{}, hdInsightInteractiveQueryClusterWorkerNodeDefinition)
+	expected := []interface{}{
+		map[string]interface{}{
+			"capacity": []interface{}{
+				map[string]interface{}{
+					"min_instance_count": 1,
+					"max_instance_count": 4,
+				},
+			},
+		},
+	}
+	if actual := output[0].(map[string]interface{})["autoscale"]; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, actual)
+	}
+}
+
+func TestHDInsightSetHashesIgnoreOrderAndFormatting(t *testing.T) {
+	tests := []struct {
+		name     string
+		hash     pluginsdk.SchemaSetFunc
+		config   []interface{}
+		returned []interface{}
+		equal    bool
+	}{
+		{
+			name:     "ssh keys in a different order",
+			hash:     hdinsightSSHKeyHash,
+			config:   []interface{}{"ssh-rsa AAAA first", "ssh-rsa BBBB second"},
+			returned: []interface{}{"ssh-rsa BBBB second", "ssh-rsa AAAA first"},
+			equal:    true,
+		},
+		{
+			name:     "ssh key read from a file",
+			hash:     hdinsightSSHKeyHash,
+			config:   []interface{}{"ssh-rsa AAAA first\n"},
+			returned: []interface{}{"ssh-rsa AAAA first"},
+			equal:    true,
+		},
+		{
+			name:     "different ssh keys",
+			hash:     hdinsightSSHKeyHash,
+			config:   []interface{}{"ssh-rsa AAAA first"},
+			returned: []interface{}{"ssh-rsa AAAB first"},
+			equal:    false,
+		},
+		{
+			name:     "ldaps urls in a different order and casing",
+			hash:     hdinsightLdapsUrlHash,
+			config:   []interface{}{"ldaps://dc1.example.com:636", "ldaps://dc2.example.com:636/"},
+			returned: []interface{}{"ldaps://DC2.example.com:636", "ldaps://dc1.example.com:636"},
+			equal:    true,
+		},
+		{
+			name:     "different ldaps urls",
+			hash:     hdinsightLdapsUrlHash,
+			config:   []interface{}{"ldaps://dc1.example.com:636"},
+			returned: []interface{}{"ldaps://dc1.example.com:3269"},
+			equal:    false,
+		},
+		{
+			name:     "group distinguished names in a different order and format",
+			hash:     hdinsightDistinguishedNameHash,
+			config:   []interface{}{"CN=HDInsight Users,OU=Groups,DC=example,DC=com", "cn=admins,dc=example,dc=com"},
+			returned: []interface{}{"CN=Admins, DC=example, DC=com", "cn=hdinsight users,ou=groups,dc=example,dc=com"},
+			equal:    true,
+		},
+		{
+			name:     "different group distinguished names",
+			hash:     hdinsightDistinguishedNameHash,
+			config:   []interface{}{"CN=HDInsight Users,DC=example,DC=com"},
+			returned: []interface{}{"CN=HDInsightUsers,DC=example,DC=com"},
+			equal:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := pluginsdk.NewSet(tt.hash, tt.config)
+			returned := pluginsdk.NewSet(tt.hash, tt.returned)
+			if actual := config.HashEqual(returned); actual != tt.equal {
+				t.Fatalf("Expected the sets to be equal to be %t but got %t", tt.equal, actual)
+			}
+		})
+	}
+}
+
+func TestExpandHDInsightSecurityProfileSortsValues(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{
+			"aadds_resource_id":       "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example/providers/Microsoft.AAD/domainServices/example.com",
+			"domain_name":             "example.com",
+			"domain_username":         "admin@example.com",
+			"domain_user_password":    "P@ssw0rd1234!",
+			"msi_resource_id":         "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example/providers/Microsoft.ManagedIdentity/userAssignedIdentities/example",
+			"ldaps_urls":              pluginsdk.NewSet(hdinsightLdapsUrlHash, []interface{}{"ldaps://dc2.example.com:636", "ldaps://dc1.example.com:636"}),
+			"cluster_users_group_dns": pluginsdk.NewSet(hdinsightDistinguishedNameHash, []interface{}{"CN=Users,DC=example,DC=com", "CN=Admins,DC=example,DC=com"}),
+		},
+	}
+
+	for i := 0; i < 5; i++ {
+		actual := ExpandHDInsightSecurityProfile(input)
+		if expected := []string{"ldaps://dc1.example.com:636", "ldaps://dc2.example.com:636"}; !reflect.DeepEqual(*actual.LdapsUrls, expected) {
+			t.Fatalf("Expected the LDAPS URLs to be %+v but got %+v", expected, *actual.LdapsUrls)
+		}
+		if expected := []string{"CN=Admins,DC=example,DC=com", "CN=Users,DC=example,DC=com"}; !reflect.DeepEqual(*actual.ClusterUsersGroupDNS, expected) {
+			t.Fatalf("Expected the group DNs to be %+v but got %+v", expected, *actual.ClusterUsersGroupDNS)
+		}
+	}
+}
+
+func TestExpandAndFlattenHDInsightsYarnQueues(t *testing.T) {
+	input := []interface{}{
+		map[string]interface{}{
+			"name":         "default",
+			"capacity":     60.0,
+			"max_capacity": 100.0,
+		},
+		map[string]interface{}{
+			"name":         "etl",
+			"capacity":     40.0,
+			"max_capacity": 62.5,
+		},
+	}
+
+	expanded := ExpandHDInsightsYarnQueues(input)
+	expected := map[string]interface{}{
+		"capacity-scheduler": map[string]interface{}{
+			"yarn.scheduler.capacity.root.queues":                   "default,etl",
+			"yarn.scheduler.capacity.root.default.capacity":         "60",
+			"yarn.scheduler.capacity.root.default.maximum-capacity": "100",
+			"yarn.scheduler.capacity.root.etl.capacity":             "40",
+			"yarn.scheduler.capacity.root.etl.maximum-capacity":     "62.5",
+		},
+	}
+	if !reflect.DeepEqual(expanded, expected) {
+		t.Fatalf("Expected %+v but got %+v", expected, expanded)
+	}
+
+	// the configuration is returned by the API as strings
+	capacityScheduler := make(map[string]*string)
+	for k, v := range expanded["capacity-scheduler"].(map[string]interface{}) {
+		capacityScheduler[k] = utils.String(v.(string))
+	}
+	if flattened := FlattenHDInsightsYarnQueues(capacityScheduler); !reflect.DeepEqual(flattened, input) {
+		t.Fatalf("Expected %+v but got %+v", input, flattened)
+	}
+
+	// YARN defaults the maximum capacity when it isn't specified
+	flattened := FlattenHDInsightsYarnQueues(map[string]*string{
+		"yarn.scheduler.capacity.root.queues":           utils.String("default"),
+		"yarn.scheduler.capacity.root.default.capacity": utils.String("100"),
+	})
+	expectedDefault := []interface{}{
+		map[string]interface{}{
+			"name":         "default",
+			"capacity":     100.0,
+			"max_capacity": 100.0,
+		},
+	}
+	if !reflect.DeepEqual(flattened, expectedDefault) {
+		t.Fatalf("Expected %+v but got %+v", expectedDefault, flattened)
+	}
+
+	if v := ExpandHDInsightsYarnQueues(nil); v != nil {
+		t.Fatalf("Expected no configuration when no queues are specified but got %+v", v)
+	}
+}
+
+func TestSchemaHDInsightNodeDefinitionTargetInstanceCountOptionalWithAutoscale(t *testing.T) {
+	definitions := map[string]HDInsightNodeDefinition{
+		"Hadoop":            hdInsightHadoopClusterWorkerNodeDefinition,
+		"HBase":             hdInsightHBaseClusterWorkerNodeDefinition,
+		"Interactive Query": hdInsightInteractiveQueryClusterWorkerNodeDefinition,
+		"Spark":             hdInsightSparkClusterWorkerNodeDefinition,
+	}
+	for kind, definition := range definitions {
+		nodeSchema := SchemaHDInsightNodeDefinition("roles.0.worker_node", definition, true)
+		targetInstanceCount := nodeSchema.Elem.(*pluginsdk.Resource).Schema["target_instance_count"]
+		if targetInstanceCount.Required || !targetInstanceCount.Optional || !targetInstanceCount.Computed {
+			t.Fatalf("Expected `target_instance_count` of the %s Worker Node to be Optional and Computed", kind)
+		}
+	}
+
+	// Kafka doesn't support autoscale, so the number of nodes must always be specified
+	nodeSchema := SchemaHDInsightNodeDefinition("roles.0.worker_node", hdInsightKafkaClusterWorkerNodeDefinition, true)
+	if !nodeSchema.Elem.(*pluginsdk.Resource).Schema["target_instance_count"].Required {
+		t.Fatalf("Expected `target_instance_count` of the Kafka Worker Node to be Required")
+	}
+}
+
+func TestHDInsightAutoscaleInitialInstanceCount(t *testing.T) {
+	schedule := func(count int32) hdinsight.AutoscaleSchedule {
+		return hdinsight.AutoscaleSchedule{
+			TimeAndCapacity: &hdinsight.AutoscaleTimeAndCapacity{
+				Time:             utils.String("08:00"),
+				MinInstanceCount: utils.Int32(count),
+				MaxInstanceCount: utils.Int32(count),
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		input    *hdinsight.Autoscale
+		expected int
+	}{
+		{
+			name:     "no autoscale",
+			expected: 0,
+		},
+		{
+			name: "capacity",
+			input: &hdinsight.Autoscale{
+				Capacity: &hdinsight.AutoscaleCapacity{
+					MinInstanceCount: utils.Int32(2),
+					MaxInstanceCount: utils.Int32(5),
+				},
+			},
+			expected: 2,
+		},
+		{
+			name: "recurrence",
+			input: &hdinsight.Autoscale{
+				Recurrence: &hdinsight.AutoscaleRecurrence{
+					Schedule: &[]hdinsight.AutoscaleSchedule{schedule(6), schedule(3), schedule(4)},
+				},
+			},
+			expected: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := hdinsightAutoscaleInitialInstanceCount(tt.input); actual != tt.expected {
+				t.Fatalf("Expected %d but got %d", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenHDInsightNodeDefinitionAutoscaleScheduleOrder(t *testing.T) {
+	schedule := func(time string, targetInstanceCount int, days ...string) map[string]interface{} {
+		weekDays := make([]interface{}, 0)
+		for _, day := range days {
+			weekDays = append(weekDays, day)
+		}
+		return map[string]interface{}{
+			"days":                  weekDays,
+			"target_instance_count": targetInstanceCount,
+			"time":                  time,
+		}
+	}
+	apiSchedule := func(time string, targetInstanceCount int, days ...hdinsight.DaysOfWeek) hdinsight.AutoscaleSchedule {
+		return hdinsight.AutoscaleSchedule{
+			Days: &days,
+			TimeAndCapacity: &hdinsight.AutoscaleTimeAndCapacity{
+				Time:             utils.String(time),
+				MinInstanceCount: utils.Int32(int32(targetInstanceCount)),
+				MaxInstanceCount: utils.Int32(int32(targetInstanceCount)),
+			},
+		}
+	}
+	existing := func(schedules ...interface{}) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"username":       "acctestusrvm",
+				"password":       "AccTestvdSC4daf986!",
+				"vm_size":        "Standard_D4_V2",
+				"ssh_keys":       pluginsdk.NewSet(pluginsdk.HashString, []interface{}{}),
+				"script_actions": []interface{}{},
+				"autoscale": []interface{}{
+					map[string]interface{}{
+						"recurrence": []interface{}{
+							map[string]interface{}{
+								"timezone": "Pacific Standard Time",
+								"schedule": schedules,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// the schedules only differ by their days, and are returned by the API in a different order to the configuration
+	role := &hdinsight.Role{
+		Name:                utils.String("workernode"),
+		TargetInstanceCount: utils.Int32(3),
+		AutoscaleConfiguration: &hdinsight.Autoscale{
+			Recurrence: &hdinsight.AutoscaleRecurrence{
+				TimeZone: utils.String("Pacific Standard Time"),
+				Schedule: &[]hdinsight.AutoscaleSchedule{
+					apiSchedule("08:00", 5, hdinsight.DaysOfWeekSunday, hdinsight.DaysOfWeekSaturday),
+					apiSchedule("08:00", 5, hdinsight.DaysOfWeekWednesday, hdinsight.DaysOfWeekThursday),
+					apiSchedule("08:00", 5, hdinsight.DaysOfWeekMonday, hdinsight.DaysOfWeekTuesday),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		existing []interface{}
+		expected []interface{}
+	}{
+		{
+			name: "ordered to match the existing schedules",
+			existing: existing(
+				schedule("08:00", 5, "Monday", "Tuesday"),
+				schedule("08:00", 5, "Wednesday", "Thursday"),
+				schedule("08:00", 5, "Saturday", "Sunday"),
+			),
+			expected: []interface{}{
+				schedule("08:00", 5, "Monday", "Tuesday"),
+				schedule("08:00", 5, "Wednesday", "Thursday"),
+				schedule("08:00", 5, "Saturday", "Sunday"),
+			},
+		},
+		{
+			name: "changed schedules are ordered after the existing schedules",
+			existing: existing(
+				schedule("08:00", 5, "Saturday", "Sunday"),
+				schedule("08:00", 5, "Monday", "Friday"),
+			),
+			expected: []interface{}{
+				schedule("08:00", 5, "Saturday", "Sunday"),
+				schedule("08:00", 5, "Monday", "Tuesday"),
+				schedule("08:00", 5, "Wednesday", "Thursday"),
+			},
+		},
+		{
+			name:     "ordered by their days when importing",
+			existing: []interface{}{},
+			expected: []interface{}{
+				schedule("08:00", 5, "Monday", "Tuesday"),
+				schedule("08:00", 5, "Wednesday", "Thursday"),
+				schedule("08:00", 5, "Sunday", "Saturday"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := FlattenHDInsightNodeDefinition(role, tt.existing, hdInsightHadoopClusterWorkerNodeDefinition)
+			autoscale := output[0].(map[string]interface{})["autoscale"].([]interface{})
+			recurrence := autoscale[0].(map[string]interface{})["recurrence"].([]interface{})
+			if actual := recurrence[0].(map[string]interface{})["schedule"]; !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("Expected %+v but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFlattenHDInsightNodeDefinitionAutoscaleSingleMode(t *testing.T) {
+	role := &hdinsight.Role{
+		Name:                utils.String("workernode"),
+		TargetInstanceCount: utils.Int32(3),
+		AutoscaleConfiguration: &hdinsight.Autoscale{
+			Capacity: &hdinsight.AutoscaleCapacity{
+				MinInstanceCount: utils.Int32(1),
+				MaxInstanceCount: utils.Int32(3),
+			},
+			Recurrence: &hdinsight.AutoscaleRecurrence{
+				TimeZone: utils.String("UTC"),
+				Schedule: &[]hdinsight.AutoscaleSchedule{},
+			},
+		},
+	}
+	existing := func(autoscale map[string]interface{}) []interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"username":       "acctestusrvm",
+				"password":       "AccTestvdSC4daf986!",
+				"vm_size":        "Standard_D4_V2",
+				"ssh_keys":       pluginsdk.NewSet(pluginsdk.HashString, []interface{}{}),
+				"script_actions": []interface{}{},
+				"autoscale":      []interface{}{autoscale},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing []interface{}
+		expected string
+	}{
+		{
+			name:     "switched to recurrence",
+			existing: existing(map[string]interface{}{"capacity": []interface{}{}, "recurrence": []interface{}{map[string]interface{}{"timezone": "UTC"}}}),
+			expected: "recurrence",
+		},
+		{
+			name:     "switched to capacity",
+			existing: existing(map[string]interface{}{"capacity": []interface{}{map[string]interface{}{"min_instance_count": 1}}, "recurrence": []interface{}{}}),
+			expected: "capacity",
+		},
+		{
+			name:     "imported",
+			existing: []interface{}{},
+			expected: "capacity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := FlattenHDInsightNodeDefinition(role, tt.existing, hdInsightSparkClusterWorkerNodeDefinition)
+			autoscale := output[0].(map[string]interface{})["autoscale"].([]interface{})[0].(map[string]interface{})
+			if len(autoscale) != 1 || autoscale[tt.expected] == nil {
+				t.Fatalf("Expected only %q autoscale but got %+v", tt.expected, autoscale)
+			}
+		})
+	}
+}
+
+func TestHDInsightAutoscaleTargetInstanceCountDiffSuppress(t *testing.T) {
+	nodeSchema := map[string]*pluginsdk.Schema{
+		"worker_node": SchemaHDInsightNodeDefinition("worker_node", hdInsightInteractiveQueryClusterWorkerNodeDefinition, true),
+	}
+	capacity := []interface{}{
+		map[string]interface{}{
+			"capacity": []interface{}{
+				map[string]interface{}{
+					"min_instance_count": 1,
+					"max_instance_count": 4,
+				},
+			},
+		},
+	}
+	recurrence := []interface{}{
+		map[string]interface{}{
+			"recurrence": []interface{}{
+				map[string]interface{}{
+					"timezone": "Pacific Standard Time",
+					"schedule": []interface{}{
+						map[string]interface{}{
+							"days":                  []interface{}{"Monday"},
+							"time":                  "10:00",
+							"target_instance_count": 9,
+						},
+					},
+				},
+			},
+		},
+	}
+	suppress := hdinsightAutoscaleTargetInstanceCountDiffSuppressFunc("worker_node")
+
+	tests := []struct {
+		name      string
+		autoscale []interface{}
+		old       string
+		new       string
+		expected  bool
+	}{
+		{
+			name:      "new cluster with capacity autoscale",
+			autoscale: capacity,
+			old:       "",
+			new:       "3",
+			expected:  false,
+		},
+		{
+			name:      "count changed by the autoscaler",
+			autoscale: capacity,
+			old:       "4",
+			new:       "3",
+			expected:  true,
+		},
+		{
+			name:      "count changed by a recurrence schedule",
+			autoscale: recurrence,
+			old:       "9",
+			new:       "4",
+			expected:  true,
+		},
+		{
+			name:      "count changed without autoscale",
+			autoscale: []interface{}{},
+			old:       "4",
+			new:       "3",
+			expected:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, nodeSchema, map[string]interface{}{
+				"worker_node": []interface{}{
+					map[string]interface{}{
+						"vm_size":               "Standard_D13_V2",
+						"username":              "acctestusrvm",
+						"password":              "AccTestvdSC4daf986!",
+						"target_instance_count": 3,
+						"autoscale":             tt.autoscale,
+					},
+				},
+			})
+			if actual := suppress("worker_node.0.target_instance_count", tt.old, tt.new, d); actual != tt.expected {
+				t.Fatalf("Expected %t but got %t", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSchemaHDInsightNodeDefinitionAutoscaleUpdatableInPlace(t *testing.T) {
+	var forceNew func(prefix string, input map[string]*pluginsdk.Schema) []string
+	forceNew = func(prefix string, input map[string]*pluginsdk.Schema) []string {
+		output := make([]string, 0)
+		for k, v := range input {
+			if v.ForceNew {
+				output = append(output, prefix+k)
+			}
+			if elem, ok := v.Elem.(*pluginsdk.Resource); ok {
+				output = append(output, forceNew(prefix+k+".0.", elem.Schema)...)
+			}
+		}
+		return output
+	}
+
+	definitions := map[string]HDInsightNodeDefinition{
+		"Hadoop":            hdInsightHadoopClusterWorkerNodeDefinition,
+		"HBase":             hdInsightHBaseClusterWorkerNodeDefinition,
+		"Interactive Query": hdInsightInteractiveQueryClusterWorkerNodeDefinition,
+		"Spark":             hdInsightSparkClusterWorkerNodeDefinition,
+	}
+	for kind, definition := range definitions {
+		nodeSchema := SchemaHDInsightNodeDefinition("roles.0.worker_node", definition, true)
+		autoscale := nodeSchema.Elem.(*pluginsdk.Resource).Schema["autoscale"]
+		if autoscale.ForceNew {
+			t.Fatalf("Expected `autoscale` of the %s Worker Node not to be ForceNew", kind)
+		}
+		if actual := forceNew("autoscale.0.", autoscale.Elem.(*pluginsdk.Resource).Schema); len(actual) > 0 {
+			t.Fatalf("Expected the %s Worker Node autoscale to be updatable in-place but %+v are ForceNew", kind, actual)
+		}
+	}
+}
+
+func TestSchemaHDInsightEdgeNodeUpdatableInPlace(t *testing.T) {
+	var forceNew func(prefix string, input map[string]*pluginsdk.Schema) []string
+	forceNew = func(prefix string, input map[string]*pluginsdk.Schema) []string {
+		output := make([]string, 0)
+		for k, v := range input {
+			if v.ForceNew {
+				output = append(output, prefix+k)
+			}
+			if elem, ok := v.Elem.(*pluginsdk.Resource); ok {
+				output = append(output, forceNew(prefix+k+".0.", elem.Schema)...)
+			}
+		}
+		return output
+	}
+
+	// edge nodes are deployed as Applications, so changing them mustn't replace the cluster
+	resources := map[string]*pluginsdk.Resource{
+		"Hadoop": resourceHDInsightHadoopCluster(),
+		"Spark":  resourceHDInsightSparkCluster(),
+	}
+	for kind, resource := range resources {
+		roles := resource.Schema["roles"]
+		if roles.ForceNew {
+			t.Fatalf("Expected `roles` of the %s Cluster not to be ForceNew", kind)
+		}
+
+		edgeNode := roles.Elem.(*pluginsdk.Resource).Schema["edge_node"]
+		if edgeNode.ForceNew {
+			t.Fatalf("Expected `roles.0.edge_node` of the %s Cluster not to be ForceNew", kind)
+		}
+		if actual := forceNew("roles.0.edge_node.0.", edgeNode.Elem.(*pluginsdk.Resource).Schema); len(actual) > 0 {
+			t.Fatalf("Expected the %s Cluster edge nodes to be updatable without replacing the cluster but %+v are ForceNew", kind, actual)
+		}
+	}
+}
+
+func TestSchemaHDInsightApplicationForceNew(t *testing.T) {
+	var updatable func(prefix string, input map[string]*pluginsdk.Schema) []string
+	updatable = func(prefix string, input map[string]*pluginsdk.Schema) []string {
+		output := make([]string, 0)
+		for k, v := range input {
+			if !v.ForceNew && !v.Computed {
+				output = append(output, prefix+k)
+			}
+			if elem, ok := v.Elem.(*pluginsdk.Resource); ok {
+				output = append(output, updatable(prefix+k+".0.", elem.Schema)...)
+			}
+		}
+		return output
+	}
+
+	// there's no way to update an Application, so any change (including to the nested blocks) must recreate it
+	if actual := updatable("", resourceHDInsightApplication().Schema); len(actual) > 0 {
+		t.Fatalf("Expected every argument of the HDInsight Application to be ForceNew but %+v aren't", actual)
+	}
+}
+
+func TestExpandHDInsightAutoscaleRecurrenceDefinitionNormalizesTimeZone(t *testing.T) {
+	recurrence := ExpandHDInsightAutoscaleRecurrenceDefinition([]interface{}{
+		map[string]interface{}{
+			"timezone": "pacific standard time",
+			"schedule": []interface{}{
+				map[string]interface{}{
+					"days":                  []interface{}{"Monday"},
+					"target_instance_count": 5,
+					"time":                  "08:00",
+				},
+			},
+		},
+	})
+
+	if recurrence == nil || recurrence.TimeZone == nil || *recurrence.TimeZone != "Pacific Standard Time" {
+		t.Fatalf("Expected the timezone to be normalized to %q but got %+v", "Pacific Standard Time", recurrence)
+	}
+}
+
+func TestExpandHDInsightVirtualNetworkProfile(t *testing.T) {
+	virtualNetworkId := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network1"
+	subnetId := virtualNetworkId + "/subnets/subnet1"
+
+	tests := []struct {
+		name             string
+		virtualNetworkId string
+		subnetId         string
+		expected         *hdinsight.VirtualNetworkProfile
+		valid            bool
+	}{
+		{
+			name:  "neither specified",
+			valid: true,
+		},
+		{
+			name:             "both specified",
+			virtualNetworkId: virtualNetworkId,
+			subnetId:         subnetId,
+			expected: &hdinsight.VirtualNetworkProfile{
+				ID:     utils.String(virtualNetworkId),
+				Subnet: utils.String(subnetId),
+			},
+			valid: true,
+		},
+		{
+			name:     "derived from the subnet",
+			subnetId: subnetId,
+			expected: &hdinsight.VirtualNetworkProfile{
+				ID:     utils.String(virtualNetworkId),
+				Subnet: utils.String(subnetId),
+			},
+			valid: true,
+		},
+		{
+			name:             "subnet in another virtual network",
+			virtualNetworkId: "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Network/virtualNetworks/network2",
+			subnetId:         subnetId,
+			valid:            false,
+		},
+		{
+			name:             "virtual network only",
+			virtualNetworkId: virtualNetworkId,
+			valid:            false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := expandHDInsightVirtualNetworkProfile(tt.virtualNetworkId, tt.subnetId)
+			if valid := err == nil; valid != tt.valid {
+				t.Fatalf("Expected %q to be valid %t but got %t (%v)", tt.name, tt.valid, valid, err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("Expected %+v but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHDInsightTierDiffSuppress(t *testing.T) {
+	tests := []struct {
+		name          string
+		userInput     string
+		azureResponse string
+		suppressed    bool
+	}{
+		{
+			name:          "matching casing",
+			userInput:     "Standard",
+			azureResponse: "Standard",
+			suppressed:    true,
+		},
+		{
+			name:          "lower case user input",
+			userInput:     "standard",
+			azureResponse: "Standard",
+			suppressed:    true,
+		},
+		{
+			name:          "upper case api response",
+			userInput:     "Standard",
+			azureResponse: "STANDARD",
+			suppressed:    true,
+		},
+		{
+			name:          "different tier",
+			userInput:     "Standard",
+			azureResponse: "premium",
+			suppressed:    false,
+		},
+		{
+			name:          "missing api response",
+			userInput:     "Standard",
+			azureResponse: "",
+			suppressed:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wasSuppressed := hdinsightTierDiffSuppressFunc("", tt.azureResponse, tt.userInput, nil)
+			if tt.suppressed != wasSuppressed {
+				t.Errorf("Expected %q to be %t but got %t", tt.name, tt.suppressed, wasSuppressed)
+			}
+		})
+	}
+}
+
+func TestNormalizeHDInsightTier(t *testing.T) {
+	for _, input := range []string{"standard", "STANDARD", "Standard"} {
+		if actual := normalizeHDInsightTier(input); actual != string(hdinsight.TierStandard) {
+			t.Errorf("Expected %q to be normalized to %q but got %q", input, hdinsight.TierStandard, actual)
+		}
+	}
+
+	if actual := normalizeHDInsightTier("pReMiUm"); actual != string(hdinsight.TierPremium) {
+		t.Errorf("Expected %q to be normalized to %q but got %q", "pReMiUm", hdinsight.TierPremium, actual)
+	}
+}
+
+func TestFlattenHDInsightEncryptionInTransitProperties(t *testing.T) {
+	testData := []struct {
+		input    *hdinsight.EncryptionInTransitProperties
+		expected bool
+	}{
+		{
+			input:    nil,
+			expected: false,
+		},
+		{
+			input:    &hdinsight.EncryptionInTransitProperties{},
+			expected: false,
+		},
+		{
+			input:    ExpandHDInsightEncryptionInTransitProperties(false),
+			expected: false,
+		},
+		{
+			input:    ExpandHDInsightEncryptionInTransitProperties(true),
+			expected: true,
+		},
+	}
+
+	for _, v := range testData {
+		if actual := FlattenHDInsightEncryptionInTransitProperties(v.input); actual != v.expected {
+			t.Fatalf("Expected %t for %+v but got %t", v.expected, v.input, actual)
+		}
+	}
+}
+
+func TestFlattenHDInsightCustomerManagedKeyEnabled(t *testing.T) {
+	testData := []struct {
+		name     string
+		input    *hdinsight.DiskEncryptionProperties
+		expected bool
+	}{
+		{
+			name:     "no disk encryption",
+			input:    nil,
+			expected: false,
+		},
+		{
+			name: "encryption at host only",
+			input: &hdinsight.DiskEncryptionProperties{
+				EncryptionAtHost: utils.Bool(true),
+			},
+			expected: false,
+		},
+		{
+			name: "customer-managed key",
+			input: &hdinsight.DiskEncryptionProperties{
+				VaultURI:            utils.String("https://example.vault.azure.net"),
+				KeyName:             utils.String("example"),
+				KeyVersion:          utils.String("00000000000000000000000000000000"),
+				EncryptionAlgorithm: hdinsight.JSONWebKeyEncryptionAlgorithmRSAOAEP,
+				MsiResourceID:       utils.String("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/example/providers/Microsoft.ManagedIdentity/userAssignedIdentities/example"),
+			},
+			expected: true,
+		},
+	}
+
+	for _, v := range testData {
+		t.Run(v.name, func(t *testing.T) {
+			if actual := FlattenHDInsightCustomerManagedKeyEnabled(v.input); actual != v.expected {
+				t.Fatalf("Expected %t but got %t", v.expected, actual)
+			}
+
+			// the `disk_encryption` block is flattened from the same properties, which don't include a Managed Identity
+			// when only encryption at host is enabled
+			if v.input != nil {
+				if _, err := FlattenHDInsightsDiskEncryptionProperties(*v.input); err != nil {
+					t.Fatalf("flattening `disk_encryption`: %+v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenHDInsightsConfigurationsGatewayEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    map[string]*string
+		expected bool
+	}{
+		{
+			name: "enabled",
+			input: map[string]*string{
+				"restAuthCredential.isEnabled": utils.String("true"),
+				"restAuthCredential.username":  utils.String("acctestusrgw"),
+				"restAuthCredential.password":  utils.String("*****"),
+			},
+			expected: true,
+		},
+		{
+			name: "disabled",
+			input: map[string]*string{
+				"restAuthCredential.isEnabled": utils.String("false"),
+				"restAuthCredential.username":  utils.String("acctestusrgw"),
+				"restAuthCredential.password":  utils.String("*****"),
+			},
+			expected: false,
+		},
+		{
+			name: "not returned from the API",
+			input: map[string]*string{
+				"restAuthCredential.username": utils.String("acctestusrgw"),
+				"restAuthCredential.password": utils.String("*****"),
+			},
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := FlattenHDInsightsConfigurations(tt.input, nil)
+			actual := output[0].(map[string]interface{})["enabled"].(bool)
+			if actual != tt.expected {
+				t.Errorf("Expected %q to be %t but got %t", tt.name, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpandHDInsightsStorageAccountsGen2DefaultWithAdditionalBlobStorage(t *testing.T) {
+	storageAccounts := []interface{}{
+		map[string]interface{}{
+			"storage_account_key":  "secret",
+			"storage_container_id": "https://additional.blob.core.windows.net/data",
+			"storage_resource_id":  "",
+			"is_default":           false,
+		},
+	}
+	gen2StorageAccounts := []interface{}{
+		map[string]interface{}{
+			"storage_resource_id":          "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.Storage/storageAccounts/primary",
+			"filesystem_id":                "https://primary.dfs.core.windows.net/cluster",
+			"managed_identity_resource_id": "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/identity1",
+			"is_default":                   true,
+		},
+	}
+
+	actual, identity, err := ExpandHDInsightsStorageAccounts(storageAccounts, gen2StorageAccounts)
+	if err != nil {
+		t.Fatalf("expanding storage accounts: %+v", err)
+	}
+
+	if len(*actual) != 2 {
+		t.Fatalf("Expected 2 storage accounts but got %d", len(*actual))
+	}
+
+	defaultAccount := (*actual)[0]
+	if *defaultAccount.Name != "primary.dfs.core.windows.net" || *defaultAccount.FileSystem != "cluster" || !*defaultAccount.IsDefault {
+		t.Fatalf("Expected the Data Lake Gen2 account to be the first and default account but got %+v", defaultAccount)
+	}
+
+	additionalAccount := (*actual)[1]
+	if *additionalAccount.Name != "additional.blob.core.windows.net" || *additionalAccount.Container != "data" || *additionalAccount.IsDefault {
+		t.Fatalf("Expected the Blob Storage account to be an additional account but got %+v", additionalAccount)
+	}
+	if additionalAccount.ResourceID != nil {
+		t.Fatalf("Expected no Resource ID for the additional account but got %q", *additionalAccount.ResourceID)
+	}
+
+	if identity == nil || len(identity.UserAssignedIdentities) != 1 {
+		t.Fatalf("Expected the Data Lake Gen2 managed identity to be assigned to the cluster but got %+v", identity)
+	}
+}
+
+func TestSchemaHDInsightCredentialsAreSensitive(t *testing.T) {
+	// credentials are either passwords or keys - the IDs of Key Vault Secrets and Keys aren't sensitive, and neither are
+	// the public keys within `ssh_keys`
+	isCredential := func(name string) bool {
+		if strings.HasSuffix(name, "_id") {
+			return false
+		}
+		return strings.Contains(name, "password") || strings.Contains(name, "secret") || strings.HasSuffix(name, "_key")
+	}
+
+	var insensitive func(prefix string, input map[string]*pluginsdk.Schema) []string
+	insensitive = func(prefix string, input map[string]*pluginsdk.Schema) []string {
+		output := make([]string, 0)
+		for k, v := range input {
+			if isCredential(k) && !v.Sensitive {
+				output = append(output, prefix+k)
+			}
+			if elem, ok := v.Elem.(*pluginsdk.Resource); ok {
+				output = append(output, insensitive(prefix+k+".0.", elem.Schema)...)
+			}
+		}
+		return output
+	}
+
+	resources := (Registration{}).SupportedResources()
+	for k, v := range (Registration{}).SupportedDataSources() {
+		resources[k] = v
+	}
+
+	for name, resource := range resources {
+		if actual := insensitive("", resource.Schema); len(actual) > 0 {
+			sort.Strings(actual)
+			t.Errorf("Expected the credentials within %q to be Sensitive but %s aren't", name, strings.Join(actual, ", "))
+		}
+	}
+}
+
+func TestSchemaHDInsightNodeDefinitionInstanceCountNamesKind(t *testing.T) {
+	definitions := map[string]HDInsightNodeDefinition{
+		"Hadoop":            hdInsightHadoopClusterWorkerNodeDefinition,
+		"HBase":             hdInsightHBaseClusterWorkerNodeDefinition,
+		"Interactive Query": hdInsightInteractiveQueryClusterWorkerNodeDefinition,
+		"Kafka":             hdInsightKafkaClusterWorkerNodeDefinition,
+		"Spark":             hdInsightSparkClusterWorkerNodeDefinition,
+	}
+	for kind, definition := range definitions {
+		nodeSchema := SchemaHDInsightNodeDefinition("roles.0.worker_node", definition, true).Elem.(*pluginsdk.Resource).Schema
+		_, errors := nodeSchema["target_instance_count"].ValidateFunc(0, "target_instance_count")
+		expected := fmt.Sprintf("%s worker_node requires at least %d instance", kind, definition.MinInstanceCount)
+		if len(errors) != 1 || !strings.HasPrefix(errors[0].Error(), expected) {
+			t.Fatalf("Expected an error starting with %q but got %+v", expected, errors)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AutoscaleRecurrenceTimeZones are the Windows Time Zone identifiers supported by the schedule based autoscale of an
+// HDInsight Cluster, taken from the output of [System.TimeZoneInfo]::GetSystemTimeZones()
+var AutoscaleRecurrenceTimeZones = []string{
+	"Afghanistan Standard Time",
+	"Alaskan Standard Time",
+	"Aleutian Standard Time",
+	"Altai Standard Time",
+	"Arab Standard Time",
+	"Arabian Standard Time",
+	"Arabic Standard Time",
+	"Argentina Standard Time",
+	"Astrakhan Standard Time",
+	"Atlantic Standard Time",
+	"AUS Central Standard Time",
+	"Aus Central W. Standard Time",
+	"AUS Eastern Standard Time",
+	"Azerbaijan Standard Time",
+	"Azores Standard Time",
+	"Bahia Standard Time",
+	"Bangladesh Standard Time",
+	"Belarus Standard Time",
+	"Bougainville Standard Time",
+	"Canada Central Standard Time",
+	"Cape Verde Standard Time",
+	"Caucasus Standard Time",
+	"Cen. Australia Standard Time",
+	"Central America Standard Time",
+	"Central Asia Standard Time",
+	"Central Brazilian Standard Time",
+	"Central Europe Standard Time",
+	"Central European Standard Time",
+	"Central Pacific Standard Time",
+	"Central Standard Time",
+	"Central Standard Time (Mexico)",
+	"Chatham Islands Standard Time",
+	"China Standard Time",
+	"Cuba Standard Time",
+	"Dateline Standard Time",
+	"E. Africa Standard Time",
+	"E. Australia Standard Time",
+	"E. Europe Standard Time",
+	"E. South America Standard Time",
+	"Easter Island Standard Time",
+	"Eastern Standard Time",
+	"Eastern Standard Time (Mexico)",
+	"Egypt Standard Time",
+	"Ekaterinburg Standard Time",
+	"Fiji Standard Time",
+	"FLE Standard Time",
+	"Georgian Standard Time",
+	"GMT Standard Time",
+	"Greenland Standard Time",
+	"Greenwich Standard Time",
+	"GTB Standard Time",
+	"Haiti Standard Time",
+	"Hawaiian Standard Time",
+	"India Standard Time",
+	"Iran Standard Time",
+	"Israel Standard Time",
+	"Jordan Standard Time",
+	"Kaliningrad Standard Time",
+	"Kamchatka Standard Time",
+	"Korea Standard Time",
+	"Libya Standard Time",
+	"Line Islands Standard Time",
+	"Lord Howe Standard Time",
+	"Magadan Standard Time",
+	"Magallanes Standard Time",
+	"Marquesas Standard Time",
+	"Mauritius Standard Time",
+	"Mid-Atlantic Standard Time",
+	"Middle East Standard Time",
+	"Montevideo Standard Time",
+	"Morocco Standard Time",
+	"Mountain Standard Time",
+	"Mountain Standard Time (Mexico)",
+	"Myanmar Standard Time",
+	"N. Central Asia Standard Time",
+	"Namibia Standard Time",
+	"Nepal Standard Time",
+	"New Zealand Standard Time",
+	"Newfoundland Standard Time",
+	"Norfolk Standard Time",
+	"North Asia East Standard Time",
+	"North Asia Standard Time",
+	"North Korea Standard Time",
+	"Omsk Standard Time",
+	"Pacific SA Standard Time",
+	"Pacific Standard Time",
+	"Pacific Standard Time (Mexico)",
+	"Pakistan Standard Time",
+	"Paraguay Standard Time",
+	"Qyzylorda Standard Time",
+	"Romance Standard Time",
+	"Russia Time Zone 10",
+	"Russia Time Zone 11",
+	"Russia Time Zone 3",
+	"Russian Standard Time",
+	"SA Eastern Standard Time",
+	"SA Pacific Standard Time",
+	"SA Western Standard Time",
+	"Saint Pierre Standard Time",
+	"Sakhalin Standard Time",
+	"Samoa Standard Time",
+	"Sao Tome Standard Time",
+	"Saratov Standard Time",
+	"SE Asia Standard Time",
+	"Singapore Standard Time",
+	"South Africa Standard Time",
+	"South Sudan Standard Time",
+	"Sri Lanka Standard Time",
+	"Sudan Standard Time",
+	"Syria Standard Time",
+	"Taipei Standard Time",
+	"Tasmania Standard Time",
+	"Tocantins Standard Time",
+	"Tokyo Standard Time",
+	"Tomsk Standard Time",
+	"Tonga Standard Time",
+	"Transbaikal Standard Time",
+	"Turkey Standard Time",
+	"Turks And Caicos Standard Time",
+	"Ulaanbaatar Standard Time",
+	"US Eastern Standard Time",
+	"US Mountain Standard Time",
+	"UTC",
+	"UTC-02",
+	"UTC-08",
+	"UTC-09",
+	"UTC-11",
+	"UTC+12",
+	"UTC+13",
+	"Venezuela Standard Time",
+	"Vladivostok Standard Time",
+	"Volgograd Standard Time",
+	"W. Australia Standard Time",
+	"W. Central Africa Standard Time",
+	"W. Europe Standard Time",
+	"W. Mongolia Standard Time",
+	"West Asia Standard Time",
+	"West Bank Standard Time",
+	"West Pacific Standard Time",
+	"Yakutsk Standard Time",
+	"Yukon Standard Time",
+}
+
+// autoscaleRecurrenceIANATimeZones maps commonly used IANA Time Zone names (lower-cased) to the equivalent Windows Time
+// Zone identifier, which are suggested when an IANA name is specified
+var autoscaleRecurrenceIANATimeZones = map[string]string{
+	"america/chicago":     "Central Standard Time",
+	"america/denver":      "Mountain Standard Time",
+	"america/los_angeles": "Pacific Standard Time",
+	"america/new_york":    "Eastern Standard Time",
+	"america/sao_paulo":   "E. South America Standard Time",
+	"asia/kolkata":        "India Standard Time",
+	"asia/shanghai":       "China Standard Time",
+	"asia/singapore":      "Singapore Standard Time",
+	"asia/tokyo":          "Tokyo Standard Time",
+	"australia/sydney":    "AUS Eastern Standard Time",
+	"etc/utc":             "UTC",
+	"europe/berlin":       "W. Europe Standard Time",
+	"europe/london":       "GMT Standard Time",
+	"europe/moscow":       "Russian Standard Time",
+	"europe/paris":        "Romance Standard Time",
+	"europe/warsaw":       "Central European Standard Time",
+}
+
+// AutoscaleRecurrenceTimeZone validates the value is one of the AutoscaleRecurrenceTimeZones (ignoring the casing),
+// suggesting the closest matches when it isn't
+func AutoscaleRecurrenceTimeZone(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %q to be string", k))
+		return warnings, errors
+	}
+
+	if NormalizeAutoscaleRecurrenceTimeZone(v) != "" {
+		return warnings, errors
+	}
+
+	message := fmt.Sprintf("%q must be a Windows Time Zone identifier such as \"Pacific Standard Time\" or \"UTC\" - got %q", k, v)
+	switch {
+	case strings.TrimSpace(v) != v && NormalizeAutoscaleRecurrenceTimeZone(strings.TrimSpace(v)) != "":
+		message = fmt.Sprintf("%s, which contains leading or trailing whitespace - did you mean %q?", message, NormalizeAutoscaleRecurrenceTimeZone(strings.TrimSpace(v)))
+	case strings.Contains(v, "/"):
+		message = fmt.Sprintf("%s, IANA Time Zone names aren't supported", message)
+		if timeZone, ok := autoscaleRecurrenceIANATimeZones[strings.ToLower(strings.TrimSpace(v))]; ok {
+			message = fmt.Sprintf("%s - did you mean %q?", message, timeZone)
+		}
+	default:
+		if suggestions := autoscaleRecurrenceTimeZoneSuggestions(v); len(suggestions) > 0 {
+			message = fmt.Sprintf("%s, did you mean %s?", message, strings.Join(suggestions, " or "))
+		}
+	}
+	errors = append(errors, fmt.Errorf("%s", message))
+
+	return warnings, errors
+}
+
+// NormalizeAutoscaleRecurrenceTimeZone returns the canonical casing of the Time Zone, or an empty string when the
+// value isn't a supported Time Zone
+func NormalizeAutoscaleRecurrenceTimeZone(input string) string {
+	for _, v := range AutoscaleRecurrenceTimeZones {
+		if strings.EqualFold(v, input) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// autoscaleRecurrenceTimeZoneSuggestions returns (up to) the three Time Zones closest to the input, ignoring those
+// which are too different from it to be a typo
+func autoscaleRecurrenceTimeZoneSuggestions(input string) []string {
+	type candidate struct {
+		timeZone string
+		distance int
+	}
+
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return nil
+	}
+
+	maxDistance := len(input) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	candidates := make([]candidate, 0)
+	for _, v := range AutoscaleRecurrenceTimeZones {
+		if distance := levenshteinDistance(input, strings.ToLower(v)); distance <= maxDistance {
+			candidates = append(candidates, candidate{timeZone: v, distance: distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	suggestions := make([]string, 0)
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		suggestions = append(suggestions, fmt.Sprintf("%q", candidates[i].timeZone))
+	}
+
+	return suggestions
+}
+
+func levenshteinDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoscaleRecurrenceTimeZone(t *testing.T) {
+	testData := []struct {
+		input    string
+		expected bool
+	}{
+		{
+			input:    "",
+			expected: false,
+		},
+		{
+			input:    "Pacific Standard Time",
+			expected: true,
+		},
+		{
+			input:    "Pacific Standard time",
+			expected: true,
+		},
+		{
+			input:    "utc",
+			expected: true,
+		},
+		{
+			input:    "Pacfic Standard Time",
+			expected: false,
+		},
+		{
+			input:    "America/Los_Angeles",
+			expected: false,
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.input)
+
+		_, errors := AutoscaleRecurrenceTimeZone(v.input, "timezone")
+		actual := len(errors) == 0
+		if v.expected != actual {
+			t.Fatalf("Expected %t but got %t", v.expected, actual)
+		}
+	}
+}
+
+func TestAutoscaleRecurrenceTimeZoneSuggestions(t *testing.T) {
+	_, errors := AutoscaleRecurrenceTimeZone("Pacfic Standard Time", "timezone")
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error but got %d", len(errors))
+	}
+	if !strings.Contains(errors[0].Error(), `did you mean "Pacific Standard Time"`) {
+		t.Fatalf("Expected the error to suggest %q but got %q", "Pacific Standard Time", errors[0].Error())
+	}
+}
+
+func TestAutoscaleRecurrenceTimeZoneMessages(t *testing.T) {
+	testData := map[string]string{
+		"Central Europe Standard Time ": `which contains leading or trailing whitespace - did you mean "Central Europe Standard Time"?`,
+		"Europe/Paris":                  `IANA Time Zone names aren't supported - did you mean "Romance Standard Time"?`,
+		"Pacific/Chatham":               "IANA Time Zone names aren't supported",
+	}
+
+	for input, expected := range testData {
+		_, errors := AutoscaleRecurrenceTimeZone(input, "timezone")
+		if len(errors) != 1 || !strings.Contains(errors[0].Error(), expected) {
+			t.Fatalf("Expected an error containing %q for %q but got %+v", expected, input, errors)
+		}
+	}
+}
+
+func TestAutoscaleRecurrenceIANATimeZonesAreSupported(t *testing.T) {
+	for iana, timeZone := range autoscaleRecurrenceIANATimeZones {
+		if NormalizeAutoscaleRecurrenceTimeZone(timeZone) != timeZone {
+			t.Fatalf("Expected %q (suggested for %q) to be a supported Time Zone", timeZone, iana)
+		}
+	}
+}
+
+func TestNormalizeAutoscaleRecurrenceTimeZone(t *testing.T) {
+	testData := map[string]string{
+		"pacific standard TIME": "Pacific Standard Time",
+		"utc+12":                "UTC+12",
+		"Mars Standard Time":    "",
+	}
+
+	for input, expected := range testData {
+		if actual := NormalizeAutoscaleRecurrenceTimeZone(input); actual != expected {
+			t.Fatalf("Expected %q to be normalized to %q but got %q", input, expected, actual)
+		}
+	}
+}
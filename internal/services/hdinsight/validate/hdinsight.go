@@ -6,6 +6,7 @@ package validate
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 func HDInsightClusterVersion(i interface{}, k string) (warnings []string, errors []error) {
@@ -25,13 +26,34 @@ func HDInsightClusterVersion(i interface{}, k string) (warnings []string, errors
 	return warnings, errors
 }
 
+// hdInsightEndpointDNSLabelSuffixes are appended to the DNS label of the cluster to build the labels of the SSH and
+// private endpoints, for example `example-ssh.azurehdinsight.net`
+var hdInsightEndpointDNSLabelSuffixes = []string{"-ssh", "-int"}
+
 func HDInsightName(v interface{}, k string) (warnings []string, errors []error) {
 	value := v.(string)
 
 	// The name must be 59 characters or less and can contain letters, numbers, and hyphens (but the first and last character must be a letter or number).
 	if matched := regexp.MustCompile(`(^[a-zA-Z0-9])([a-zA-Z0-9-]{1,57})([a-zA-Z0-9]$)`).Match([]byte(value)); !matched {
 		errors = append(errors, fmt.Errorf("%q must be 59 characters or less and can contain letters, numbers, and hyphens (but the first and last character must be a letter or number).", k))
+		return warnings, errors
+	}
+
+	// the endpoints of the cluster are built from the name, each of which must fit within a 63 character DNS label
+	label := HDInsightEndpointDNSLabel(value)
+	for _, suffix := range hdInsightEndpointDNSLabelSuffixes {
+		if len(label+suffix) > 63 {
+			// the DNS suffix of the endpoint depends on the Azure environment, so only the label is included
+			errors = append(errors, fmt.Errorf("%q must be %d characters or less, since the label %q of the endpoint must fit within a 63 character DNS label - got %d characters", k, 63-len(suffix), label+suffix, len(value)))
+			break
+		}
 	}
 
 	return warnings, errors
 }
+
+// HDInsightEndpointDNSLabel returns the DNS label used for the `*.azurehdinsight.net` endpoints of the cluster with the
+// specified name - since DNS is case-insensitive, names differing only by case share the same label
+func HDInsightEndpointDNSLabel(name string) string {
+	return strings.ToLower(name)
+}
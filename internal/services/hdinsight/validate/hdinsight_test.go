@@ -3,7 +3,10 @@
 
 package validate
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestHDInsightClusterVersion(t *testing.T) {
 	tests := []struct {
@@ -60,3 +63,59 @@ func TestHDInsightClusterVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestHDInsightName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		valid bool
+	}{
+		{
+			name:  "too short",
+			input: "ab",
+			valid: false,
+		},
+		{
+			name:  "mixed case",
+			input: "Example-Cluster1",
+			valid: true,
+		},
+		{
+			name:  "ends with a hyphen",
+			input: "example-",
+			valid: false,
+		},
+		{
+			name:  "longest name whose endpoints fit within a DNS label",
+			input: strings.Repeat("a", 59),
+			valid: true,
+		},
+		{
+			name:  "too long",
+			input: strings.Repeat("a", 60),
+			valid: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errors := HDInsightName(tt.input, "name")
+			validationFailed := len(errors) > 0
+
+			if tt.valid && validationFailed {
+				t.Errorf("Expected %q to be valid but got %+v", tt.input, errors)
+			} else if !tt.valid && !validationFailed {
+				t.Errorf("Expected %q to be invalid but didn't get an error", tt.input)
+			}
+		})
+	}
+}
+
+func TestHDInsightEndpointDNSLabel(t *testing.T) {
+	if actual := HDInsightEndpointDNSLabel("Example-Cluster1"); actual != "example-cluster1" {
+		t.Fatalf("Expected %q but got %q", "example-cluster1", actual)
+	}
+
+	if HDInsightEndpointDNSLabel("example") != HDInsightEndpointDNSLabel("EXAMPLE") {
+		t.Fatalf("Expected names differing only by case to share the same DNS label")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeDefinitionInstanceCount validates the number of instances of a role against the limits for that kind of cluster,
+// naming the kind and role in the error since otherwise these are only rejected by the API once the cluster is being
+// provisioned. A `max` of 0 means there is no upper limit.
+func NodeDefinitionInstanceCount(clusterKind, nodeType string, min, max int) func(interface{}, string) ([]string, []error) {
+	role := strings.TrimSpace(fmt.Sprintf("%s %s", clusterKind, nodeType))
+
+	return func(i interface{}, k string) (warnings []string, errors []error) {
+		v, ok := i.(int)
+		if !ok {
+			errors = append(errors, fmt.Errorf("expected type of %q to be integer", k))
+			return warnings, errors
+		}
+
+		if v < min {
+			errors = append(errors, fmt.Errorf("%s requires at least %d %s but %q is %d", role, min, pluralizeInstances(min), k, v))
+		}
+		if max > 0 && v > max {
+			errors = append(errors, fmt.Errorf("%s supports at most %d %s but %q is %d", role, max, pluralizeInstances(max), k, v))
+		}
+
+		return warnings, errors
+	}
+}
+
+func pluralizeInstances(count int) string {
+	if count == 1 {
+		return "instance"
+	}
+	return "instances"
+}
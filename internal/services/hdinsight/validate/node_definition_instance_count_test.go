@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNodeDefinitionInstanceCount(t *testing.T) {
+	testData := []struct {
+		clusterKind string
+		nodeType    string
+		min         int
+		max         int
+		input       int
+		expected    string
+	}{
+		{
+			clusterKind: "Spark",
+			nodeType:    "worker_node",
+			min:         1,
+			input:       0,
+			expected:    "Spark worker_node requires at least 1 instance but",
+		},
+		{
+			clusterKind: "Spark",
+			nodeType:    "worker_node",
+			min:         1,
+			input:       1,
+		},
+		{
+			clusterKind: "Spark",
+			nodeType:    "worker_node",
+			min:         1,
+			input:       250,
+		},
+		{
+			clusterKind: "Hadoop",
+			nodeType:    "edge_node",
+			min:         1,
+			max:         25,
+			input:       26,
+			expected:    "Hadoop edge_node supports at most 25 instances but",
+		},
+		{
+			nodeType: "worker_node",
+			min:      2,
+			input:    -1,
+			expected: "worker_node requires at least 2 instances but",
+		},
+	}
+
+	for _, v := range testData {
+		_, errors := NodeDefinitionInstanceCount(v.clusterKind, v.nodeType, v.min, v.max)(v.input, "target_instance_count")
+		if v.expected == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected %d to be valid for the %s %s but got %+v", v.input, v.clusterKind, v.nodeType, errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 || !strings.HasPrefix(errors[0].Error(), v.expected) {
+			t.Fatalf("Expected an error starting with %q for %d but got %+v", v.expected, v.input, errors)
+		}
+	}
+}
@@ -3,6 +3,11 @@
 
 package validate
 
+import (
+	"fmt"
+	"strings"
+)
+
 // short of deploying every VM Sku for every node type for every HDInsight Cluster
 // this is the list I've (@tombuildsstuff) found for valid SKU's from an endpoint in the Portal
 // using another SKU causes a bad request from the API - as such this is a best effort UX
@@ -108,3 +113,150 @@ var NodeDefinitionVMSize = []string{
 	"Standard_GS5",
 	"Standard_NC24",
 }
+
+// KafkaManagementNodeVMSize are the VM SKU's which can be used for the Kafka Management Nodes, which host the
+// Kafka REST Proxy - the API only allows a subset of the SKU's supported by the other node types
+var KafkaManagementNodeVMSize = []string{
+	"Standard_A4_V2",
+	"Standard_A4m_V2",
+	"Standard_A8_V2",
+	"Standard_A8m_V2",
+	"Standard_D3_V2",
+	"Standard_D4_V2",
+	"Standard_D5_V2",
+	"Standard_D4a_V4",
+	"Standard_D8a_V4",
+	"Standard_E4_V3",
+	"Standard_E8_V3",
+}
+
+// NodeDefinitionVMSizeCores are the number of cores of each of the VM SKU's above, which count against the regional
+// HDInsight core quota of the subscription
+var NodeDefinitionVMSizeCores = map[string]int{
+	"ExtraSmall":        1,
+	"Small":             1,
+	"Medium":            2,
+	"Large":             4,
+	"ExtraLarge":        8,
+	"A5":                2,
+	"A6":                4,
+	"A7":                8,
+	"A8":                8,
+	"A9":                16,
+	"A10":               8,
+	"A11":               16,
+	"Standard_A1_V2":    1,
+	"Standard_A2_V2":    2,
+	"Standard_A2m_V2":   2,
+	"Standard_A3":       4,
+	"Standard_A4_V2":    4,
+	"Standard_A4m_V2":   4,
+	"Standard_A8_V2":    8,
+	"Standard_A8m_V2":   8,
+	"Standard_D1":       1,
+	"Standard_D2":       2,
+	"Standard_D3":       4,
+	"Standard_D4":       8,
+	"Standard_D11":      2,
+	"Standard_D12":      4,
+	"Standard_D13":      8,
+	"Standard_D14":      16,
+	"Standard_D1_V2":    1,
+	"Standard_D2_V2":    2,
+	"Standard_D3_V2":    4,
+	"Standard_D4_V2":    8,
+	"Standard_D5_V2":    16,
+	"Standard_D11_V2":   2,
+	"Standard_D12_V2":   4,
+	"Standard_D13_V2":   8,
+	"Standard_D14_V2":   16,
+	"Standard_DS1_V2":   1,
+	"Standard_DS2_V2":   2,
+	"Standard_DS3_V2":   4,
+	"Standard_DS4_V2":   8,
+	"Standard_DS5_V2":   16,
+	"Standard_DS11_V2":  2,
+	"Standard_DS12_V2":  4,
+	"Standard_DS13_V2":  8,
+	"Standard_DS14_V2":  16,
+	"Standard_E2_V3":    2,
+	"Standard_E4_V3":    4,
+	"Standard_E8_V3":    8,
+	"Standard_E16_V3":   16,
+	"Standard_E20_V3":   20,
+	"Standard_E32_V3":   32,
+	"Standard_E64_V3":   64,
+	"Standard_E64i_V3":  64,
+	"Standard_E2s_V3":   2,
+	"Standard_E4s_V3":   4,
+	"Standard_E8s_V3":   8,
+	"Standard_E16s_V3":  16,
+	"Standard_E20s_V3":  20,
+	"Standard_E32s_V3":  32,
+	"Standard_E64s_V3":  64,
+	"Standard_E64is_V3": 64,
+	"Standard_D2a_V4":   2,
+	"Standard_D4a_V4":   4,
+	"Standard_D8a_V4":   8,
+	"Standard_D16a_V4":  16,
+	"Standard_D32a_V4":  32,
+	"Standard_D48a_V4":  48,
+	"Standard_D64a_V4":  64,
+	"Standard_D96a_V4":  96,
+	"Standard_E2a_V4":   2,
+	"Standard_E4a_V4":   4,
+	"Standard_E8a_V4":   8,
+	"Standard_E16a_V4":  16,
+	"Standard_E20a_V4":  20,
+	"Standard_E32a_V4":  32,
+	"Standard_E48a_V4":  48,
+	"Standard_E64a_V4":  64,
+	"Standard_E96a_V4":  96,
+	"Standard_G1":       2,
+	"Standard_G2":       4,
+	"Standard_G3":       8,
+	"Standard_G4":       16,
+	"Standard_G5":       32,
+	"Standard_F2s_V2":   2,
+	"Standard_F4s_V2":   4,
+	"Standard_F8s_V2":   8,
+	"Standard_F16s_V2":  16,
+	"Standard_F32s_V2":  32,
+	"Standard_F64s_V2":  64,
+	"Standard_F72s_V2":  72,
+	"Standard_GS1":      2,
+	"Standard_GS2":      4,
+	"Standard_GS3":      8,
+	"Standard_GS4":      16,
+	"Standard_GS5":      32,
+	"Standard_NC24":     24,
+}
+
+// NodeDefinitionVMSizeIn validates that the VM Size is one of the specified VM SKU's, explaining why the burstable
+// (B-series) SKU's and the SKU's which HDInsight supports but not for this type of node can't be used
+func NodeDefinitionVMSizeIn(vmSizes []string, nodeType string) func(interface{}, string) ([]string, []error) {
+	return func(i interface{}, k string) (warnings []string, errors []error) {
+		v, ok := i.(string)
+		if !ok {
+			errors = append(errors, fmt.Errorf("expected type of %q to be string", k))
+			return warnings, errors
+		}
+
+		for _, vmSize := range vmSizes {
+			if v == vmSize {
+				return warnings, errors
+			}
+		}
+
+		switch {
+		case strings.HasPrefix(strings.ToLower(v), "standard_b"):
+			errors = append(errors, fmt.Errorf("%q must be a VM Size supported by HDInsight but got the burstable (B-series) VM Size %q - burstable VM Sizes (as well as Spot and Low Priority VMs) aren't supported by HDInsight since the cluster services need sustained CPU, consider a smaller number of general purpose nodes such as `Standard_A4_V2` or `Standard_D3_V2` instead", k, v))
+		case NodeDefinitionVMSizeCores[v] > 0:
+			errors = append(errors, fmt.Errorf("%q of %q is supported by HDInsight but can't be used for the `%s` - expected one of %s", k, v, nodeType, strings.Join(vmSizes, ", ")))
+		default:
+			errors = append(errors, fmt.Errorf("expected %s to be one of %q, got %s", k, vmSizes, v))
+		}
+
+		return warnings, errors
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNodeDefinitionVMSizeCores(t *testing.T) {
+	for _, vmSize := range NodeDefinitionVMSize {
+		if cores, ok := NodeDefinitionVMSizeCores[vmSize]; !ok || cores <= 0 {
+			t.Fatalf("Expected the number of cores to be known for the VM Size %q", vmSize)
+		}
+	}
+}
+
+func TestNodeDefinitionVMSizeIn(t *testing.T) {
+	testData := []struct {
+		vmSizes  []string
+		nodeType string
+		input    string
+		expected string
+	}{
+		{
+			vmSizes:  NodeDefinitionVMSize,
+			nodeType: "worker_node",
+			input:    "Standard_D3_V2",
+		},
+		{
+			vmSizes:  NodeDefinitionVMSize,
+			nodeType: "worker_node",
+			input:    "Standard_B4ms",
+			expected: "burstable (B-series)",
+		},
+		{
+			vmSizes:  NodeDefinitionVMSize,
+			nodeType: "head_node",
+			input:    "Standard_B2s",
+			expected: "burstable (B-series)",
+		},
+		{
+			vmSizes:  NodeDefinitionVMSize,
+			nodeType: "worker_node",
+			input:    "Standard_D1_V2",
+		},
+		{
+			vmSizes:  NodeDefinitionVMSize,
+			nodeType: "zookeeper_node",
+			input:    "Standard_D1_V2",
+		},
+		{
+			vmSizes:  KafkaManagementNodeVMSize,
+			nodeType: "kafka_management_node",
+			input:    "Standard_D14_V2",
+			expected: "can't be used for the `kafka_management_node`",
+		},
+		{
+			vmSizes:  NodeDefinitionVMSize,
+			nodeType: "worker_node",
+			input:    "Standard_Unknown",
+			expected: "to be one of",
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q for the %s..", v.input, v.nodeType)
+
+		_, errors := NodeDefinitionVMSizeIn(v.vmSizes, v.nodeType)(v.input, "vm_size")
+		if v.expected == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected no errors but got %+v", errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 || !strings.Contains(errors[0].Error(), v.expected) {
+			t.Fatalf("Expected an error containing %q but got %+v", v.expected, errors)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+// RestProxySecurityGroupID validates the Object ID of the Azure Active Directory Group allowed to use the Kafka REST
+// proxy - calling out the display name of the group or a Resource ID being specified instead, which the API only
+// rejects once the cluster is being provisioned
+func RestProxySecurityGroupID(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %q to be string", k))
+		return warnings, errors
+	}
+
+	if _, err := uuid.ParseUUID(v); err == nil {
+		return warnings, errors
+	}
+
+	switch {
+	case v == "":
+		errors = append(errors, fmt.Errorf("%q must be the Object ID (a GUID) of an Azure Active Directory Group and cannot be empty", k))
+	case strings.HasPrefix(v, "/") || strings.Contains(strings.ToLower(v), "/providers/"):
+		errors = append(errors, fmt.Errorf("%q must be the Object ID (a GUID) of an Azure Active Directory Group, rather than a Resource ID - got %q", k, v))
+	default:
+		errors = append(errors, fmt.Errorf("%q must be the Object ID (a GUID) of an Azure Active Directory Group, rather than its display name (which is specified using `security_group_name`) - got %q", k, v))
+	}
+
+	return warnings, errors
+}
+
+// RestProxySecurityGroupName validates the display name of the Azure Active Directory Group allowed to use the Kafka
+// REST proxy, calling out the Object ID of the group being specified instead
+func RestProxySecurityGroupName(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %q to be string", k))
+		return warnings, errors
+	}
+
+	if strings.TrimSpace(v) == "" {
+		errors = append(errors, fmt.Errorf("%q must be the display name of an Azure Active Directory Group and cannot be empty", k))
+		return warnings, errors
+	}
+
+	if _, err := uuid.ParseUUID(v); err == nil {
+		errors = append(errors, fmt.Errorf("%q must be the display name of an Azure Active Directory Group, rather than its Object ID (which is specified using `security_group_id`) - got %q", k, v))
+	}
+
+	return warnings, errors
+}
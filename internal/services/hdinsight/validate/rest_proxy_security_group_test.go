@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRestProxySecurityGroupID(t *testing.T) {
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "",
+			expected: "cannot be empty",
+		},
+		{
+			input: "6ca9b7d0-3a54-4fd3-b7c4-a8c5e3b7c4a1",
+		},
+		{
+			input: "6CA9B7D0-3A54-4FD3-B7C4-A8C5E3B7C4A1",
+		},
+		{
+			input:    "kafka-rest-proxy-users",
+			expected: "rather than its display name",
+		},
+		{
+			input:    "/subscriptions/12345678-1234-9876-4563-123456789012/resourceGroups/group1/providers/Microsoft.ManagedIdentity/userAssignedIdentities/identity1",
+			expected: "rather than a Resource ID",
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.input)
+
+		_, errors := RestProxySecurityGroupID(v.input, "security_group_id")
+		if v.expected == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected no errors but got %+v", errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 || !strings.Contains(errors[0].Error(), v.expected) {
+			t.Fatalf("Expected an error containing %q but got %+v", v.expected, errors)
+		}
+	}
+}
+
+func TestRestProxySecurityGroupName(t *testing.T) {
+	testData := []struct {
+		input    string
+		expected string
+	}{
+		{
+			input:    "",
+			expected: "cannot be empty",
+		},
+		{
+			input:    "  ",
+			expected: "cannot be empty",
+		},
+		{
+			input: "kafka-rest-proxy-users",
+		},
+		{
+			input:    "6ca9b7d0-3a54-4fd3-b7c4-a8c5e3b7c4a1",
+			expected: "rather than its Object ID",
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.input)
+
+		_, errors := RestProxySecurityGroupName(v.input, "security_group_name")
+		if v.expected == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected no errors but got %+v", errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 || !strings.Contains(errors[0].Error(), v.expected) {
+			t.Fatalf("Expected an error containing %q but got %+v", v.expected, errors)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// storageAccountHostRegex matches the Blob and Data Lake Gen2 endpoints of a storage account across the Azure
+// environments, capturing the name of the account
+var storageAccountHostRegex = regexp.MustCompile(`^([a-z0-9]{3,24})\.(blob|dfs)\.core\.`)
+
+// ScriptActionURI validates the URI of a script action, since a malformed URI is otherwise only rejected once the
+// nodes try to download the script - which for a new cluster is well into provisioning
+func ScriptActionURI(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	if strings.ContainsAny(v, " \t\r\n") {
+		errors = append(errors, fmt.Errorf("expected %s to not contain unescaped whitespace (spaces should be encoded as `%%20`) but got %q", k, redactScriptActionURI(v)))
+		return
+	}
+
+	uri, err := url.Parse(v)
+	if err != nil {
+		// the error includes the whole URI, so only the reason is output
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		errors = append(errors, fmt.Errorf("expected %s to be a valid URL but got %q: %+v", k, redactScriptActionURI(v), err))
+		return
+	}
+
+	if !strings.EqualFold(uri.Scheme, "https") {
+		errors = append(errors, fmt.Errorf("expected %s to use the https scheme, such as %q, but got %q", k, "https://example.blob.core.windows.net/scripts/install.sh", redactScriptActionURI(v)))
+		return
+	}
+
+	if uri.Host == "" {
+		errors = append(errors, fmt.Errorf("expected %s to include a host but got %q", k, redactScriptActionURI(v)))
+		return
+	}
+
+	// the script is downloaded from a blob, so a URI of a storage account must include both the container and the blob
+	if _, ok := HDInsightStorageAccountName(uri.Host); ok {
+		segments := strings.Split(strings.Trim(uri.Path, "/"), "/")
+		if len(segments) < 2 || strings.HasSuffix(uri.Path, "/") {
+			errors = append(errors, fmt.Errorf("expected %s to be the URI of a blob containing the script, rather than a storage account or container, but got %q", k, redactScriptActionURI(v)))
+		}
+	}
+
+	return
+}
+
+// HDInsightStorageAccountName returns the name of the storage account when the host is the Blob or Data Lake Gen2
+// endpoint of a storage account
+func HDInsightStorageAccountName(host string) (string, bool) {
+	matches := storageAccountHostRegex.FindStringSubmatch(strings.ToLower(host))
+	if len(matches) < 2 {
+		return "", false
+	}
+
+	return matches[1], true
+}
+
+// redactScriptActionURI removes the query string from the URI, which can contain a SAS token that shouldn't be output
+func redactScriptActionURI(input string) string {
+	if i := strings.Index(input, "?"); i != -1 {
+		return input[:i]
+	}
+
+	return input
+}
@@ -0,0 +1,108 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptActionURI(t *testing.T) {
+	testData := []struct {
+		input string
+		err   string
+	}{
+		{
+			input: "https://example.blob.core.windows.net/scripts/install.sh",
+		},
+		{
+			input: "https://example.blob.core.windows.net/scripts/nested/install.sh?sv=2021-06-08&sig=abc%3D",
+		},
+		{
+			input: "https://raw.githubusercontent.com/Azure/azure-quickstart-templates/master/101-hdinsight-linux-with-edge-node/scripts/EmptyNodeSetup.sh",
+		},
+		{
+			input: "https://example.com/install%20agent.sh",
+		},
+		{
+			input: "example.blob.core.windows.net/scripts/install.sh",
+			err:   "to use the https scheme",
+		},
+		{
+			input: "http://example.blob.core.windows.net/scripts/install.sh",
+			err:   "to use the https scheme",
+		},
+		{
+			input: "https:///scripts/install.sh",
+			err:   "to include a host",
+		},
+		{
+			input: "https://example.com/install agent.sh",
+			err:   "to not contain unescaped whitespace",
+		},
+		{
+			input: "https://example.com/%zz/install.sh",
+			err:   "to be a valid URL",
+		},
+		{
+			input: "https://example.blob.core.windows.net/scripts",
+			err:   "rather than a storage account or container",
+		},
+		{
+			input: "https://example.blob.core.windows.net/scripts/",
+			err:   "rather than a storage account or container",
+		},
+		{
+			input: "https://example.dfs.core.windows.net",
+			err:   "rather than a storage account or container",
+		},
+		{
+			// the SAS token mustn't be output
+			input: "https://example.blob.core.windows.net/scripts?sig=secret",
+			err:   `"https://example.blob.core.windows.net/scripts"`,
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q", v.input)
+
+		_, errors := ScriptActionURI(v.input, "uri")
+		if v.err == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected %q to be valid but got %+v", v.input, errors)
+			}
+			continue
+		}
+
+		if len(errors) != 1 {
+			t.Fatalf("Expected a single error for %q but got %+v", v.input, errors)
+		}
+		if !strings.Contains(errors[0].Error(), v.err) {
+			t.Fatalf("Expected the error for %q to contain %q but got %q", v.input, v.err, errors[0].Error())
+		}
+		if strings.Contains(errors[0].Error(), "secret") {
+			t.Fatalf("Expected the SAS token to be redacted from the error but got %q", errors[0].Error())
+		}
+	}
+}
+
+func TestHDInsightStorageAccountName(t *testing.T) {
+	testData := []struct {
+		host     string
+		expected string
+	}{
+		{host: "example.blob.core.windows.net", expected: "example"},
+		{host: "Example.DFS.core.windows.net", expected: "example"},
+		{host: "example.blob.core.chinacloudapi.cn", expected: "example"},
+		{host: "example.file.core.windows.net"},
+		{host: "raw.githubusercontent.com"},
+	}
+
+	for _, v := range testData {
+		actual, ok := HDInsightStorageAccountName(v.host)
+		if ok != (v.expected != "") || actual != v.expected {
+			t.Fatalf("Expected %q for %q but got %q (%t)", v.expected, v.host, actual, ok)
+		}
+	}
+}
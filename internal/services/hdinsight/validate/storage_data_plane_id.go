@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var storageDataPlaneNameRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// HDInsightStorageContainerID validates the URL of the Blob Storage Container used by a cluster, since the name of the
+// container is otherwise only validated by the storage layer once the cluster is being provisioned
+func HDInsightStorageContainerID(i interface{}, k string) (warnings []string, errors []error) {
+	return hdinsightStorageDataPlaneID(i, k, "container", "https://example.blob.core.windows.net/example")
+}
+
+// HDInsightStorageFilesystemID validates the URL of the Data Lake Gen2 Filesystem used by a cluster
+func HDInsightStorageFilesystemID(i interface{}, k string) (warnings []string, errors []error) {
+	return hdinsightStorageDataPlaneID(i, k, "filesystem", "https://example.dfs.core.windows.net/example")
+}
+
+func hdinsightStorageDataPlaneID(i interface{}, k, segment, example string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return
+	}
+
+	uri, err := url.Parse(v)
+	if err != nil || uri.Host == "" {
+		errors = append(errors, fmt.Errorf("expected %s to be a URL such as %q but got %q", k, example, v))
+		return
+	}
+
+	name := strings.TrimPrefix(uri.Path, "/")
+	if name == "" || strings.Contains(name, "/") {
+		errors = append(errors, fmt.Errorf("expected the path of %s to only contain the name of the %s, such as %q, but got %q", k, segment, example, v))
+		return
+	}
+
+	if len(name) < 3 || len(name) > 63 {
+		errors = append(errors, fmt.Errorf("the %s name %q in %s must be between 3 and 63 characters long", segment, name, k))
+	}
+
+	if !storageDataPlaneNameRegex.MatchString(name) {
+		errors = append(errors, fmt.Errorf("the %s name %q in %s can only contain lowercase letters, numbers and hyphens, must start and end with a letter or number, and can't contain consecutive hyphens", segment, name, k))
+	}
+
+	return
+}
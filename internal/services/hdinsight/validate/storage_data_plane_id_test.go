@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHDInsightStorageContainerID(t *testing.T) {
+	testData := []struct {
+		input string
+		err   string
+	}{
+		{
+			input: "",
+			err:   "to be a URL",
+		},
+		{
+			input: "example",
+			err:   "to be a URL",
+		},
+		{
+			input: "https://example.blob.core.windows.net",
+			err:   "to only contain the name of the container",
+		},
+		{
+			input: "https://example.blob.core.windows.net/example/directory",
+			err:   "to only contain the name of the container",
+		},
+		{
+			input: "https://example.blob.core.windows.net/ab",
+			err:   `the container name "ab" in storage_container_id must be between 3 and 63 characters long`,
+		},
+		{
+			input: "https://example.blob.core.windows.net/" + strings.Repeat("a", 64),
+			err:   "must be between 3 and 63 characters long",
+		},
+		{
+			input: "https://example.blob.core.windows.net/Example",
+			err:   `the container name "Example" in storage_container_id can only contain lowercase letters`,
+		},
+		{
+			input: "https://example.blob.core.windows.net/example_container",
+			err:   `the container name "example_container"`,
+		},
+		{
+			input: "https://example.blob.core.windows.net/-example",
+			err:   "must start and end with a letter or number",
+		},
+		{
+			input: "https://example.blob.core.windows.net/example--container",
+			err:   "can't contain consecutive hyphens",
+		},
+		{
+			input: "https://example.blob.core.windows.net/abc",
+		},
+		{
+			input: "https://example.blob.core.windows.net/example-container-01",
+		},
+		{
+			input: "https://example.blob.core.windows.net/" + strings.Repeat("a", 63),
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.input)
+
+		_, errors := HDInsightStorageContainerID(v.input, "storage_container_id")
+		if v.err == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected no errors but got %+v", errors)
+			}
+			continue
+		}
+
+		if len(errors) == 0 {
+			t.Fatalf("Expected an error containing %q but got none", v.err)
+		}
+		if !strings.Contains(errors[0].Error(), v.err) {
+			t.Fatalf("Expected an error containing %q but got %q", v.err, errors[0].Error())
+		}
+	}
+}
+
+func TestHDInsightStorageFilesystemID(t *testing.T) {
+	testData := []struct {
+		input string
+		err   string
+	}{
+		{
+			input: "https://example.dfs.core.windows.net/",
+			err:   "to only contain the name of the filesystem",
+		},
+		{
+			input: "https://example.dfs.core.windows.net/Example_Filesystem",
+			err:   `the filesystem name "Example_Filesystem" in filesystem_id`,
+		},
+		{
+			input: "https://example.dfs.core.windows.net/example-filesystem",
+		},
+	}
+
+	for _, v := range testData {
+		t.Logf("[DEBUG] Testing %q..", v.input)
+
+		_, errors := HDInsightStorageFilesystemID(v.input, "filesystem_id")
+		if v.err == "" {
+			if len(errors) != 0 {
+				t.Fatalf("Expected no errors but got %+v", errors)
+			}
+			continue
+		}
+
+		if len(errors) == 0 {
+			t.Fatalf("Expected an error containing %q but got none", v.err)
+		}
+		if !strings.Contains(errors[0].Error(), v.err) {
+			t.Fatalf("Expected an error containing %q but got %q", v.err, errors[0].Error())
+		}
+	}
+}